@@ -4,20 +4,53 @@ import (
 	"time"
 )
 
+const (
+	AccountKindFeed   = 0
+	AccountKindBundle = 1 // Boosts the toots of its member feed accounts
+)
+
+// Profile fields an admin has overridden; feed checks don't change them anymore
+const (
+	LockFeedName = 1 << iota
+	LockFeedSummary
+	LockProfileImage
+)
+
+// Feed metadata hash that matches no feed, so that the next check updates the profile from the feed
+const FeedMetaHashStale = -1
+
 type Account struct {
-	Id              int
-	CreatedAt       time.Time
-	UserUrl         string // https://rss-parrot.net/u/taiwantrailsandtales.com
-	Handle          string // taiwantrailsandtales.com
-	FeedName        string // taiwan trails and tales | a guide to get you out of the city and into the hills
-	FeedSummary     string // Taiwan Trails and Tales is a one-stop shop for everything Taiwan hiking related. Here you can find information about hundreds of hiking trails in Taiwan, as well as all the details you need to know about how and when to visit.
-	SiteUrl         string // https://taiwantrailsandtales.com
-	FeedUrl         string // https://taiwantrailsandtales.com/feed
-	FeedLastUpdated time.Time
-	NextCheckDue    time.Time
-	PubKey          string
-	ProfileImageUrl string
-	HeaderImageUrl  string
+	Id               int
+	Kind             int
+	CreatedAt        time.Time
+	UserUrl          string // https://rss-parrot.net/u/taiwantrailsandtales.com
+	Handle           string // taiwantrailsandtales.com
+	FeedName         string // taiwan trails and tales | a guide to get you out of the city and into the hills
+	FeedSummary      string // Taiwan Trails and Tales is a one-stop shop for everything Taiwan hiking related. Here you can find information about hundreds of hiking trails in Taiwan, as well as all the details you need to know about how and when to visit.
+	SiteUrl          string // https://taiwantrailsandtales.com
+	FeedUrl          string // https://taiwantrailsandtales.com/feed
+	FeedLastUpdated  time.Time
+	NextCheckDue     time.Time
+	PubKey           string
+	ProfileImageUrl  string
+	HeaderImageUrl   string
+	FeedMetaHash     int64     // Hash of title, description and image last seen in the feed; 0 if not yet known
+	ProfileUpdatedAt time.Time // Last time name/summary/image changed and an actor Update was sent
+	RelayToots       bool      // If true, the birb announces this account's toots to relays
+	ManuallyApproves bool      // If true, follow requests wait in a queue until an admin approves them
+	LastCheckedAt    time.Time // Last time the feed was fetched, successfully or not
+	FeedErrorCount   int       // Failed checks since the last successful one
+	LockedFields     int       // Combination of Lock... flags
+	Paused           bool      // If true, the feed is not checked
+	CheckIntervalMin int       // Fixed time between feed checks; if 0, it depends on how often the feed has new posts
+}
+
+func (a *Account) IsLocked(field int) bool {
+	return a.LockedFields&field != 0
+}
+
+func (a *Account) IsBundle() bool {
+	return a.Kind == AccountKindBundle
 }
 
 type Mention struct {
@@ -41,12 +74,89 @@ type Toot struct {
 }
 
 type TootQueueItem struct {
-	Id          int
-	SendingUser string
-	ToInbox     string
-	TootedAt    time.Time
-	StatusId    string
-	Content     string
+	Id            int
+	SendingUser   string
+	ToInbox       string
+	ToHost        string
+	Priority      int // Higher priority items are sent first
+	TootedAt      time.Time
+	StatusId      string
+	Content       string
+	Activity      string    // Serialized activity to send as-is; if empty, a Create Note is built from StatusId and Content
+	Attempts      int       // Failed delivery attempts so far
+	NextAttemptAt time.Time // Item is not picked up before this time
+	LastError     string
+}
+
+// Activity that arrived in an inbox, with a verified signature, waiting to be processed
+type InboundItem struct {
+	Id            int
+	ReceivingUser string // Whose inbox the activity was posted to; empty for the shared inbox
+	Sender        string // Serialized actor that signed the activity
+	SenderId      string // ID of that actor; one sender's items are processed one at a time, in order
+	Activity      string
+	ReceivedAt    time.Time
+	Attempts      int       // Failed processing attempts so far
+	NextAttemptAt time.Time // Item is not picked up before this time
+	LastError     string
+}
+
+// A failed check of an account's feed
+type FeedError struct {
+	AccountId  int
+	OccurredAt time.Time
+	Error      string
+}
+
+// Sort keys for listing accounts
+const (
+	AccountSortCreated   = "created"
+	AccountSortHandle    = "handle"
+	AccountSortFollowers = "followers"
+	AccountSortUpdated   = "updated" // Time of the feed's last new post
+)
+
+// Criteria for listing accounts; zero values don't filter
+type AccountFilter struct {
+	Search        string // Substring of the handle or the site URL
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	MinFollowers  int
+	MaxFollowers  int  // Negative for no upper limit
+	OnlyFailing   bool // Only accounts whose last feed check failed
+	IdleSince     time.Time
+	Sort          string
+	Desc          bool
+	AfterValue    any // Sort value of the last item on the previous page; nil for the first page
+	AfterId       int // ID of the last item on the previous page
+	Limit         int
+}
+
+// Settings an admin can change; only the non-nil fields are stored
+type AccountSettings struct {
+	FeedName         *string
+	FeedSummary      *string
+	ProfileImageUrl  *string
+	HeaderImageUrl   *string
+	FeedUrl          *string
+	FeedMetaHash     *int64
+	LockedFields     *int
+	Paused           *bool
+	CheckIntervalMin *int
+	NextCheckDue     *time.Time
+	RelayToots       *bool
+}
+
+// Account with the counts shown when listing accounts
+type AccountSummary struct {
+	*Account
+	FollowerCount int // Approved followers only
+}
+
+type TootQueueSummary struct {
+	Total    int
+	Due      int // Items whose next attempt is not in the future
+	Retrying int // Items that have failed at least once
 }
 
 type FollowerInfo struct {
@@ -58,3 +168,92 @@ type FollowerInfo struct {
 	UserInbox     string // https://genart.social/users/twilliability/inbox
 	SharedInbox   string // https://genart.social/inbox
 }
+
+type AccountTombstone struct {
+	Handle    string
+	DeletedAt time.Time
+	PubKey    string
+	PrivKey   string // Kept so that Deletes can still be signed, and a re-created account gets the same key
+}
+
+type InboxHealth struct {
+	Inbox         string
+	Host          string
+	LastSuccess   time.Time
+	FirstFailure  time.Time // Start of current failure streak
+	FailureStreak int       // Failed deliveries since the last success; 0 if inbox is healthy
+	LastError     string
+}
+
+const (
+	RelayPending  = 0
+	RelayAccepted = 1
+	RelayRejected = 2
+)
+
+// Our subscription to an ActivityPub relay
+type Relay struct {
+	Inbox        string
+	FollowId     string // ID of the Follow we sent, which the relay's Accept or Reject refers to
+	FollowObject string // What we followed: the public collection, or the relay's actor
+	Status       int
+	UpdatedAt    time.Time
+}
+
+// Domain block levels; each one includes the restrictions of the ones below it
+const (
+	DomainSilence       = 1 // Only follows and unfollows are processed from the domain
+	DomainRejectFollows = 2 // Follows from the domain are rejected
+	DomainSuspend       = 3 // Nothing is accepted from or delivered to the domain
+)
+
+type DomainBlock struct {
+	Domain    string
+	Level     int
+	Reason    string
+	CreatedAt time.Time
+}
+
+// Kinds of feed block rules
+const (
+	FeedBlockUrl    = "url"    // Exact feed URL, ignoring scheme and case
+	FeedBlockDomain = "domain" // Every feed on the domain and its subdomains
+	FeedBlockGlob   = "glob"   // Feed URL without scheme, where * matches any run of characters and ? a single one
+	FeedBlockRegex  = "regex"  // Regular expression on the feed URL without scheme, ignoring case
+)
+
+type FeedBlock struct {
+	Id        int
+	Kind      string
+	Pattern   string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// A Flag activity that someone sent about one of our accounts
+type Report struct {
+	Id            int
+	ActivityId    string
+	Reporter      string // URL of the actor who sent the Flag; often a remote instance's actor
+	AccountHandle string
+	StatusIds     []string // Reported statuses of the account, if any
+	Comment       string
+	CreatedAt     time.Time
+	ResolvedAt    time.Time
+	Resolution    string // Action taken when the report was resolved; empty while report is open
+}
+
+// Remote actor as we last fetched it; just what we need to verify signatures and deliver activities
+type CachedActor struct {
+	UserUrl           string
+	FetchedAt         time.Time
+	ActorType         string
+	PreferredUserName string
+	Inbox             string
+	SharedInbox       string
+	Followers         string
+	KeyId             string
+	KeyOwner          string
+	PublicKeyPem      string
+	AssertionKeys     string // Ed25519 keys from assertionMethod, as JSON
+}
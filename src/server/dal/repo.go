@@ -7,13 +7,14 @@ import (
 	"fmt"
 	"github.com/mattn/go-sqlite3"
 	"rss_parrot/shared"
+	"strings"
 	"sync"
 	"time"
 )
 
 //go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_repo.go -package mocks rss_parrot/dal IRepo
 
-const schemaVer = 6
+const schemaVer = 25
 
 //go:embed scripts/*
 var scripts embed.FS
@@ -25,9 +26,15 @@ type IRepo interface {
 	AddAccountIfNotExist(account *Account, privKey string) (isNew bool, err error)
 	DoesAccountExist(user string) (bool, error)
 	GetPrivKey(user string) (string, error)
+	GetEdKeys(user string) (pubKey, privKey string, err error)
+	SetEdKeysIfMissing(user, pubKey, privKey string) error
 	GetAccount(user string) (*Account, error)
 	BruteDeleteAccount(accountId int) error
+	TombstoneAccount(accountId int, deletedAt time.Time) error
+	GetAccountTombstone(user string) (*AccountTombstone, error)
+	DeleteAccountTombstone(user string) error
 	GetAccountsPage(offset, limit int) ([]*Account, int, error)
+	GetAccountSummaries(filter *AccountFilter) ([]*AccountSummary, error)
 	AddToot(accountId int, toot *Toot) error
 	GetToot(statusId string) (*Toot, error)
 	GetPostCount(user string) (uint, error)
@@ -36,23 +43,89 @@ type IRepo interface {
 	GetTootExtracts(accountId int) ([]*Toot, error)
 	GetFeedLastUpdated(accountId int) (time.Time, error)
 	UpdateAccountFeedTimes(accountId int, lastUpdated, nextCheckDue time.Time) error
+	UpdateAccountFeedMeta(accountId int, feedMetaHash int64) error
+	UpdateAccountSettings(accountId int, settings *AccountSettings) error
+	UpdateAccountProfile(accountId int, feedName, feedSummary, profileImageUrl string,
+		feedMetaHash int64, updatedAt time.Time) error
 	AddFeedPostIfNew(accountId int, post *FeedPost) (isNew bool, err error)
 	GetAccountToCheck(checkDue time.Time) (*Account, int, error)
+	// Stores the outcome of a feed check; checkErr is empty if the check succeeded
+	RecordFeedCheck(accountId int, when time.Time, checkErr string) error
+	GetFeedErrors(accountId int) ([]*FeedError, error)
+	// Counts approved followers, or, if onlyApproved is false, pending ones too; banned followers never count
 	GetFollowerCount(user string, onlyApproved bool) (uint, error)
 
-	// Returns number of all followers of feeds. Includes unapproved and banned ones, but excludes followers of birb.
+	// Returns number of all followers of feeds. Includes unapproved ones, but excludes banned ones and followers of birb.
 	GetFeedFollowerCount() (int, error)
 
+	// Returns the number of distinct servers that our followers are on
+	GetFollowerDomainCount() (int, error)
+
 	GetFollowersByUser(user string, onlyApproved bool) ([]*FollowerInfo, error)
 	GetFollowersById(accountId int, onlyApproved bool) ([]*FollowerInfo, error)
+	// Returns nil if the actor is not on record as a follower of the user, in any approve status
+	GetFollower(user, followerUserUrl string) (*FollowerInfo, error)
 	SetFollowerApproveStatus(user, followerUserUrl string, status int) error
 	AddFollower(user string, follower *FollowerInfo) error
+	// Banned followers stay on record, so that a repeated Follow is rejected too
 	RemoveFollower(user, followerUserUrl string) error
+	AddRemoteBlock(user, actorUrl string, blockedAt time.Time) error
+	RemoveRemoteBlock(user, actorUrl string) error
+	IsRemoteBlocked(user, actorUrl string) (bool, error)
 	AddTootQueueItem(tqi *TootQueueItem) error
-	GetTootQueueItems(aboveId, maxCount int) ([]*TootQueueItem, int, error)
+	GetTootQueueItems(due time.Time, skipIds []int, skipHosts []string, maxPerHost, maxCount int) ([]*TootQueueItem, int, error)
+	GetTootQueueSummary(due time.Time) (*TootQueueSummary, error)
+	GetTootQueueCount(sendingUser string) (int, error)
+	RescheduleTootQueueItem(id, attempts int, nextAttemptAt time.Time, lastError string) error
 	DeleteTootQueueItem(id int) error
+	AddInboundItem(item *InboundItem) error
+	GetInboundItems(due time.Time, skipIds []int, maxCount int) ([]*InboundItem, int, error)
+	RescheduleInboundItem(id, attempts int, nextAttemptAt time.Time, lastError string) error
+	DeleteInboundItem(id int) error
+	RecordInboxSuccess(inbox, host string, when time.Time) error
+	RecordInboxFailure(inbox, host string, when time.Time, lastError string) error
+	GetFailingInboxes() ([]*InboxHealth, error)
+	GetDeadInboxes(failingSince time.Time) ([]string, error)
+	ResetInboxFailuresForHost(host string) (int, error)
 	PurgePostsAndToots(accountId int, fromBefore time.Time) error
+	GetCachedActor(userUrl string) (*CachedActor, error)
+	SaveCachedActor(actor *CachedActor) error
+	DeleteCachedActors(fetchedBefore time.Time) error
+	UpdateFollowerInboxes(userUrl, userInbox, sharedInbox string) error
+	// Returns the accounts that have the remote user as a follower, whatever the follow's approve status
+	GetFollowedAccounts(followerUserUrl string) ([]*Account, error)
+	// Moves the follow to the new account, merging with its existing follow if any; a ban carries over
+	MoveFollower(user, oldUserUrl string, flwr *FollowerInfo) error
+	SetAccountRelayToots(accountId int, relayToots bool) error
+	SetAccountManuallyApproves(accountId int, manuallyApproves bool) error
+	GetRelays() ([]*Relay, error)
+	SaveRelay(relay *Relay) error
+	DeleteRelay(inbox string) error
+	AddBundleMember(bundleId, memberId int, addedAt time.Time) error
+	RemoveBundleMember(bundleId, memberId int) error
+	GetBundleMembers(bundleId int) ([]*Account, error)
+	GetBundlesOfMember(memberId int) ([]*Account, error)
+	GetDomainBlocks() ([]*DomainBlock, error)
+	SaveDomainBlock(block *DomainBlock) error
+	DeleteDomainBlock(domain string) error
+	AddReport(report *Report) (isNew bool, err error)
+	GetReports(onlyOpen bool) ([]*Report, error)
+	GetReport(id int) (*Report, error)
+	ResolveReport(id int, resolution string, resolvedAt time.Time) error
+	GetOpenReportCount() (int, error)
+	DeleteToot(statusId string) error
+	GetFeedBlocks() ([]*FeedBlock, error)
+	// Stores the rule, or updates the reason if the same rule exists. Returns the rule's ID.
+	SaveFeedBlock(block *FeedBlock) (int, error)
+	DeleteFeedBlock(id int) (found bool, err error)
+	// Returns all accounts that parrot a feed
+	GetFeedAccounts() ([]*Account, error)
+	// Returns the value of a system parameter; empty string if it is not set
+	GetSysParam(name string) (string, error)
+	SetSysParam(name, val string) error
 	MarkActivityHandled(id string, when time.Time) (alreadyHandled bool, err error)
+	// Forgets that the activity was handled, so that it is processed when it arrives again
+	UnmarkActivityHandled(id string) error
 	DeleteHandledActivities(before time.Time) error
 }
 
@@ -207,10 +280,11 @@ func (repo *Repo) AddAccountIfNotExist(acct *Account, privKey string) (isNew boo
 
 	isNew = true
 	_, err = repo.db.Exec(`INSERT INTO accounts
-    	(created_at, user_url, handle, feed_name, feed_summary, profile_image_url, site_url, feed_url, pubkey, privkey)
-		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+    	(created_at, user_url, handle, feed_name, feed_summary, profile_image_url, site_url, feed_url, pubkey, privkey,
+    	 feed_meta_hash, kind)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		acct.CreatedAt, acct.UserUrl, acct.Handle, acct.FeedName, acct.FeedSummary, acct.ProfileImageUrl,
-		acct.SiteUrl, acct.FeedUrl, acct.PubKey, privKey)
+		acct.SiteUrl, acct.FeedUrl, acct.PubKey, privKey, acct.FeedMetaHash, acct.Kind)
 	if err == nil {
 		return
 	}
@@ -250,14 +324,8 @@ func (repo *Repo) GetAccount(user string) (*Account, error) {
 
 func (repo *Repo) getAccount(user string) (*Account, error) {
 
-	row := repo.db.QueryRow(
-		`SELECT id, created_at, user_url, handle, feed_name, feed_summary, profile_image_url, site_url, feed_url,
-         		feed_last_updated, next_check_due, pubkey
-		FROM accounts WHERE handle=?`, user)
-	var err error
-	var res Account
-	err = row.Scan(&res.Id, &res.CreatedAt, &res.UserUrl, &res.Handle, &res.FeedName, &res.FeedSummary,
-		&res.ProfileImageUrl, &res.SiteUrl, &res.FeedUrl, &res.FeedLastUpdated, &res.NextCheckDue, &res.PubKey)
+	row := repo.db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE handle=?`, user)
+	res, err := scanAccount(row)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -265,7 +333,32 @@ func (repo *Repo) getAccount(user string) (*Account, error) {
 			return nil, err
 		}
 	}
-	return &res, nil
+	return res, nil
+}
+
+// Columns read into an Account, in the order expected by scanAccount
+const accountColumns = `id, created_at, user_url, handle, feed_name, feed_summary, profile_image_url,
+	site_url, feed_url, feed_last_updated, next_check_due, pubkey, feed_meta_hash, profile_updated_at, relay_toots,
+	kind, manually_approves, last_checked_at, feed_error_count, header_image_url, locked_fields, paused,
+	check_interval_min`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// Any extra destinations receive the columns the query selects after accountColumns
+func scanAccount(row rowScanner, extra ...any) (*Account, error) {
+	var a Account
+	dest := []any{&a.Id, &a.CreatedAt, &a.UserUrl, &a.Handle, &a.FeedName, &a.FeedSummary,
+		&a.ProfileImageUrl, &a.SiteUrl, &a.FeedUrl, &a.FeedLastUpdated, &a.NextCheckDue, &a.PubKey,
+		&a.FeedMetaHash, &a.ProfileUpdatedAt, &a.RelayToots, &a.Kind,
+		&a.ManuallyApproves, &a.LastCheckedAt, &a.FeedErrorCount, &a.HeaderImageUrl, &a.LockedFields, &a.Paused,
+		&a.CheckIntervalMin}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
 }
 
 func (repo *Repo) BruteDeleteAccount(accountId int) error {
@@ -297,13 +390,25 @@ func (repo *Repo) BruteDeleteAccount(accountId int) error {
 		if err != nil {
 			return err
 		}
+		_, err = repo.db.Exec(`DELETE FROM remote_blocks WHERE account_id=?`, accountId)
+		if err != nil {
+			return err
+		}
+		_, err = repo.db.Exec(`DELETE FROM feed_errors WHERE account_id=?`, accountId)
+		if err != nil {
+			return err
+		}
 		return nil
 	}
 
 	step4 := func() error {
 		repo.muDb.Lock()
 		defer repo.muDb.Unlock()
-		_, err := repo.db.Exec(`DELETE FROM accounts WHERE id=?`, accountId)
+		_, err := repo.db.Exec(`DELETE FROM bundle_members WHERE bundle_id=? OR member_id=?`, accountId, accountId)
+		if err != nil {
+			return err
+		}
+		_, err = repo.db.Exec(`DELETE FROM accounts WHERE id=?`, accountId)
 		if err != nil {
 			return err
 		}
@@ -329,6 +434,43 @@ func (repo *Repo) BruteDeleteAccount(accountId int) error {
 	return nil
 }
 
+func (repo *Repo) TombstoneAccount(accountId int, deletedAt time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO account_tombstones (handle, deleted_at, pubkey, privkey)
+		SELECT handle, ?, pubkey, privkey FROM accounts WHERE id=?
+		ON CONFLICT DO UPDATE SET deleted_at=excluded.deleted_at, pubkey=excluded.pubkey, privkey=excluded.privkey`,
+		deletedAt, accountId)
+	return err
+}
+
+func (repo *Repo) GetAccountTombstone(user string) (*AccountTombstone, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	row := repo.db.QueryRow(`SELECT handle, deleted_at, pubkey, privkey FROM account_tombstones WHERE handle=?`, user)
+	var res AccountTombstone
+	if err := row.Scan(&res.Handle, &res.DeletedAt, &res.PubKey, &res.PrivKey); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (repo *Repo) DeleteAccountTombstone(user string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM account_tombstones WHERE handle=?`, user)
+	return err
+}
+
 func (repo *Repo) GetAccountsPage(offset, limit int) ([]*Account, int, error) {
 
 	repo.muDb.RLock()
@@ -343,9 +485,7 @@ func (repo *Repo) GetAccountsPage(offset, limit int) ([]*Account, int, error) {
 		return nil, 0, err
 	}
 
-	query := `SELECT id, created_at, user_url, handle, feed_name, feed_summary, profile_image_url, site_url, feed_url,
-        feed_last_updated, next_check_due, pubkey
-		FROM accounts ORDER BY ID DESC LIMIT ? OFFSET ?`
+	query := `SELECT ` + accountColumns + ` FROM accounts ORDER BY ID DESC LIMIT ? OFFSET ?`
 	rows, err := repo.db.Query(query, limit, offset)
 	if err != nil {
 		return nil, 0, err
@@ -353,23 +493,116 @@ func (repo *Repo) GetAccountsPage(offset, limit int) ([]*Account, int, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		a := Account{}
-		err = rows.Scan(&a.Id, &a.CreatedAt, &a.UserUrl, &a.Handle, &a.FeedName, &a.FeedSummary,
-			&a.ProfileImageUrl, &a.SiteUrl, &a.FeedUrl, &a.FeedLastUpdated, &a.NextCheckDue, &a.PubKey)
-		if err = rows.Err(); err != nil {
+		var a *Account
+		if a, err = scanAccount(rows); err != nil {
 			return nil, 0, err
 		}
-		res = append(res, &a)
+		res = append(res, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
 	}
 	return res, total, nil
 }
 
+// Expressions accounts can be listed by; the account ID breaks ties
+// Times are compared as numbers: stored values don't all have the same text format
+var accountSortExprs = map[string]string{
+	AccountSortCreated:   "julianday(created_at)",
+	AccountSortHandle:    "handle",
+	AccountSortFollowers: "follower_count",
+	AccountSortUpdated:   "julianday(feed_last_updated)",
+}
+
+func (repo *Repo) GetAccountSummaries(filter *AccountFilter) ([]*AccountSummary, error) {
+
+	sortExpr, ok := accountSortExprs[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort key: %s", filter.Sort)
+	}
+
+	var conds []string
+	var args []any
+	if filter.Search != "" {
+		conds = append(conds, `(instr(lower(handle), lower(?))>0 OR instr(lower(site_url), lower(?))>0)`)
+		args = append(args, filter.Search, filter.Search)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conds = append(conds, `julianday(created_at)>=julianday(?)`)
+		args = append(args, filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conds = append(conds, `julianday(created_at)<julianday(?)`)
+		args = append(args, filter.CreatedBefore)
+	}
+	if filter.MinFollowers > 0 {
+		conds = append(conds, `follower_count>=?`)
+		args = append(args, filter.MinFollowers)
+	}
+	if filter.MaxFollowers >= 0 {
+		conds = append(conds, `follower_count<=?`)
+		args = append(args, filter.MaxFollowers)
+	}
+	if filter.OnlyFailing {
+		conds = append(conds, `feed_error_count>0`)
+	}
+	if !filter.IdleSince.IsZero() {
+		conds = append(conds, `julianday(feed_last_updated)<julianday(?) AND feed_url<>''`)
+		args = append(args, filter.IdleSince)
+	}
+	cmp, order := ">", "ASC"
+	if filter.Desc {
+		cmp, order = "<", "DESC"
+	}
+	if filter.AfterValue != nil {
+		afterExpr := "?"
+		if _, isTime := filter.AfterValue.(time.Time); isTime {
+			afterExpr = "julianday(?)"
+		}
+		conds = append(conds, fmt.Sprintf(`(%s%s%s OR (%s=%s AND id%s?))`,
+			sortExpr, cmp, afterExpr, sortExpr, afterExpr, cmp))
+		args = append(args, filter.AfterValue, filter.AfterValue, filter.AfterId)
+	}
+	where := ""
+	if len(conds) != 0 {
+		where = `WHERE ` + strings.Join(conds, " AND ")
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM (SELECT %s,
+			(SELECT COUNT(*) FROM followers WHERE followers.account_id=accounts.id AND approve_status=1)
+				AS follower_count
+			FROM accounts)
+		%s ORDER BY %s %s, id %s LIMIT ?`, accountColumns, where, sortExpr, order, order)
+	args = append(args, filter.Limit)
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*AccountSummary
+	for rows.Next() {
+		var item AccountSummary
+		if item.Account, err = scanAccount(rows, &item.FollowerCount); err != nil {
+			return nil, err
+		}
+		res = append(res, &item)
+	}
+	return res, rows.Err()
+}
+
 func (repo *Repo) GetPrivKey(user string) (string, error) {
 
 	repo.muDb.RLock()
 	defer repo.muDb.RUnlock()
 
-	row := repo.db.QueryRow(`SELECT privkey FROM accounts WHERE handle=?`, user)
+	// Deleted accounts' keys live on in their tombstone
+	row := repo.db.QueryRow(`SELECT privkey FROM accounts WHERE handle=?
+		UNION ALL SELECT privkey FROM account_tombstones WHERE handle=? LIMIT 1`, user, user)
 	var err error
 	var res string
 	err = row.Scan(&res)
@@ -395,6 +628,31 @@ func (repo *Repo) SetPrivKey(user, privKey string) error {
 	return nil
 }
 
+// Returns the account's Ed25519 keys; empty strings if the account has none yet, or doesn't exist
+func (repo *Repo) GetEdKeys(user string) (pubKey, privKey string, err error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	row := repo.db.QueryRow(`SELECT ed_pubkey, ed_privkey FROM accounts WHERE handle=?`, user)
+	err = row.Scan(&pubKey, &privKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	return
+}
+
+// Stores the account's Ed25519 keys, unless it already has some. The first writer wins.
+func (repo *Repo) SetEdKeysIfMissing(user, pubKey, privKey string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE accounts SET ed_pubkey=?, ed_privkey=? WHERE handle=? AND ed_privkey=''`,
+		pubKey, privKey, user)
+	return err
+}
+
 func (repo *Repo) AddToot(accountId int, toot *Toot) error {
 
 	repo.muDb.Lock()
@@ -524,6 +782,8 @@ func (repo *Repo) GetFollowerCount(user string, onlyApproved bool) (uint, error)
 		ON followers.account_id=accounts.id AND accounts.handle=?`
 	if onlyApproved {
 		sql += ` WHERE followers.approve_status=1`
+	} else {
+		sql += ` WHERE followers.approve_status>=0`
 	}
 
 	row := repo.db.QueryRow(sql, user)
@@ -540,8 +800,8 @@ func (repo *Repo) GetFeedFollowerCount() (int, error) {
 	repo.muDb.RLock()
 	defer repo.muDb.RUnlock()
 
-	row := repo.db.QueryRow(`SELECT COUNT(*) FROM followers WHERE account_id NOT IN
-        (SELECT id FROM accounts WHERE handle='?');`, repo.cfg.Birb.User)
+	row := repo.db.QueryRow(`SELECT COUNT(*) FROM followers WHERE approve_status>=0 AND account_id NOT IN
+        (SELECT id FROM accounts WHERE handle=?);`, repo.cfg.Birb.User)
 	var err error
 	var count int
 	if err = row.Scan(&count); err != nil {
@@ -550,6 +810,21 @@ func (repo *Repo) GetFeedFollowerCount() (int, error) {
 	return count, nil
 }
 
+func (repo *Repo) GetFollowerDomainCount() (int, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	// Host is what comes between https:// and the next slash
+	row := repo.db.QueryRow(`SELECT COUNT(DISTINCT substr(user_url, 9, instr(substr(user_url, 9), '/') - 1))
+		FROM followers WHERE approve_status>=0`)
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (repo *Repo) SetFollowerApproveStatus(user, followerUserUrl string, status int) error {
 
 	repo.muDb.Lock()
@@ -572,7 +847,8 @@ func (repo *Repo) GetFollowersByUser(user string, onlyApproved bool) ([]*Followe
 	repo.muDb.RLock()
 	defer repo.muDb.RUnlock()
 
-	query := `SELECT followers.request_id, followers.user_url, followers.handle, host, user_inbox, shared_inbox
+	query := `SELECT followers.request_id, followers.approve_status, followers.user_url, followers.handle,
+       	host, user_inbox, shared_inbox
 		FROM followers JOIN accounts ON followers.account_id=accounts.id AND accounts.handle=?`
 	if onlyApproved {
 		query += ` WHERE followers.approve_status=1`
@@ -590,7 +866,8 @@ func (repo *Repo) GetFollowersById(accountId int, onlyApproved bool) ([]*Followe
 	repo.muDb.RLock()
 	defer repo.muDb.RUnlock()
 
-	query := `SELECT request_id, user_url, handle, host, user_inbox, shared_inbox FROM followers WHERE account_id=?`
+	query := `SELECT request_id, approve_status, user_url, handle, host, user_inbox, shared_inbox
+		FROM followers WHERE account_id=?`
 	if onlyApproved {
 		query += ` AND followers.approve_status=1`
 	}
@@ -602,12 +879,33 @@ func (repo *Repo) GetFollowersById(accountId int, onlyApproved bool) ([]*Followe
 	return readGetFollowers(rows)
 }
 
+func (repo *Repo) GetFollower(user, followerUserUrl string) (*FollowerInfo, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT followers.request_id, followers.approve_status, followers.user_url,
+		followers.handle, host, user_inbox, shared_inbox
+		FROM followers JOIN accounts ON followers.account_id=accounts.id AND accounts.handle=?
+		WHERE followers.user_url=?`, user, followerUserUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	followers, err := readGetFollowers(rows)
+	if err != nil || len(followers) == 0 {
+		return nil, err
+	}
+	return followers[0], nil
+}
+
 func readGetFollowers(rows *sql.Rows) ([]*FollowerInfo, error) {
 	var err error
 	res := make([]*FollowerInfo, 0)
 	for rows.Next() {
 		mui := FollowerInfo{}
-		err = rows.Scan(&mui.RequestId, &mui.UserUrl, &mui.Handle, &mui.Host, &mui.UserInbox, &mui.SharedInbox)
+		err = rows.Scan(&mui.RequestId, &mui.ApproveStatus, &mui.UserUrl, &mui.Handle, &mui.Host,
+			&mui.UserInbox, &mui.SharedInbox)
 		if err != nil {
 			return nil, err
 		}
@@ -651,7 +949,7 @@ func (repo *Repo) RemoveFollower(user, followerUserUrl string) error {
 	if err = row.Scan(&accountId); err != nil {
 		return err
 	}
-	_, err = repo.db.Exec(`DELETE FROM followers WHERE account_id=? AND user_url=?`,
+	_, err = repo.db.Exec(`DELETE FROM followers WHERE account_id=? AND user_url=? AND approve_status>=0`,
 		accountId, followerUserUrl)
 	if err != nil {
 		return err
@@ -659,6 +957,41 @@ func (repo *Repo) RemoveFollower(user, followerUserUrl string) error {
 	return nil
 }
 
+func (repo *Repo) AddRemoteBlock(user, actorUrl string, blockedAt time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO remote_blocks (account_id, actor_url, blocked_at)
+		SELECT id, ?, ? FROM accounts WHERE handle=? ON CONFLICT DO NOTHING`,
+		actorUrl, blockedAt, user)
+	return err
+}
+
+func (repo *Repo) RemoveRemoteBlock(user, actorUrl string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM remote_blocks
+		WHERE account_id=(SELECT id FROM accounts WHERE handle=?) AND actor_url=?`, user, actorUrl)
+	return err
+}
+
+func (repo *Repo) IsRemoteBlocked(user, actorUrl string) (bool, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	var count int
+	row := repo.db.QueryRow(`SELECT COUNT(*) FROM remote_blocks
+		WHERE account_id=(SELECT id FROM accounts WHERE handle=?) AND actor_url=?`, user, actorUrl)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
 func (repo *Repo) GetFeedLastUpdated(accountId int) (res time.Time, err error) {
 
 	repo.muDb.RLock()
@@ -683,38 +1016,166 @@ func (repo *Repo) UpdateAccountFeedTimes(accountId int, lastUpdated, nextCheckDu
 	return err
 }
 
+// Stores the settings an admin changed: profile, feed URL, check schedule and content options.
+// Columns for nil fields are not written, so a feed check running meanwhile doesn't lose its updates.
+func (repo *Repo) UpdateAccountSettings(accountId int, settings *AccountSettings) error {
+
+	var cols []string
+	var args []any
+	set := func(col string, val any) {
+		cols = append(cols, col+"=?")
+		args = append(args, val)
+	}
+	if settings.FeedName != nil {
+		set("feed_name", *settings.FeedName)
+	}
+	if settings.FeedSummary != nil {
+		set("feed_summary", *settings.FeedSummary)
+	}
+	if settings.ProfileImageUrl != nil {
+		set("profile_image_url", *settings.ProfileImageUrl)
+	}
+	if settings.HeaderImageUrl != nil {
+		set("header_image_url", *settings.HeaderImageUrl)
+	}
+	if settings.FeedUrl != nil {
+		set("feed_url", *settings.FeedUrl)
+	}
+	if settings.FeedMetaHash != nil {
+		set("feed_meta_hash", *settings.FeedMetaHash)
+	}
+	if settings.LockedFields != nil {
+		set("locked_fields", *settings.LockedFields)
+	}
+	if settings.Paused != nil {
+		set("paused", *settings.Paused)
+	}
+	if settings.CheckIntervalMin != nil {
+		set("check_interval_min", *settings.CheckIntervalMin)
+	}
+	if settings.NextCheckDue != nil {
+		set("next_check_due", *settings.NextCheckDue)
+	}
+	if settings.RelayToots != nil {
+		set("relay_toots", *settings.RelayToots)
+	}
+	if len(cols) == 0 {
+		return nil
+	}
+	args = append(args, accountId)
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE accounts SET `+strings.Join(cols, ", ")+` WHERE id=?`, args...)
+	return err
+}
+
+func (repo *Repo) UpdateAccountFeedMeta(accountId int, feedMetaHash int64) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE accounts SET feed_meta_hash=? WHERE id=?`, feedMetaHash, accountId)
+	return err
+}
+
+func (repo *Repo) UpdateAccountProfile(accountId int, feedName, feedSummary, profileImageUrl string,
+	feedMetaHash int64, updatedAt time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE accounts SET feed_name=?, feed_summary=?, profile_image_url=?,
+        feed_meta_hash=?, profile_updated_at=? WHERE id=?`,
+		feedName, feedSummary, profileImageUrl, feedMetaHash, updatedAt, accountId)
+	return err
+}
+
 func (repo *Repo) GetAccountToCheck(checkDue time.Time) (*Account, int, error) {
 
 	repo.muDb.RLock()
 	defer repo.muDb.RUnlock()
 
 	var nCheckableAccounts int
-	row := repo.db.QueryRow(`SELECT COUNT(*) FROM accounts WHERE next_check_due<?`, checkDue)
+	row := repo.db.QueryRow(`SELECT COUNT(*) FROM accounts WHERE next_check_due<? AND paused=0`, checkDue)
 	if err := row.Scan(&nCheckableAccounts); err != nil {
 		return nil, 0, err
 	}
 
-	rows, err := repo.db.Query(`SELECT id, created_at, user_url, handle, feed_name, feed_summary,
-    	profile_image_url, site_url, feed_url, feed_last_updated, next_check_due, pubkey
-		FROM accounts WHERE next_check_due<? LIMIT 1`, checkDue)
+	rows, err := repo.db.Query(`SELECT `+accountColumns+`
+		FROM accounts WHERE next_check_due<? AND paused=0 LIMIT 1`, checkDue)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 	var acct *Account = nil
 	for rows.Next() {
-		res := Account{}
-		err = rows.Scan(&res.Id, &res.CreatedAt, &res.UserUrl, &res.Handle, &res.FeedName, &res.FeedSummary,
-			&res.ProfileImageUrl, &res.SiteUrl, &res.FeedUrl, &res.FeedLastUpdated, &res.NextCheckDue, &res.PubKey)
-		if err = rows.Err(); err != nil {
+		if acct, err = scanAccount(rows); err != nil {
 			return nil, 0, err
 		}
-		acct = &res
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
 	}
 	return acct, nCheckableAccounts, nil
 
 }
 
+const feedErrorsKept = 10
+
+func (repo *Repo) RecordFeedCheck(accountId int, when time.Time, checkErr string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	if checkErr == "" {
+		_, err := repo.db.Exec(`UPDATE accounts SET last_checked_at=?, feed_error_count=0 WHERE id=?`,
+			when, accountId)
+		return err
+	}
+
+	_, err := repo.db.Exec(`UPDATE accounts SET last_checked_at=?, feed_error_count=feed_error_count+1
+		WHERE id=?`, when, accountId)
+	if err != nil {
+		return err
+	}
+	_, err = repo.db.Exec(`INSERT INTO feed_errors (account_id, occurred_at, error) VALUES (?, ?, ?)`,
+		accountId, when, checkErr)
+	if err != nil {
+		return err
+	}
+	// Only the most recent errors are kept
+	_, err = repo.db.Exec(`DELETE FROM feed_errors WHERE account_id=? AND rowid NOT IN
+		(SELECT rowid FROM feed_errors WHERE account_id=? ORDER BY occurred_at DESC LIMIT ?)`,
+		accountId, accountId, feedErrorsKept)
+	return err
+}
+
+// Returns the account's recent feed errors, newest first
+func (repo *Repo) GetFeedErrors(accountId int) ([]*FeedError, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT account_id, occurred_at, error FROM feed_errors
+		WHERE account_id=? ORDER BY occurred_at DESC`, accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*FeedError
+	for rows.Next() {
+		var fe FeedError
+		if err = rows.Scan(&fe.AccountId, &fe.OccurredAt, &fe.Error); err != nil {
+			return nil, err
+		}
+		res = append(res, &fe)
+	}
+	return res, rows.Err()
+}
+
 func (repo *Repo) AddFeedPostIfNew(accountId int, post *FeedPost) (isNew bool, err error) {
 
 	repo.muDb.Lock()
@@ -750,13 +1211,20 @@ func (repo *Repo) AddTootQueueItem(tqi *TootQueueItem) error {
 	repo.muDb.Lock()
 	defer repo.muDb.Unlock()
 
-	_, err := repo.db.Exec(`INSERT INTO toot_queue (sending_user, to_inbox, tooted_at, status_id, content)
-		VALUES(?, ?, ?, ?, ?)`,
-		tqi.SendingUser, tqi.ToInbox, tqi.TootedAt, tqi.StatusId, tqi.Content)
+	_, err := repo.db.Exec(`INSERT INTO toot_queue (sending_user, to_inbox, to_host, priority, tooted_at,
+		status_id, content, activity)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+		tqi.SendingUser, tqi.ToInbox, tqi.ToHost, tqi.Priority, tqi.TootedAt,
+		tqi.StatusId, tqi.Content, tqi.Activity)
 	return err
 }
 
-func (repo *Repo) GetTootQueueItems(aboveId, maxCount int) ([]*TootQueueItem, int, error) {
+// Returns up to maxCount queue items that are due, leaving out the ones in skipIds (typically those being sent)
+// and the ones going to skipHosts. Takes at most maxPerHost items per host. Higher priority comes first; within
+// a priority, hosts take turns: every host's oldest item, then every host's second oldest, and so on.
+// Also returns the total number of items in the queue.
+func (repo *Repo) GetTootQueueItems(due time.Time, skipIds []int, skipHosts []string,
+	maxPerHost, maxCount int) ([]*TootQueueItem, int, error) {
 
 	repo.muDb.RLock()
 	defer repo.muDb.RUnlock()
@@ -767,8 +1235,27 @@ func (repo *Repo) GetTootQueueItems(aboveId, maxCount int) ([]*TootQueueItem, in
 		return nil, 0, err
 	}
 
-	rows, err := repo.db.Query(`SELECT id, sending_user, to_inbox, tooted_at, status_id, content
-		FROM toot_queue WHERE id>? ORDER BY id ASC LIMIT ?`, aboveId, maxCount)
+	args := []any{due}
+	skipCond := ""
+	if len(skipIds) != 0 {
+		skipCond += " AND id NOT IN (?" + strings.Repeat(", ?", len(skipIds)-1) + ")"
+		for _, id := range skipIds {
+			args = append(args, id)
+		}
+	}
+	if len(skipHosts) != 0 {
+		skipCond += " AND to_host NOT IN (?" + strings.Repeat(", ?", len(skipHosts)-1) + ")"
+		for _, host := range skipHosts {
+			args = append(args, host)
+		}
+	}
+	args = append(args, maxPerHost, maxCount)
+
+	rows, err := repo.db.Query(`SELECT id, sending_user, to_inbox, to_host, priority, tooted_at, status_id, content,
+		activity, attempts, next_attempt_at, last_error
+		FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY to_host, priority ORDER BY id ASC) AS host_rank
+			FROM toot_queue WHERE next_attempt_at<=?`+skipCond+`)
+		WHERE host_rank<=? ORDER BY priority DESC, host_rank ASC, id ASC LIMIT ?`, args...)
 	if err != nil {
 		return nil, itmCount, err
 	}
@@ -776,7 +1263,8 @@ func (repo *Repo) GetTootQueueItems(aboveId, maxCount int) ([]*TootQueueItem, in
 	res := make([]*TootQueueItem, 0, maxCount)
 	for rows.Next() {
 		tqi := TootQueueItem{}
-		err = rows.Scan(&tqi.Id, &tqi.SendingUser, &tqi.ToInbox, &tqi.TootedAt, &tqi.StatusId, &tqi.Content)
+		err = rows.Scan(&tqi.Id, &tqi.SendingUser, &tqi.ToInbox, &tqi.ToHost, &tqi.Priority, &tqi.TootedAt,
+			&tqi.StatusId, &tqi.Content, &tqi.Activity, &tqi.Attempts, &tqi.NextAttemptAt, &tqi.LastError)
 		if err != nil {
 			return nil, itmCount, err
 		}
@@ -788,30 +1276,705 @@ func (repo *Repo) GetTootQueueItems(aboveId, maxCount int) ([]*TootQueueItem, in
 	return res, itmCount, nil
 }
 
-func (repo *Repo) DeleteTootQueueItem(id int) error {
+func (repo *Repo) AddInboundItem(item *InboundItem) error {
 
 	repo.muDb.Lock()
 	defer repo.muDb.Unlock()
 
-	_, err := repo.db.Exec(`DELETE FROM toot_queue WHERE id=?`, id)
+	_, err := repo.db.Exec(`INSERT INTO inbound_queue (receiving_user, sender, sender_id, activity, received_at)
+		VALUES(?, ?, ?, ?, ?)`,
+		item.ReceivingUser, item.Sender, item.SenderId, item.Activity, item.ReceivedAt)
 	return err
 }
 
-func (repo *Repo) PurgePostsAndToots(accountId int, fromBefore time.Time) error {
+// Returns up to maxCount inbound items that are due, oldest first, leaving out the ones in skipIds
+// (typically those being processed). Also returns the total number of items in the queue.
+// Only a sender's oldest item is ever returned: the next one waits until that has been processed and removed,
+// so that, e.g., a Follow and its Undo are not handled in reverse order.
+func (repo *Repo) GetInboundItems(due time.Time, skipIds []int, maxCount int) ([]*InboundItem, int, error) {
 
-	repo.muDb.Lock()
-	defer repo.muDb.Unlock()
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
 
-	if _, err := repo.db.Exec(`DELETE FROM feed_posts
-       	WHERE account_id=? AND post_time<=?`, accountId, fromBefore); err != nil {
-		return err
+	var itmCount int
+	row := repo.db.QueryRow(`SELECT COUNT(*) FROM inbound_queue`)
+	if err := row.Scan(&itmCount); err != nil {
+		return nil, 0, err
 	}
-	if _, err := repo.db.Exec(`DELETE FROM toots
-       	WHERE account_id=? AND tooted_at<=?`, accountId, fromBefore); err != nil {
-		return err
+
+	args := []any{due}
+	skipCond := ""
+	if len(skipIds) != 0 {
+		skipCond += " AND id NOT IN (?" + strings.Repeat(", ?", len(skipIds)-1) + ")"
+		for _, id := range skipIds {
+			args = append(args, id)
+		}
 	}
+	args = append(args, maxCount)
 
-	return nil
+	rows, err := repo.db.Query(`SELECT id, receiving_user, sender, activity, received_at,
+		attempts, next_attempt_at, last_error
+		FROM inbound_queue q WHERE next_attempt_at<=?`+skipCond+`
+		AND NOT EXISTS (SELECT 1 FROM inbound_queue e WHERE e.sender_id=q.sender_id AND e.sender_id<>'' AND e.id<q.id)
+		ORDER BY id ASC LIMIT ?`, args...)
+	if err != nil {
+		return nil, itmCount, err
+	}
+	defer rows.Close()
+	res := make([]*InboundItem, 0, maxCount)
+	for rows.Next() {
+		itm := InboundItem{}
+		err = rows.Scan(&itm.Id, &itm.ReceivingUser, &itm.Sender, &itm.Activity, &itm.ReceivedAt,
+			&itm.Attempts, &itm.NextAttemptAt, &itm.LastError)
+		if err != nil {
+			return nil, itmCount, err
+		}
+		res = append(res, &itm)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, itmCount, err
+	}
+	return res, itmCount, nil
+}
+
+func (repo *Repo) RescheduleInboundItem(id, attempts int, nextAttemptAt time.Time, lastError string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE inbound_queue SET attempts=?, next_attempt_at=?, last_error=? WHERE id=?`,
+		attempts, nextAttemptAt, lastError, id)
+	return err
+}
+
+func (repo *Repo) DeleteInboundItem(id int) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM inbound_queue WHERE id=?`, id)
+	return err
+}
+
+func (repo *Repo) GetTootQueueSummary(due time.Time) (*TootQueueSummary, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	var res TootQueueSummary
+	row := repo.db.QueryRow(`SELECT COUNT(*),
+		COALESCE(SUM(CASE WHEN next_attempt_at<=? THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN attempts>0 THEN 1 ELSE 0 END), 0)
+		FROM toot_queue`, due)
+	if err := row.Scan(&res.Total, &res.Due, &res.Retrying); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (repo *Repo) GetTootQueueCount(sendingUser string) (int, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	var count int
+	row := repo.db.QueryRow(`SELECT COUNT(*) FROM toot_queue WHERE sending_user=?`, sendingUser)
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (repo *Repo) RescheduleTootQueueItem(id, attempts int, nextAttemptAt time.Time, lastError string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE toot_queue SET attempts=?, next_attempt_at=?, last_error=? WHERE id=?`,
+		attempts, nextAttemptAt, lastError, id)
+	return err
+}
+
+func (repo *Repo) DeleteTootQueueItem(id int) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM toot_queue WHERE id=?`, id)
+	return err
+}
+
+func (repo *Repo) RecordInboxSuccess(inbox, host string, when time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO inbox_health (inbox, host, last_success) VALUES(?, ?, ?)
+		ON CONFLICT(inbox) DO UPDATE SET last_success=excluded.last_success,
+		first_failure='1900-01-01 00:00:00', failure_streak=0, last_error=''`,
+		inbox, host, when)
+	return err
+}
+
+func (repo *Repo) RecordInboxFailure(inbox, host string, when time.Time, lastError string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	// First failure is only set when the streak starts
+	_, err := repo.db.Exec(`INSERT INTO inbox_health (inbox, host, first_failure, failure_streak, last_error)
+		VALUES(?, ?, ?, 1, ?)
+		ON CONFLICT(inbox) DO UPDATE SET failure_streak=failure_streak+1, last_error=excluded.last_error,
+		first_failure=CASE WHEN failure_streak=0 THEN excluded.first_failure ELSE first_failure END`,
+		inbox, host, when, lastError)
+	return err
+}
+
+// Returns health info of all inboxes that are currently in a failure streak, longest-failing first.
+func (repo *Repo) GetFailingInboxes() ([]*InboxHealth, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT inbox, host, last_success, first_failure, failure_streak, last_error
+		FROM inbox_health WHERE failure_streak>0 ORDER BY first_failure ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]*InboxHealth, 0)
+	for rows.Next() {
+		ih := InboxHealth{}
+		err = rows.Scan(&ih.Inbox, &ih.Host, &ih.LastSuccess, &ih.FirstFailure, &ih.FailureStreak, &ih.LastError)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &ih)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Returns inboxes that have been failing without interruption since before failingSince.
+func (repo *Repo) GetDeadInboxes(failingSince time.Time) ([]string, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT inbox FROM inbox_health WHERE failure_streak>0 AND first_failure<?`,
+		failingSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]string, 0)
+	for rows.Next() {
+		var inbox string
+		if err = rows.Scan(&inbox); err != nil {
+			return nil, err
+		}
+		res = append(res, inbox)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Clears the failure streak of all inboxes on host. Returns the number of inboxes affected.
+func (repo *Repo) ResetInboxFailuresForHost(host string) (int, error) {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	res, err := repo.db.Exec(`UPDATE inbox_health SET first_failure='1900-01-01 00:00:00', failure_streak=0
+		WHERE host=? AND failure_streak>0`, host)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	return int(count), err
+}
+
+// Returns the cached actor, or nil if we don't have it
+func (repo *Repo) GetCachedActor(userUrl string) (*CachedActor, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	row := repo.db.QueryRow(`SELECT user_url, fetched_at, actor_type, preferred_username, inbox, shared_inbox,
+		followers, key_id, key_owner, public_key_pem, assertion_keys
+		FROM actor_cache WHERE user_url=?`, userUrl)
+	var ca CachedActor
+	err := row.Scan(&ca.UserUrl, &ca.FetchedAt, &ca.ActorType, &ca.PreferredUserName, &ca.Inbox, &ca.SharedInbox,
+		&ca.Followers, &ca.KeyId, &ca.KeyOwner, &ca.PublicKeyPem, &ca.AssertionKeys)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ca, nil
+}
+
+func (repo *Repo) SaveCachedActor(actor *CachedActor) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO actor_cache (user_url, fetched_at, actor_type, preferred_username,
+		inbox, shared_inbox, followers, key_id, key_owner, public_key_pem, assertion_keys)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_url) DO UPDATE SET fetched_at=excluded.fetched_at, actor_type=excluded.actor_type,
+		preferred_username=excluded.preferred_username, inbox=excluded.inbox, shared_inbox=excluded.shared_inbox,
+		followers=excluded.followers, key_id=excluded.key_id, key_owner=excluded.key_owner,
+		public_key_pem=excluded.public_key_pem, assertion_keys=excluded.assertion_keys`,
+		actor.UserUrl, actor.FetchedAt, actor.ActorType, actor.PreferredUserName,
+		actor.Inbox, actor.SharedInbox, actor.Followers, actor.KeyId, actor.KeyOwner, actor.PublicKeyPem,
+		actor.AssertionKeys)
+	return err
+}
+
+func (repo *Repo) DeleteCachedActors(fetchedBefore time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM actor_cache WHERE fetched_at<?`, fetchedBefore)
+	return err
+}
+
+// Updates the inboxes of a remote user in all of their follow records
+func (repo *Repo) UpdateFollowerInboxes(userUrl, userInbox, sharedInbox string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE followers SET user_inbox=?, shared_inbox=? WHERE user_url=?`,
+		userInbox, sharedInbox, userUrl)
+	return err
+}
+
+func (repo *Repo) GetFollowedAccounts(followerUserUrl string) ([]*Account, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	return repo.queryAccounts(`SELECT `+accountColumns+` FROM accounts
+		WHERE id IN (SELECT account_id FROM followers WHERE user_url=?) ORDER BY handle`, followerUserUrl)
+}
+
+// Points user's follower record of oldUserUrl at the new account. The record keeps its request ID and approve
+// status. If the new account already follows user, the old record is simply dropped.
+func (repo *Repo) MoveFollower(user, oldUserUrl string, flwr *FollowerInfo) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	row := repo.db.QueryRow(`SELECT id FROM accounts WHERE handle=?`, user)
+	var err error
+	var accountId int
+	if err = row.Scan(&accountId); err != nil {
+		return err
+	}
+	// If the new account already follows, the old row goes, but a ban on it must not
+	_, err = repo.db.Exec(`UPDATE followers SET approve_status=-1 WHERE account_id=? AND user_url=?
+		AND EXISTS (SELECT 1 FROM followers WHERE account_id=? AND user_url=? AND approve_status<0)`,
+		accountId, flwr.UserUrl, accountId, oldUserUrl)
+	if err != nil {
+		return err
+	}
+	_, err = repo.db.Exec(`DELETE FROM followers WHERE account_id=? AND user_url=?
+		AND EXISTS (SELECT 1 FROM followers WHERE account_id=? AND user_url=?)`,
+		accountId, oldUserUrl, accountId, flwr.UserUrl)
+	if err != nil {
+		return err
+	}
+	_, err = repo.db.Exec(`UPDATE followers SET user_url=?, handle=?, host=?, user_inbox=?, shared_inbox=?
+		WHERE account_id=? AND (user_url=? OR user_url=?)`, flwr.UserUrl, flwr.Handle, flwr.Host,
+		flwr.UserInbox, flwr.SharedInbox, accountId, oldUserUrl, flwr.UserUrl)
+	return err
+}
+
+func (repo *Repo) SetAccountRelayToots(accountId int, relayToots bool) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE accounts SET relay_toots=? WHERE id=?`, relayToots, accountId)
+	return err
+}
+
+func (repo *Repo) SetAccountManuallyApproves(accountId int, manuallyApproves bool) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE accounts SET manually_approves=? WHERE id=?`, manuallyApproves, accountId)
+	return err
+}
+
+func (repo *Repo) GetRelays() ([]*Relay, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT inbox, follow_id, follow_object, status, updated_at FROM relays ORDER BY inbox`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Relay
+	for rows.Next() {
+		var r Relay
+		if err = rows.Scan(&r.Inbox, &r.FollowId, &r.FollowObject, &r.Status, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &r)
+	}
+	return res, rows.Err()
+}
+
+func (repo *Repo) SaveRelay(relay *Relay) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO relays (inbox, follow_id, follow_object, status, updated_at)
+		VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(inbox) DO UPDATE SET follow_id=excluded.follow_id, follow_object=excluded.follow_object,
+		status=excluded.status, updated_at=excluded.updated_at`,
+		relay.Inbox, relay.FollowId, relay.FollowObject, relay.Status, relay.UpdatedAt)
+	return err
+}
+
+func (repo *Repo) DeleteRelay(inbox string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM relays WHERE inbox=?`, inbox)
+	return err
+}
+
+func (repo *Repo) AddBundleMember(bundleId, memberId int, addedAt time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO bundle_members (bundle_id, member_id, added_at) VALUES(?, ?, ?)
+		ON CONFLICT(bundle_id, member_id) DO NOTHING`,
+		bundleId, memberId, addedAt)
+	return err
+}
+
+func (repo *Repo) RemoveBundleMember(bundleId, memberId int) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM bundle_members WHERE bundle_id=? AND member_id=?`, bundleId, memberId)
+	return err
+}
+
+func (repo *Repo) queryAccounts(query string, args ...any) ([]*Account, error) {
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Account
+	for rows.Next() {
+		var a *Account
+		if a, err = scanAccount(rows); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
+
+func (repo *Repo) GetBundleMembers(bundleId int) ([]*Account, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	return repo.queryAccounts(`SELECT `+accountColumns+` FROM accounts
+		WHERE id IN (SELECT member_id FROM bundle_members WHERE bundle_id=?) ORDER BY handle`, bundleId)
+}
+
+func (repo *Repo) GetBundlesOfMember(memberId int) ([]*Account, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	return repo.queryAccounts(`SELECT `+accountColumns+` FROM accounts
+		WHERE id IN (SELECT bundle_id FROM bundle_members WHERE member_id=?) ORDER BY handle`, memberId)
+}
+
+func (repo *Repo) GetDomainBlocks() ([]*DomainBlock, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT domain, level, reason, created_at FROM domain_blocks ORDER BY domain`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*DomainBlock
+	for rows.Next() {
+		var b DomainBlock
+		if err = rows.Scan(&b.Domain, &b.Level, &b.Reason, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &b)
+	}
+	return res, rows.Err()
+}
+
+func (repo *Repo) SaveDomainBlock(block *DomainBlock) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO domain_blocks (domain, level, reason, created_at) VALUES(?, ?, ?, ?)
+		ON CONFLICT(domain) DO UPDATE SET level=excluded.level, reason=excluded.reason`,
+		block.Domain, block.Level, block.Reason, block.CreatedAt)
+	return err
+}
+
+func (repo *Repo) DeleteDomainBlock(domain string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM domain_blocks WHERE domain=?`, domain)
+	return err
+}
+
+func (repo *Repo) AddReport(report *Report) (isNew bool, err error) {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	res, err := repo.db.Exec(`INSERT INTO reports (activity_id, reporter, account_handle, status_ids, comment, created_at)
+		VALUES(?, ?, ?, ?, ?, ?) ON CONFLICT(activity_id) DO NOTHING`,
+		report.ActivityId, report.Reporter, report.AccountHandle, strings.Join(report.StatusIds, "\n"),
+		report.Comment, report.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	return count != 0, err
+}
+
+const reportColumns = `id, activity_id, reporter, account_handle, status_ids, comment, created_at, resolved_at, resolution`
+
+func scanReport(row rowScanner) (*Report, error) {
+	var r Report
+	var statusIds string
+	err := row.Scan(&r.Id, &r.ActivityId, &r.Reporter, &r.AccountHandle, &statusIds, &r.Comment,
+		&r.CreatedAt, &r.ResolvedAt, &r.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	if statusIds != "" {
+		r.StatusIds = strings.Split(statusIds, "\n")
+	}
+	return &r, nil
+}
+
+func (repo *Repo) GetReports(onlyOpen bool) ([]*Report, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	query := `SELECT ` + reportColumns + ` FROM reports`
+	if onlyOpen {
+		query += ` WHERE resolution=''`
+	}
+	query += ` ORDER BY id DESC`
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Report
+	for rows.Next() {
+		var r *Report
+		if r, err = scanReport(rows); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+func (repo *Repo) GetReport(id int) (*Report, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	row := repo.db.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id=?`, id)
+	res, err := scanReport(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return res, err
+}
+
+func (repo *Repo) ResolveReport(id int, resolution string, resolvedAt time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`UPDATE reports SET resolution=?, resolved_at=? WHERE id=?`, resolution, resolvedAt, id)
+	return err
+}
+
+func (repo *Repo) GetOpenReportCount() (int, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	var res int
+	row := repo.db.QueryRow(`SELECT COUNT(*) FROM reports WHERE resolution=''`)
+	err := row.Scan(&res)
+	return res, err
+}
+
+func (repo *Repo) DeleteToot(statusId string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM toots WHERE status_id=?`, statusId)
+	return err
+}
+
+func (repo *Repo) GetFeedBlocks() ([]*FeedBlock, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT id, kind, pattern, reason, created_at FROM feed_blocks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*FeedBlock
+	for rows.Next() {
+		var b FeedBlock
+		if err = rows.Scan(&b.Id, &b.Kind, &b.Pattern, &b.Reason, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &b)
+	}
+	return res, rows.Err()
+}
+
+func (repo *Repo) SaveFeedBlock(block *FeedBlock) (int, error) {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO feed_blocks (kind, pattern, reason, created_at) VALUES(?, ?, ?, ?)
+		ON CONFLICT(kind, pattern) DO UPDATE SET reason=excluded.reason`,
+		block.Kind, block.Pattern, block.Reason, block.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	row := repo.db.QueryRow(`SELECT id FROM feed_blocks WHERE kind=? AND pattern=?`, block.Kind, block.Pattern)
+	err = row.Scan(&id)
+	return id, err
+}
+
+func (repo *Repo) DeleteFeedBlock(id int) (bool, error) {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	res, err := repo.db.Exec(`DELETE FROM feed_blocks WHERE id=?`, id)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+func (repo *Repo) GetFeedAccounts() ([]*Account, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	rows, err := repo.db.Query(`SELECT ` + accountColumns + ` FROM accounts WHERE feed_url<>'' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Account
+	for rows.Next() {
+		var a *Account
+		if a, err = scanAccount(rows); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
+
+func (repo *Repo) GetSysParam(name string) (string, error) {
+
+	repo.muDb.RLock()
+	defer repo.muDb.RUnlock()
+
+	var val sql.NullString
+	row := repo.db.QueryRow(`SELECT val FROM sys_params WHERE name=?`, name)
+	if err := row.Scan(&val); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return val.String, nil
+}
+
+func (repo *Repo) SetSysParam(name, val string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`INSERT INTO sys_params (name, val) VALUES(?, ?)
+		ON CONFLICT(name) DO UPDATE SET val=excluded.val`, name, val)
+	return err
+}
+
+func (repo *Repo) PurgePostsAndToots(accountId int, fromBefore time.Time) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	if _, err := repo.db.Exec(`DELETE FROM feed_posts
+       	WHERE account_id=? AND post_time<=?`, accountId, fromBefore); err != nil {
+		return err
+	}
+	if _, err := repo.db.Exec(`DELETE FROM toots
+       	WHERE account_id=? AND tooted_at<=?`, accountId, fromBefore); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 func (repo *Repo) MarkActivityHandled(id string, when time.Time) (alreadyHandled bool, err error) {
@@ -841,6 +2004,15 @@ func (repo *Repo) MarkActivityHandled(id string, when time.Time) (alreadyHandled
 	return
 }
 
+func (repo *Repo) UnmarkActivityHandled(id string) error {
+
+	repo.muDb.Lock()
+	defer repo.muDb.Unlock()
+
+	_, err := repo.db.Exec(`DELETE FROM handled_activities WHERE activity_id=?`, id)
+	return err
+}
+
 func (repo *Repo) DeleteHandledActivities(before time.Time) error {
 
 	repo.muDb.Lock()
@@ -0,0 +1,2 @@
+ALTER TABLE accounts ADD COLUMN feed_meta_hash INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN profile_updated_at DATETIME NOT NULL DEFAULT '1900-01-01 00:00:00';
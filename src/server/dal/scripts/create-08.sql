@@ -0,0 +1,8 @@
+CREATE TABLE account_tombstones
+(
+    handle     TEXT     NOT NULL,
+    deleted_at DATETIME NOT NULL,
+    pubkey     TEXT     NOT NULL,
+    privkey    TEXT     NOT NULL,
+    PRIMARY KEY (handle)
+);
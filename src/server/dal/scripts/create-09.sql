@@ -0,0 +1,18 @@
+ALTER TABLE toot_queue ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE toot_queue ADD COLUMN next_attempt_at DATETIME NOT NULL DEFAULT '1900-01-01 00:00:00';
+ALTER TABLE toot_queue ADD COLUMN last_error TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_121 ON toot_queue (next_attempt_at);
+
+CREATE TABLE inbox_health
+(
+    inbox          TEXT     NOT NULL,
+    host           TEXT     NOT NULL,
+    last_success   DATETIME NOT NULL DEFAULT '1900-01-01 00:00:00',
+    first_failure  DATETIME NOT NULL DEFAULT '1900-01-01 00:00:00',
+    failure_streak INTEGER  NOT NULL DEFAULT 0,
+    last_error     TEXT     NOT NULL DEFAULT '',
+    PRIMARY KEY (inbox)
+);
+
+CREATE INDEX idx_170 ON inbox_health (host);
@@ -0,0 +1,5 @@
+ALTER TABLE toot_queue ADD COLUMN to_host TEXT NOT NULL DEFAULT '';
+ALTER TABLE toot_queue ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+
+UPDATE toot_queue SET to_host=substr(to_inbox, 9, instr(substr(to_inbox, 9), '/') - 1)
+WHERE to_inbox LIKE 'https://%/%';
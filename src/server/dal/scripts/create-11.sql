@@ -0,0 +1 @@
+ALTER TABLE toot_queue ADD COLUMN activity TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+CREATE TABLE actor_cache
+(
+    user_url           TEXT     NOT NULL,
+    fetched_at         DATETIME NOT NULL,
+    actor_type         TEXT     NOT NULL,
+    preferred_username TEXT     NOT NULL,
+    inbox              TEXT     NOT NULL,
+    shared_inbox       TEXT     NOT NULL,
+    followers          TEXT     NOT NULL,
+    key_id             TEXT     NOT NULL,
+    key_owner          TEXT     NOT NULL,
+    public_key_pem     TEXT     NOT NULL,
+    PRIMARY KEY (user_url)
+);
+CREATE INDEX idx_180 ON actor_cache (fetched_at);
@@ -0,0 +1,3 @@
+ALTER TABLE accounts ADD COLUMN ed_pubkey TEXT NOT NULL DEFAULT '';
+ALTER TABLE accounts ADD COLUMN ed_privkey TEXT NOT NULL DEFAULT '';
+ALTER TABLE actor_cache ADD COLUMN assertion_keys TEXT NOT NULL DEFAULT '';
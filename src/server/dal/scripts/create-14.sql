@@ -0,0 +1,9 @@
+CREATE TABLE relays
+(
+    inbox      TEXT     NOT NULL,
+    follow_id  TEXT     NOT NULL,
+    status     INTEGER  NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (inbox)
+);
+ALTER TABLE accounts ADD COLUMN relay_toots INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+ALTER TABLE accounts ADD COLUMN kind INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE bundle_members
+(
+    bundle_id INTEGER  NOT NULL,
+    member_id INTEGER  NOT NULL,
+    added_at  DATETIME NOT NULL,
+    PRIMARY KEY (bundle_id, member_id)
+);
+CREATE INDEX idx_bundle_members_member ON bundle_members (member_id);
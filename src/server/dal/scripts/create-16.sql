@@ -0,0 +1,8 @@
+CREATE TABLE domain_blocks
+(
+    domain     TEXT     NOT NULL,
+    level      INTEGER  NOT NULL,
+    reason     TEXT     NOT NULL DEFAULT (''),
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (domain)
+);
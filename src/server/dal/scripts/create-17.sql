@@ -0,0 +1 @@
+ALTER TABLE accounts ADD COLUMN manually_approves INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+CREATE TABLE reports
+(
+    id             INTEGER PRIMARY KEY NOT NULL,
+    activity_id    TEXT                NOT NULL,
+    reporter       TEXT                NOT NULL,
+    account_handle TEXT                NOT NULL,
+    status_ids     TEXT                NOT NULL DEFAULT (''),
+    comment        TEXT                NOT NULL DEFAULT (''),
+    created_at     DATETIME            NOT NULL,
+    resolved_at    DATETIME            NOT NULL DEFAULT '1900-01-01 00:00:00',
+    resolution     TEXT                NOT NULL DEFAULT ('')
+);
+CREATE UNIQUE INDEX idx_reports_activity ON reports (activity_id);
+CREATE INDEX idx_reports_resolution ON reports (resolution);
@@ -0,0 +1,7 @@
+CREATE TABLE remote_blocks
+(
+    account_id INTEGER  NOT NULL,
+    actor_url  TEXT     NOT NULL,
+    blocked_at DATETIME NOT NULL,
+    PRIMARY KEY (account_id, actor_url)
+);
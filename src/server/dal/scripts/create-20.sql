@@ -0,0 +1,13 @@
+CREATE TABLE inbound_queue
+(
+    id              INTEGER PRIMARY KEY,
+    receiving_user  TEXT     NOT NULL DEFAULT '',
+    sender          TEXT     NOT NULL,
+    activity        TEXT     NOT NULL,
+    received_at     DATETIME NOT NULL,
+    attempts        INTEGER  NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL DEFAULT '1900-01-01 00:00:00',
+    last_error      TEXT     NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_inbound_queue_next ON inbound_queue (next_attempt_at);
@@ -0,0 +1,10 @@
+ALTER TABLE accounts ADD COLUMN last_checked_at DATETIME NOT NULL DEFAULT '1900-01-01 00:00:00';
+ALTER TABLE accounts ADD COLUMN feed_error_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE feed_errors
+(
+    account_id  INTEGER  NOT NULL,
+    occurred_at DATETIME NOT NULL,
+    error       TEXT     NOT NULL
+);
+CREATE INDEX idx_feed_errors_account ON feed_errors (account_id, occurred_at);
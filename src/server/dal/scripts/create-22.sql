@@ -0,0 +1,4 @@
+ALTER TABLE accounts ADD COLUMN header_image_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE accounts ADD COLUMN locked_fields INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN paused INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN check_interval_min INTEGER NOT NULL DEFAULT 0;
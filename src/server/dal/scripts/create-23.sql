@@ -0,0 +1,9 @@
+CREATE TABLE feed_blocks
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind       TEXT     NOT NULL,
+    pattern    TEXT     NOT NULL,
+    reason     TEXT     NOT NULL DEFAULT (''),
+    created_at DATETIME NOT NULL,
+    UNIQUE (kind, pattern)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE inbound_queue ADD COLUMN sender_id TEXT NOT NULL DEFAULT '';
+UPDATE inbound_queue SET sender_id=COALESCE(json_extract(sender, '$.id'), '');
+CREATE INDEX idx_inbound_queue_sender ON inbound_queue (sender_id, id);
@@ -0,0 +1 @@
+ALTER TABLE relays ADD COLUMN follow_object TEXT NOT NULL DEFAULT '';
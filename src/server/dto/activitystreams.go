@@ -0,0 +1,210 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Servers differ in how they serialize the same ActivityStreams data. Before parsing an inbound activity,
+// we bring it to the compacted shape the rest of the code expects:
+// - "@id" and "@type" become "id" and "type"; "as:" and full-IRI prefixes are dropped from keys and types
+// - A type given as an array becomes its first entry
+// - Actor, attributedTo, inReplyTo and target are always a single ID, even if they came embedded or in an array
+// - Audience properties (to, cc, bto, bcc, audience) are always an array of IDs
+// - Aliases of the public collection become its full IRI
+// - Embedded objects lose their own @context, which only the top level keeps
+// - Objects with only a contentMap get a content from it
+
+const asNamespace = "https://www.w3.org/ns/activitystreams#"
+
+const activityPublic = asNamespace + "Public"
+
+var publicAliases = map[string]struct{}{
+	"Public":    {},
+	"as:Public": {},
+}
+
+var singleIdProps = map[string]struct{}{
+	"actor":        {},
+	"attributedTo": {},
+	"inReplyTo":    {},
+	"target":       {},
+}
+
+var audienceProps = map[string]struct{}{
+	"to":       {},
+	"cc":       {},
+	"bto":      {},
+	"bcc":      {},
+	"audience": {},
+}
+
+// Returns the activity in normalized form. The input must be valid JSON, but it is not required to be an object.
+func NormalizeActivity(data []byte) ([]byte, error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalizeNode(root, true))
+}
+
+func normalizeKey(key string) string {
+	switch key {
+	case "@id":
+		return "id"
+	case "@type":
+		return "type"
+	}
+	key = strings.TrimPrefix(key, asNamespace)
+	return strings.TrimPrefix(key, "as:")
+}
+
+func normalizeNode(node any, isRoot bool) any {
+	switch val := node.(type) {
+	case map[string]any:
+		return normalizeObject(val, isRoot)
+	case []any:
+		res := make([]any, 0, len(val))
+		for _, item := range val {
+			res = append(res, normalizeNode(item, false))
+		}
+		return res
+	default:
+		return node
+	}
+}
+
+func normalizeObject(obj map[string]any, isRoot bool) map[string]any {
+
+	res := make(map[string]any, len(obj))
+	for key, val := range obj {
+		if key == "@context" {
+			if isRoot {
+				res[key] = val
+			}
+			continue
+		}
+		origKey := key
+		key = normalizeKey(key)
+		// Don't let a prefixed duplicate overwrite the plain property
+		if _, exists := res[key]; exists && key != origKey {
+			continue
+		}
+		if key == "type" {
+			res[key] = normalizeType(val)
+		} else if _, ok := singleIdProps[key]; ok {
+			if id := GetId(val); id != "" {
+				res[key] = id
+			} else {
+				res[key] = nil
+			}
+		} else if _, ok := audienceProps[key]; ok {
+			res[key] = normalizeAudience(val)
+		} else {
+			res[key] = normalizeNode(val, false)
+		}
+	}
+
+	if _, hasContent := res["content"]; !hasContent {
+		if contentMap, ok := res["contentMap"].(map[string]any); ok {
+			for _, content := range contentMap {
+				if str, ok := content.(string); ok {
+					res["content"] = str
+					break
+				}
+			}
+		}
+	}
+	return res
+}
+
+func normalizeType(val any) any {
+	if slice, ok := val.([]any); ok {
+		for _, item := range slice {
+			if str, ok := item.(string); ok {
+				val = str
+				break
+			}
+		}
+	}
+	if str, ok := val.(string); ok {
+		str = strings.TrimPrefix(str, asNamespace)
+		return strings.TrimPrefix(str, "as:")
+	}
+	return val
+}
+
+func normalizeAudience(val any) []string {
+	ids := GetIds(val)
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, isAlias := publicAliases[id]; isAlias {
+			id = activityPublic
+		}
+		res = append(res, id)
+	}
+	return res
+}
+
+// Returns the ID of a property that is either an ID or an embedded object. With multiple values, returns the first ID.
+func GetId(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case map[string]any:
+		if id, ok := v["id"].(string); ok {
+			return id
+		}
+		id, _ := v["@id"].(string)
+		return id
+	case []any:
+		for _, item := range v {
+			if id := GetId(item); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// Returns the IDs of a property that may be a single value or an array of IDs and embedded objects.
+// Values without an ID are skipped.
+func GetIds(val any) []string {
+	var items []any
+	if slice, ok := val.([]any); ok {
+		items = slice
+	} else if val != nil {
+		items = []any{val}
+	}
+	var res []string
+	for _, item := range items {
+		if id := GetId(item); id != "" {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// Unmarshals an activity's object into dst. If dst is a string, it receives the object's ID, even if the object
+// is embedded. If dst is a struct but the object is just an ID, dst gets that ID and nothing else.
+func unmarshalObject[T any](raw json.RawMessage, dst *T) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if str, ok := any(dst).(*string); ok {
+		var val any
+		if err := json.Unmarshal(raw, &val); err != nil {
+			return err
+		}
+		*str = GetId(val)
+		return nil
+	}
+	if raw[0] == '"' {
+		var id string
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return err
+		}
+		raw, _ = json.Marshal(map[string]string{"id": id})
+	}
+	return json.Unmarshal(raw, dst)
+}
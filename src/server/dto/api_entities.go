@@ -3,14 +3,189 @@ package dto
 import "time"
 
 type Feed struct {
-	CreatedAt       time.Time `json:"created_at"`
-	UserUrl         string    `json:"user_url"`
+	CreatedAt        time.Time `json:"created_at"`
+	UserUrl          string    `json:"user_url"`
+	Handle           string    `json:"handle"`
+	FeedName         string    `json:"feed_name"`
+	FeedSummary      string    `json:"feed_summary"`
+	ProfileImageUrl  string    `json:"profile_image_url"`
+	SiteUrl          string    `json:"site_url"`
+	FeedUrl          string    `json:"feed_url"`
+	FeedLastUpdated  time.Time `json:"feed_last_updated"`
+	NextCheckDue     time.Time `json:"next_check_due"`
+	RelayToots       bool      `json:"relay_toots"`
+	ManuallyApproves bool      `json:"manually_approves"`
+}
+
+type Bundle struct {
+	CreatedAt time.Time `json:"created_at"`
+	UserUrl   string    `json:"user_url"`
+	Handle    string    `json:"handle"`
+	Name      string    `json:"name"`
+	Summary   string    `json:"summary"`
+	Members   []string  `json:"members"` // Handles of member feed accounts
+}
+
+type DomainBlock struct {
+	Domain    string    `json:"domain"`
+	Level     string    `json:"level"` // silence, reject_follows or suspend
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedBlock struct {
+	Id        int       `json:"id"`
+	Kind      string    `json:"kind"` // url, domain, glob or regex
+	Pattern   string    `json:"pattern"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedBlockRequest struct {
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+	Purge   bool   `json:"purge"` // Also delete the existing accounts that the rule matches
+}
+
+type FeedBlockResult struct {
+	Block    FeedBlock `json:"block"`
+	Matching []string  `json:"matching"` // Handles of existing accounts that the rule matches
+	Purged   bool      `json:"purged"`
+}
+
+type ImportResult struct {
+	Imported int `json:"imported"`
+}
+
+type ManuallyApproves struct {
+	Enabled bool `json:"enabled"`
+}
+
+type FollowRequest struct {
+	UserUrl   string `json:"user_url"`
+	Handle    string `json:"handle"`
+	Host      string `json:"host"`
+	RequestId string `json:"request_id"`
+}
+
+type Report struct {
+	Id         int       `json:"id"`
+	ActivityId string    `json:"activity_id"`
+	Reporter   string    `json:"reporter"`
+	Account    string    `json:"account"`
+	StatusIds  []string  `json:"status_ids"`
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+	Resolution string    `json:"resolution"` // Empty while the report is open
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+type ReportResolution struct {
+	Action string `json:"action"` // dismiss, suspend_account, delete_status or block_feed
+}
+
+type RelayToots struct {
+	Enabled bool `json:"enabled"`
+}
+
+type Relay struct {
+	Inbox     string    `json:"inbox"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DeliveryQueue struct {
+	Total    int `json:"total"`
+	Due      int `json:"due"`
+	Retrying int `json:"retrying"`
+}
+
+type InboxHealth struct {
+	Inbox         string    `json:"inbox"`
+	Host          string    `json:"host"`
+	LastSuccess   time.Time `json:"last_success"`
+	FirstFailure  time.Time `json:"first_failure"`
+	FailureStreak int       `json:"failure_streak"`
+	LastError     string    `json:"last_error"`
+	Dead          bool      `json:"dead"`
+}
+
+type AccountItem struct {
 	Handle          string    `json:"handle"`
+	UserUrl         string    `json:"user_url"`
+	IsBundle        bool      `json:"is_bundle"`
 	FeedName        string    `json:"feed_name"`
-	FeedSummary     string    `json:"feed_summary"`
-	ProfileImageUrl string    `json:"profile_image_url"`
 	SiteUrl         string    `json:"site_url"`
 	FeedUrl         string    `json:"feed_url"`
+	CreatedAt       time.Time `json:"created_at"`
 	FeedLastUpdated time.Time `json:"feed_last_updated"`
+	LastCheckedAt   time.Time `json:"last_checked_at"`
 	NextCheckDue    time.Time `json:"next_check_due"`
+	FeedErrorCount  int       `json:"feed_error_count"` // Failed checks since the last successful one
+	FollowerCount   int       `json:"follower_count"`
+	Paused          bool      `json:"paused"`
+}
+
+type AccountList struct {
+	Accounts   []AccountItem `json:"accounts"`
+	NextCursor string        `json:"next_cursor,omitempty"` // Pass as ?cursor= to get the next page; empty on the last page
+}
+
+type FeedError struct {
+	OccurredAt time.Time `json:"occurred_at"`
+	Error      string    `json:"error"`
+}
+
+type AccountDetails struct {
+	AccountItem
+	FeedSummary      string      `json:"feed_summary"`
+	ProfileImageUrl  string      `json:"profile_image_url"`
+	HeaderImageUrl   string      `json:"header_image_url"`
+	Locked           []string    `json:"locked"` // Profile fields set by an admin, which the feed no longer changes
+	CheckIntervalMin int         `json:"check_interval_min"`
+	RelayToots       bool        `json:"relay_toots"`
+	ManuallyApproves bool        `json:"manually_approves"`
+	PendingFollowers int         `json:"pending_followers"`
+	PostCount        int         `json:"post_count"`
+	QueuedToots      int         `json:"queued_toots"` // Deliveries from this account waiting in the queue
+	RecentErrors     []FeedError `json:"recent_errors"`
+}
+
+// Fields to change in an account; missing fields stay as they are.
+// Setting a profile field locks it, so feed checks don't change it; list fields in unlock to release them.
+// Manual approval of followers is turned on and off with PUT /api/accounts/{account}/manually-approves.
+type AccountPatch struct {
+	FeedName         *string  `json:"feed_name"`
+	FeedSummary      *string  `json:"feed_summary"`
+	ProfileImageUrl  *string  `json:"profile_image_url"`
+	HeaderImageUrl   *string  `json:"header_image_url"`
+	FeedUrl          *string  `json:"feed_url"`
+	CheckIntervalMin *int     `json:"check_interval_min"` // 0: schedule follows how often the feed has new posts
+	Paused           *bool    `json:"paused"`
+	RelayToots       *bool    `json:"relay_toots"`
+	Unlock           []string `json:"unlock"` // feed_name, feed_summary or profile_image_url
+}
+
+// What happened when an account's feed was checked on request
+type FeedDiagnostics struct {
+	FeedUrl        string            `json:"feed_url"`
+	HttpStatus     int               `json:"http_status"` // 0 if the request failed before a response
+	Redirects      []string          `json:"redirects"`   // URLs we were redirected to, in order
+	ResponseTimeMs int64             `json:"response_time_ms"`
+	ResponseBytes  int               `json:"response_bytes"`
+	Truncated      bool              `json:"truncated"` // Response was larger than we read; the feed is not parsed then
+	Error          string            `json:"error"`     // Why the update failed, e.g., a parse error; empty on success
+	ItemCount      int               `json:"item_count"`
+	LastSeenBefore time.Time         `json:"last_seen_before"` // Time of the newest post we knew about before the check
+	Items          []FeedItemVerdict `json:"items"`
+	NextCheckDue   time.Time         `json:"next_check_due"`
+}
+
+type FeedItemVerdict struct {
+	Title     string     `json:"title"`
+	Link      string     `json:"link"`
+	Published *time.Time `json:"published"`
+	Updated   *time.Time `json:"updated"`
+	Verdict   string     `json:"verdict"` // new, not_newer, future or no_date
 }
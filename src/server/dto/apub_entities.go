@@ -3,27 +3,52 @@ package dto
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 )
 
 type UserInfo struct {
-	Context           any           `json:"@context"`
-	Id                string        `json:"id"`
-	Type              string        `json:"type"`
-	PreferredUserName string        `json:"preferredUsername"`
-	Name              string        `json:"name"`
-	Summary           string        `json:"summary"`
-	ManuallyApproves  bool          `json:"manuallyApprovesFollowers"`
-	Published         string        `json:"published"`
-	Inbox             string        `json:"inbox"`
-	Outbox            string        `json:"outbox"`
-	Followers         string        `json:"followers"`
-	Following         string        `json:"following"`
-	Endpoints         UserEndpoints `json:"endpoints"`
-	PublicKey         PublicKey     `json:"publicKey"`
-	Attachments       []Attachment  `json:"attachment"`
-	Icon              Image         `json:"icon"`
-	Image             Image         `json:"image"`
+	Context            any           `json:"@context"`
+	Id                 string        `json:"id"`
+	Type               string        `json:"type"`
+	PreferredUserName  string        `json:"preferredUsername"`
+	Name               string        `json:"name"`
+	Summary            string        `json:"summary"`
+	ManuallyApproves   bool          `json:"manuallyApprovesFollowers"`
+	Published          string        `json:"published"`
+	Inbox              string        `json:"inbox"`
+	Outbox             string        `json:"outbox"`
+	Followers          string        `json:"followers"`
+	Following          string        `json:"following"`
+	Endpoints          UserEndpoints `json:"endpoints"`
+	PublicKey          PublicKey     `json:"publicKey"`
+	AssertionMethod    []Multikey    `json:"-"`
+	RawAssertionMethod any           `json:"assertionMethod,omitempty"`
+	AlsoKnownAs        []string      `json:"-"`
+	RawAlsoKnownAs     any           `json:"alsoKnownAs,omitempty"`
+	Attachments        []Attachment  `json:"attachment"`
+	Icon               Image         `json:"icon"`
+	Image              Image         `json:"image"`
+}
+
+func (x *UserInfo) UnmarshalJSON(data []byte) error {
+	type Y UserInfo
+	var y = (*Y)(x)
+	if err := json.Unmarshal(data, y); err != nil {
+		return err
+	}
+	y.AssertionMethod = getMultikeys(y.RawAssertionMethod)
+	// Aliases are only needed to verify a Move; an actor with malformed ones is still good otherwise
+	y.AlsoKnownAs = GetIds(y.RawAlsoKnownAs)
+	return nil
+}
+
+func (x *UserInfo) MarshalJSON() ([]byte, error) {
+	type Y UserInfo
+	var y = (*Y)(x)
+	y.RawAssertionMethod = nil
+	if len(y.AssertionMethod) != 0 {
+		y.RawAssertionMethod = y.AssertionMethod
+	}
+	return json.Marshal(y)
 }
 
 type Attachment struct {
@@ -47,6 +72,49 @@ type PublicKey struct {
 	PublicKeyPem string `json:"publicKeyPem"`
 }
 
+// Verification method for object integrity proofs (FEP-521a)
+type Multikey struct {
+	Id                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyMultibase string `json:"publicKeyMultibase"`
+}
+
+// assertionMethod may be a single object or an array; entries that are only links are ignored
+func getMultikeys(raw any) []Multikey {
+	var items []any
+	switch val := raw.(type) {
+	case map[string]any:
+		items = []any{val}
+	case []any:
+		items = val
+	}
+	var res []Multikey
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		var mk Multikey
+		mk.Id, _ = obj["id"].(string)
+		mk.Type, _ = obj["type"].(string)
+		mk.Controller, _ = obj["controller"].(string)
+		mk.PublicKeyMultibase, _ = obj["publicKeyMultibase"].(string)
+		if mk.Type == "Multikey" && mk.PublicKeyMultibase != "" {
+			res = append(res, mk)
+		}
+	}
+	return res
+}
+
+type Tombstone struct {
+	Context    any    `json:"@context,omitempty"`
+	Id         string `json:"id"`
+	Type       string `json:"type"`
+	FormerType string `json:"formerType,omitempty"`
+	Deleted    string `json:"deleted,omitempty"`
+}
+
 type OrderedListSummary struct {
 	Context    any     `json:"@context"`
 	Id         string  `json:"id"`
@@ -56,27 +124,6 @@ type OrderedListSummary struct {
 	Last       *string `json:"last,omitempty"`
 }
 
-func getRecipient(raw any) ([]string, error) {
-	var res []string
-	if raw == nil {
-		return res, nil
-	}
-	if slice, ok := raw.([]interface{}); ok {
-		for _, s := range slice {
-			if str, ok := s.(string); ok {
-				res = append(res, str)
-			} else {
-				return res, fmt.Errorf("list of recipients must only contain strings")
-			}
-		}
-	} else if str, ok := raw.(string); ok {
-		res = []string{str}
-	} else {
-		return res, fmt.Errorf("to and cc must be single string or array of strings")
-	}
-	return res, nil
-}
-
 type ActivityInBase struct {
 	Id     string   `json:"id"`
 	Type   string   `json:"type"`
@@ -90,17 +137,16 @@ type ActivityInBase struct {
 
 func (x *ActivityInBase) UnmarshalJSON(data []byte) error {
 	var err error
+	if data, err = NormalizeActivity(data); err != nil {
+		return err
+	}
 	type Y ActivityInBase
 	var y = (*Y)(x)
 	if err = json.Unmarshal(data, y); err != nil {
 		return err
 	}
-	if y.To, err = getRecipient(y.RawTo); err != nil {
-		return err
-	}
-	if y.Cc, err = getRecipient(y.RawCc); err != nil {
-		return err
-	}
+	y.To = GetIds(y.RawTo)
+	y.Cc = GetIds(y.RawCc)
 	return nil
 }
 
@@ -117,20 +163,66 @@ type ActivityIn[T any] struct {
 
 func (x *ActivityIn[T]) UnmarshalJSON(data []byte) error {
 	var err error
-	type Y ActivityIn[T]
-	var y = (*Y)(x)
-	if err = json.Unmarshal(data, y); err != nil {
+	if data, err = NormalizeActivity(data); err != nil {
 		return err
 	}
-	if y.To, err = getRecipient(y.RawTo); err != nil {
+	// Object is parsed separately: it may be an ID or an embedded object, whatever T is
+	type Y ActivityIn[T]
+	var y = struct {
+		*Y
+		RawObject json.RawMessage `json:"object"`
+	}{Y: (*Y)(x)}
+	if err = json.Unmarshal(data, &y); err != nil {
 		return err
 	}
-	if y.Cc, err = getRecipient(y.RawCc); err != nil {
+	if err = unmarshalObject(y.RawObject, &x.Object); err != nil {
 		return err
 	}
+	x.To = GetIds(x.RawTo)
+	x.Cc = GetIds(x.RawCc)
 	return nil
 }
 
+// Move activity: actor moved their account from object (which is themselves) to target
+type Move struct {
+	Id     string `json:"id"`
+	Type   string `json:"type"`
+	Actor  string `json:"actor"`
+	Object string `json:"object"`
+	Target string `json:"target"`
+}
+
+func (x *Move) UnmarshalJSON(data []byte) error {
+	var err error
+	if data, err = NormalizeActivity(data); err != nil {
+		return err
+	}
+	// Some servers embed the old actor instead of giving its ID
+	type Y Move
+	var y = struct {
+		*Y
+		RawObject json.RawMessage `json:"object"`
+	}{Y: (*Y)(x)}
+	if err = json.Unmarshal(data, &y); err != nil {
+		return err
+	}
+	return unmarshalObject(y.RawObject, &x.Object)
+}
+
+// Flag activity: object is the reported actor and/or statuses, as a single ID or a list
+type Flag struct {
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Actor   string `json:"actor"`
+	Content string `json:"content"`
+	Object  any    `json:"object"`
+}
+
+// Returns the IDs in the object, whether they come as strings or as objects with an id
+func (x *Flag) ObjectIds() []string {
+	return GetIds(x.Object)
+}
+
 type ActivityOut struct {
 	Context any       `json:"@context"`
 	Id      string    `json:"id"`
@@ -165,12 +257,8 @@ func (x *Note) UnmarshalJSON(data []byte) error {
 	if err = json.Unmarshal(data, y); err != nil {
 		return err
 	}
-	if y.To, err = getRecipient(y.RawTo); err != nil {
-		return err
-	}
-	if y.Cc, err = getRecipient(y.RawCc); err != nil {
-		return err
-	}
+	y.To = GetIds(y.RawTo)
+	y.Cc = GetIds(y.RawCc)
 	if y.Tag, err = getTag(y.RawTag); err != nil {
 		return err
 	}
@@ -198,44 +286,39 @@ func getTag(raw any) (*[]Tag, error) {
 		return nil, nil
 	}
 
-	retrieve := func(obj *map[string]interface{}) (*Tag, error) {
+	// Servers put all sorts of things in tags (emoji, hashtags without href...)
+	// Items we can't use are skipped rather than rejecting the whole object
+	retrieve := func(val any) *Tag {
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
 		var tag Tag
-		var ok bool
-		if tag.Href, ok = (*obj)["href"].(string); !ok {
-			return nil, errors.New("invalid data in tag's 'href' property; string expected")
+		if tag.Href, ok = obj["href"].(string); !ok {
+			return nil
 		}
-		if tag.Name, ok = (*obj)["name"].(string); !ok {
-			return nil, errors.New("invalid data in tag's 'name' property; string expected")
+		if tag.Name, ok = obj["name"].(string); !ok {
+			return nil
 		}
-		if tag.Type, ok = (*obj)["type"].(string); !ok {
-			return nil, errors.New("invalid data in tag's 'type' property; string expected")
+		if tag.Type, ok = obj["type"].(string); !ok {
+			return nil
 		}
-		return &tag, nil
+		return &tag
 	}
 
-	// Single Tag object
-	if obj, ok := raw.(map[string]interface{}); ok {
-		if tag, err := retrieve(&obj); err != nil {
-			return nil, err
-		} else {
-			return &[]Tag{*tag}, nil
-		}
-	}
-	// Array
+	var items []any
 	if slice, ok := raw.([]interface{}); ok {
-		var res []Tag
-		for _, s := range slice {
-			if obj, ok := s.(map[string]interface{}); ok {
-				if tag, err := retrieve(&obj); err != nil {
-					return nil, err
-				} else {
-					res = append(res, *tag)
-				}
-			} else {
-				return nil, errors.New("unexpected item in 'tag' array; must only contain tag objects")
-			}
+		items = slice
+	} else if _, ok := raw.(map[string]interface{}); ok {
+		items = []any{raw}
+	} else {
+		return nil, errors.New("invalid data in 'tag' property")
+	}
+	res := []Tag{}
+	for _, item := range items {
+		if tag := retrieve(item); tag != nil {
+			res = append(res, *tag)
 		}
-		return &res, nil
 	}
-	return nil, errors.New("invalid data in 'tag' property")
+	return &res, nil
 }
@@ -0,0 +1,74 @@
+package dto
+
+type NodeInfoLinks struct {
+	Links []NodeInfoLink `json:"links"`
+}
+
+type NodeInfoLink struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+}
+
+// NodeInfo 2.1 document
+type NodeInfo struct {
+	Version           string           `json:"version"`
+	Software          NodeInfoSoftware `json:"software"`
+	Protocols         []string         `json:"protocols"`
+	Services          NodeInfoServices `json:"services"`
+	OpenRegistrations bool             `json:"openRegistrations"`
+	Usage             NodeInfoUsage    `json:"usage"`
+	Metadata          NodeInfoMetadata `json:"metadata"`
+}
+
+type NodeInfoSoftware struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Repository string `json:"repository"`
+	Homepage   string `json:"homepage"`
+}
+
+type NodeInfoServices struct {
+	Inbound  []string `json:"inbound"`
+	Outbound []string `json:"outbound"`
+}
+
+type NodeInfoUsage struct {
+	Users      NodeInfoUsers `json:"users"`
+	LocalPosts int           `json:"localPosts"`
+}
+
+type NodeInfoUsers struct {
+	Total int `json:"total"`
+}
+
+type NodeInfoMetadata struct {
+	NodeName        string `json:"nodeName"`
+	NodeDesc        string `json:"nodeDescription"`
+	FeedFollowers   int    `json:"feedFollowers"`
+	FollowerServers int    `json:"followerServers"`
+}
+
+// Subset of Mastodon's v1 instance entity that clients need to display the instance
+type MastodonInstance struct {
+	Uri              string                `json:"uri"`
+	Title            string                `json:"title"`
+	ShortDescription string                `json:"short_description"`
+	Description      string                `json:"description"`
+	Email            string                `json:"email"`
+	Version          string                `json:"version"`
+	Urls             map[string]string     `json:"urls"`
+	Stats            MastodonInstanceStats `json:"stats"`
+	Thumbnail        string                `json:"thumbnail"`
+	Languages        []string              `json:"languages"`
+	Registrations    bool                  `json:"registrations"`
+	ApprovalRequired bool                  `json:"approval_required"`
+	InvitesEnabled   bool                  `json:"invites_enabled"`
+	ContactAccount   any                   `json:"contact_account"`
+	Rules            []any                 `json:"rules"`
+}
+
+type MastodonInstanceStats struct {
+	UserCount   int `json:"user_count"`
+	StatusCount int `json:"status_count"`
+	DomainCount int `json:"domain_count"`
+}
@@ -19,24 +19,27 @@ import (
 
 type IActivitySender interface {
 	Send(privKey *rsa.PrivateKey, sendingUser, inboxUrl string, activity *dto.ActivityOut) error
+	SendJson(privKey *rsa.PrivateKey, sendingUser, inboxUrl string, bodyJson []byte) error
 }
 
 const activityTimeoutSec = 10
 
 type activitySender struct {
-	cfg       *shared.Config
-	logger    shared.ILogger
-	userAgent shared.IUserAgent
-	metrics   IMetrics
-	idb       shared.IdBuilder
+	cfg        *shared.Config
+	logger     shared.ILogger
+	userAgent  shared.IUserAgent
+	metrics    IMetrics
+	sigSchemes ISigSchemes
+	idb        shared.IdBuilder
 }
 
 func NewActivitySender(cfg *shared.Config,
 	logger shared.ILogger,
 	userAgent shared.IUserAgent,
 	metrics IMetrics,
+	sigSchemes ISigSchemes,
 ) IActivitySender {
-	return &activitySender{cfg, logger, userAgent, metrics, shared.IdBuilder{cfg.Host}}
+	return &activitySender{cfg, logger, userAgent, metrics, sigSchemes, shared.IdBuilder{cfg.Host}}
 }
 
 func (sender *activitySender) Send(
@@ -45,9 +48,19 @@ func (sender *activitySender) Send(
 	inboxUrl string,
 	activity *dto.ActivityOut,
 ) error {
+	bodyJson, _ := json.Marshal(activity)
+	return sender.SendJson(privKey, sendingUser, inboxUrl, bodyJson)
+}
 
-	obs := sender.metrics.StartApubRequestOut("post")
-	defer obs.Finish()
+// Sends an already serialized activity, e.g., one that was stored in the outbox queue.
+// Peers that have signed requests to us with RFC 9421 get an RFC 9421 signature; if they reject it,
+// we fall back to the draft-cavage signature that everyone understands.
+func (sender *activitySender) SendJson(
+	privKey *rsa.PrivateKey,
+	sendingUser,
+	inboxUrl string,
+	bodyJson []byte,
+) error {
 
 	host := strings.Replace(inboxUrl, "https://", "", -1)
 	slashIx := strings.IndexByte(host, '/')
@@ -56,39 +69,71 @@ func (sender *activitySender) Send(
 	}
 	host = host[:slashIx]
 
-	bodyJson, _ := json.Marshal(activity)
-	dateStr := time.Now().UTC().Format(http.TimeFormat)
+	if sender.sigSchemes.PrefersRfc9421(host) {
+		status, err := sender.post(privKey, sendingUser, inboxUrl, host, bodyJson, true)
+		if err == nil {
+			return nil
+		}
+		if status != http.StatusBadRequest && status != http.StatusUnauthorized && status != http.StatusForbidden {
+			return err
+		}
+		sender.logger.Infof("RFC 9421 signature rejected by %s; falling back to draft-cavage", host)
+		sender.sigSchemes.ClearRfc9421(host)
+	}
 
-	// DBG
-	//fmt.Println(string(bodyJson))
+	_, err := sender.post(privKey, sendingUser, inboxUrl, host, bodyJson, false)
+	return err
+}
+
+// Signs and posts the activity. Returns the response's status code if we got one.
+func (sender *activitySender) post(
+	privKey *rsa.PrivateKey,
+	sendingUser,
+	inboxUrl,
+	host string,
+	bodyJson []byte,
+	useRfc9421 bool,
+) (int, error) {
+
+	obs := sender.metrics.StartApubRequestOut("post")
+	defer obs.Finish()
+
+	dateStr := time.Now().UTC().Format(http.TimeFormat)
 
 	req, err := http.NewRequest("POST", inboxUrl, bytes.NewBuffer(bodyJson))
+	if err != nil {
+		return 0, err
+	}
 	sender.userAgent.AddUserAgent(req)
 	req.Header.Set("Content-Type", "application/activity+json")
 	req.Header.Set("host", host)
 	req.Header.Set("date", dateStr)
 
-	signer, _, err := httpsig.NewSigner(
-		[]httpsig.Algorithm{httpsig.RSA_SHA256},
-		httpsig.DigestSha256,
-		[]string{httpsig.RequestTarget, "Host", "date", "digest"},
-		httpsig.Signature,
-		0)
-	if err != nil {
-		return err
-	}
-
 	keyId := sender.idb.UserKeyId(sendingUser)
-	err = signer.SignRequest(privKey, keyId, req, bodyJson)
-	if err != nil {
-		return err
+	if useRfc9421 {
+		if err = shared.SignRfc9421(req, privKey, keyId, bodyJson); err != nil {
+			return 0, err
+		}
+	} else {
+		signer, _, err := httpsig.NewSigner(
+			[]httpsig.Algorithm{httpsig.RSA_SHA256},
+			httpsig.DigestSha256,
+			[]string{httpsig.RequestTarget, "Host", "date", "digest"},
+			httpsig.Signature,
+			0)
+		if err != nil {
+			return 0, err
+		}
+		if err = signer.SignRequest(privKey, keyId, req, bodyJson); err != nil {
+			return 0, err
+		}
 	}
 
 	client := http.Client{}
 	client.Timeout = time.Second * activityTimeoutSec
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	defer resp.Body.Close()
@@ -100,8 +145,8 @@ func (sender *activitySender) Send(
 	if resp.StatusCode >= 300 {
 		msg := fmt.Sprintf("got status %s: response: %s", resp.Status, respBody)
 		sender.logger.Warnf("Activity POST failed to %s: %s", inboxUrl, msg)
-		return errors.New(msg)
+		return resp.StatusCode, errors.New(msg)
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
@@ -2,43 +2,294 @@ package logic
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"rss_parrot/dal"
 	"rss_parrot/shared"
 	"strings"
+	"sync"
+	"time"
 )
 
 //go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_blocked_feeds.go -package mocks rss_parrot/logic IBlockedFeeds
 
 type IBlockedFeeds interface {
 	IsBlocked(feedUrl string) (bool, error)
+	GetBlocks() ([]*dal.FeedBlock, error)
+	// Validates and stores a rule; kind is one of the dal.FeedBlock* values. Adding an existing rule updates its reason.
+	AddBlock(kind, pattern, reason string) (*dal.FeedBlock, error)
+	RemoveBlock(id int) (found bool, err error)
+	// Returns the accounts whose feed the rule blocks
+	GetMatchingAccounts(block *dal.FeedBlock) ([]*dal.Account, error)
 }
 
-type blockedFeeds struct {
-	cfg *shared.Config
+// System parameter that records which block list file has been imported into the DB
+const feedBlocksImportedParam = "blocked_feeds_imported"
+
+type feedBlockRule struct {
+	block *dal.FeedBlock
+	re    *regexp.Regexp
 }
 
-func NewBlockedFeeds(cfg *shared.Config) IBlockedFeeds {
-	return &blockedFeeds{cfg}
+// Rules from the DB, arranged for quick lookup
+type feedBlockIndex struct {
+	urls     map[string]struct{}
+	domains  map[string]struct{}
+	patterns []*regexp.Regexp
 }
 
-func (bf *blockedFeeds) IsBlocked(feedUrl string) (bool, error) {
+type blockedFeeds struct {
+	cfg    *shared.Config
+	logger shared.ILogger
+	repo   dal.IRepo
+	mu     sync.Mutex
+	index  *feedBlockIndex // nil if it needs to be reloaded
+}
 
-	feedUrl = strings.ToLower(feedUrl)
+func NewBlockedFeeds(cfg *shared.Config, logger shared.ILogger, repo dal.IRepo) IBlockedFeeds {
+	return &blockedFeeds{cfg: cfg, logger: logger, repo: repo}
+}
+
+func normalizeFeedUrl(feedUrl string) string {
+	feedUrl = strings.ToLower(strings.TrimSpace(feedUrl))
 	feedUrl = strings.TrimPrefix(feedUrl, "https://")
 	feedUrl = strings.TrimPrefix(feedUrl, "http://")
-	readFile, err := os.Open(bf.cfg.BlockedFeedsFile)
+	return feedUrl
+}
+
+// Returns the lowercase host of a feed URL, which may lack a scheme; empty string if it cannot be parsed
+func getFeedHost(feedUrl string) string {
+	parsedUrl, err := url.Parse("https://" + normalizeFeedUrl(feedUrl))
 	if err != nil {
-		return false, err
+		return ""
+	}
+	return strings.TrimSuffix(parsedUrl.Hostname(), ".")
+}
+
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.ReplaceAll(expr, `\*`, `.*`)
+	expr = strings.ReplaceAll(expr, `\?`, `.`)
+	return regexp.Compile("^" + expr + "$")
+}
+
+// Normalizes the rule's pattern and compiles it if it is a glob or a regex
+func parseFeedBlock(kind, pattern string) (*feedBlockRule, error) {
+
+	var err error
+	rule := feedBlockRule{block: &dal.FeedBlock{Kind: kind}}
+	switch kind {
+	case dal.FeedBlockUrl:
+		rule.block.Pattern = normalizeFeedUrl(pattern)
+		if rule.block.Pattern == "" {
+			return nil, errors.New("feed URL must not be empty")
+		}
+	case dal.FeedBlockDomain:
+		if rule.block.Pattern, err = normalizeDomain(pattern); err != nil {
+			return nil, err
+		}
+	case dal.FeedBlockGlob:
+		rule.block.Pattern = normalizeFeedUrl(pattern)
+		if rule.block.Pattern == "" || strings.Trim(rule.block.Pattern, "*") == "" {
+			return nil, fmt.Errorf("glob would block every feed: '%s'", pattern)
+		}
+		if rule.re, err = globToRegexp(rule.block.Pattern); err != nil {
+			return nil, err
+		}
+	case dal.FeedBlockRegex:
+		rule.block.Pattern = strings.TrimSpace(pattern)
+		if rule.block.Pattern == "" {
+			return nil, errors.New("regex must not be empty")
+		}
+		if rule.re, err = regexp.Compile("(?i)" + rule.block.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid regex: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown kind of feed block: '%s'", kind)
+	}
+	return &rule, nil
+}
+
+func (rule *feedBlockRule) matches(feedUrl string) bool {
+	switch rule.block.Kind {
+	case dal.FeedBlockUrl:
+		return normalizeFeedUrl(feedUrl) == rule.block.Pattern
+	case dal.FeedBlockDomain:
+		host := getFeedHost(feedUrl)
+		return host == rule.block.Pattern || strings.HasSuffix(host, "."+rule.block.Pattern)
+	default:
+		return rule.re.MatchString(normalizeFeedUrl(feedUrl))
+	}
+}
+
+// Adds the URLs in the block list file as rules, unless that file has been imported already
+func (bf *blockedFeeds) importFileOnce() error {
+
+	fileName := bf.cfg.BlockedFeedsFile
+	if fileName == "" {
+		return nil
+	}
+	imported, err := bf.repo.GetSysParam(feedBlocksImportedParam)
+	if err != nil || imported == fileName {
+		return err
+	}
+
+	readFile, err := os.Open(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
 	}
 	defer readFile.Close()
 	fileScanner := bufio.NewScanner(readFile)
 	fileScanner.Split(bufio.ScanLines)
 
+	count := 0
+	now := time.Now().UTC()
 	for fileScanner.Scan() {
-		line := fileScanner.Text()
-		if feedUrl == line {
+		line := normalizeFeedUrl(fileScanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		block := dal.FeedBlock{Kind: dal.FeedBlockUrl, Pattern: line, Reason: "Imported from block list file", CreatedAt: now}
+		if _, err = bf.repo.SaveFeedBlock(&block); err != nil {
+			return err
+		}
+		count++
+	}
+	if err = fileScanner.Err(); err != nil {
+		return err
+	}
+	bf.logger.Infof("Imported %d blocked feeds from %s", count, fileName)
+	return bf.repo.SetSysParam(feedBlocksImportedParam, fileName)
+}
+
+func (bf *blockedFeeds) loadIndex() error {
+
+	if bf.index != nil {
+		return nil
+	}
+	if err := bf.importFileOnce(); err != nil {
+		return err
+	}
+	blocks, err := bf.repo.GetFeedBlocks()
+	if err != nil {
+		return err
+	}
+	index := feedBlockIndex{urls: map[string]struct{}{}, domains: map[string]struct{}{}}
+	for _, b := range blocks {
+		switch b.Kind {
+		case dal.FeedBlockUrl:
+			index.urls[b.Pattern] = struct{}{}
+		case dal.FeedBlockDomain:
+			index.domains[b.Pattern] = struct{}{}
+		default:
+			rule, err := parseFeedBlock(b.Kind, b.Pattern)
+			if err != nil {
+				// Don't let one bad rule in the DB disable all the others
+				bf.logger.Errorf("Ignoring invalid feed block %d: %v", b.Id, err)
+				continue
+			}
+			index.patterns = append(index.patterns, rule.re)
+		}
+	}
+	bf.index = &index
+	return nil
+}
+
+func (bf *blockedFeeds) IsBlocked(feedUrl string) (bool, error) {
+
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+
+	if err := bf.loadIndex(); err != nil {
+		return false, err
+	}
+
+	feedUrl = normalizeFeedUrl(feedUrl)
+	if _, found := bf.index.urls[feedUrl]; found {
+		return true, nil
+	}
+	host := getFeedHost(feedUrl)
+	for host != "" {
+		if _, found := bf.index.domains[host]; found {
+			return true, nil
+		}
+		dotIx := strings.IndexByte(host, '.')
+		if dotIx == -1 {
+			break
+		}
+		host = host[dotIx+1:]
+	}
+	for _, re := range bf.index.patterns {
+		if re.MatchString(feedUrl) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
+
+func (bf *blockedFeeds) GetBlocks() ([]*dal.FeedBlock, error) {
+
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+
+	// So that the file's rules show up even before the first feed has been checked
+	if err := bf.importFileOnce(); err != nil {
+		return nil, err
+	}
+	return bf.repo.GetFeedBlocks()
+}
+
+func (bf *blockedFeeds) AddBlock(kind, pattern, reason string) (*dal.FeedBlock, error) {
+
+	rule, err := parseFeedBlock(kind, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+
+	bf.index = nil
+	bf.logger.Infof("Blocking feeds: %s %s", rule.block.Kind, rule.block.Pattern)
+	rule.block.Reason = reason
+	rule.block.CreatedAt = time.Now().UTC()
+	if rule.block.Id, err = bf.repo.SaveFeedBlock(rule.block); err != nil {
+		return nil, err
+	}
+	return rule.block, nil
+}
+
+func (bf *blockedFeeds) RemoveBlock(id int) (bool, error) {
+
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+
+	bf.index = nil
+	bf.logger.Infof("Removing feed block %d", id)
+	return bf.repo.DeleteFeedBlock(id)
+}
+
+func (bf *blockedFeeds) GetMatchingAccounts(block *dal.FeedBlock) ([]*dal.Account, error) {
+
+	rule, err := parseFeedBlock(block.Kind, block.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := bf.repo.GetFeedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var res []*dal.Account
+	for _, acct := range accounts {
+		if rule.matches(acct.FeedUrl) {
+			res = append(res, acct)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,145 @@
+package logic
+
+import (
+	"fmt"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/shared"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_bundles.go -package mocks rss_parrot/logic IBundles
+
+type IBundles interface {
+	// Creates a bundle account with its own key; returns the existing account if the bundle is already there.
+	CreateBundle(handle, name, summary string) (acct *dal.Account, isNew bool, err error)
+	// Has every bundle that the account is a member of boost the toot
+	AnnounceToot(memberId int, statusId string)
+}
+
+type bundles struct {
+	cfg       *shared.Config
+	logger    shared.ILogger
+	repo      dal.IRepo
+	keyStore  IKeyStore
+	messenger IMessenger
+	relays    IRelays
+	idb       shared.IdBuilder
+}
+
+func NewBundles(
+	cfg *shared.Config,
+	logger shared.ILogger,
+	repo dal.IRepo,
+	keyStore IKeyStore,
+	messenger IMessenger,
+	relays IRelays,
+) IBundles {
+	return &bundles{
+		cfg:       cfg,
+		logger:    logger,
+		repo:      repo,
+		keyStore:  keyStore,
+		messenger: messenger,
+		relays:    relays,
+		idb:       shared.IdBuilder{Host: cfg.Host},
+	}
+}
+
+func (bn *bundles) CreateBundle(handle, name, summary string) (acct *dal.Account, isNew bool, err error) {
+
+	if acct, err = bn.repo.GetAccount(handle); err != nil {
+		return
+	}
+	if acct != nil {
+		if !acct.IsBundle() {
+			err = fmt.Errorf("handle is taken by an account that is not a bundle: %s", handle)
+			acct = nil
+		}
+		return
+	}
+
+	// Same as with feeds: a handle that existed before gets its old key back
+	var pubKey, privKey string
+	var tomb *dal.AccountTombstone
+	if tomb, err = bn.repo.GetAccountTombstone(handle); err != nil {
+		return
+	}
+	if tomb != nil {
+		pubKey, privKey = tomb.PubKey, tomb.PrivKey
+	} else if pubKey, privKey, err = bn.keyStore.MakeKeyPair(); err != nil {
+		return
+	}
+
+	isNew, err = bn.repo.AddAccountIfNotExist(&dal.Account{
+		Kind:        dal.AccountKindBundle,
+		CreatedAt:   time.Now(),
+		Handle:      handle,
+		UserUrl:     bn.idb.UserUrl(handle),
+		FeedName:    name,
+		FeedSummary: summary,
+		SiteUrl:     bn.idb.UserProfile(handle),
+		PubKey:      pubKey,
+	}, privKey)
+	if err != nil {
+		return
+	}
+	if isNew && tomb != nil {
+		if err = bn.repo.DeleteAccountTombstone(handle); err != nil {
+			return
+		}
+	}
+	if isNew {
+		if err = bn.keyStore.CreateEdKeys(handle); err != nil {
+			return
+		}
+	}
+	if acct, err = bn.repo.GetAccount(handle); err != nil {
+		return
+	}
+
+	bn.logger.Infof("Bundle is %s; newly created: %v", handle, isNew)
+	if isNew {
+		bn.relays.AnnounceAccount(handle)
+	}
+	return
+}
+
+func (bn *bundles) AnnounceToot(memberId int, statusId string) {
+
+	bundleAccts, err := bn.repo.GetBundlesOfMember(memberId)
+	if err != nil {
+		bn.logger.Errorf("Failed to get bundles of account %d: %v", memberId, err)
+		return
+	}
+	for _, bundle := range bundleAccts {
+		if err = bn.announce(bundle.Handle, statusId); err != nil {
+			bn.logger.Errorf("Failed to queue Announce of %s by bundle %s: %v", statusId, bundle.Handle, err)
+		}
+	}
+}
+
+func (bn *bundles) announce(bundle, statusId string) error {
+
+	followers, err := bn.repo.GetFollowersByUser(bundle, true)
+	if err != nil {
+		return err
+	}
+	inboxes := getDistinctInboxes(followers)
+	if len(inboxes) == 0 {
+		return nil
+	}
+
+	to := []string{shared.ActivityPublic}
+	cc := []string{bn.idb.UserFollowers(bundle)}
+	act := &dto.ActivityOut{
+		Context: "https://www.w3.org/ns/activitystreams",
+		Id:      bn.idb.ActivityUrl(bn.repo.GetNextId()),
+		Type:    "Announce",
+		Actor:   bn.idb.UserUrl(bundle),
+		To:      &to,
+		Cc:      &cc,
+		Object:  statusId,
+	}
+	return bn.messenger.EnqueueActivityToMany(bundle, inboxes, act, PriorityBulk)
+}
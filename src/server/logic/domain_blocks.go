@@ -0,0 +1,257 @@
+package logic
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"rss_parrot/dal"
+	"rss_parrot/shared"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_domain_blocks.go -package mocks rss_parrot/logic IDomainBlocks
+
+type IDomainBlocks interface {
+	// True if host is a suspended domain, or a subdomain of one.
+	IsBlocked(host string) (bool, error)
+	// Returns the block level of host, taking parent domains into account; 0 if the host is not blocked.
+	GetLevel(host string) (int, error)
+	GetBlocks() ([]*dal.DomainBlock, error)
+	SetBlock(domain string, level int, reason string) error
+	RemoveBlock(domain string) error
+	// Imports a CSV block list, such as Mastodon's domain block export. Returns the number of domains imported.
+	ImportCsv(r io.Reader) (int, error)
+}
+
+var domainLevelNames = map[int]string{
+	dal.DomainSilence:       "silence",
+	dal.DomainRejectFollows: "reject_follows",
+	dal.DomainSuspend:       "suspend",
+}
+
+func DomainLevelName(level int) string {
+	return domainLevelNames[level]
+}
+
+// Parses a block level's name; returns 0 if the name is not known.
+func ParseDomainLevel(name string) int {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for level, levelName := range domainLevelNames {
+		if name == levelName {
+			return level
+		}
+	}
+	return 0
+}
+
+type domainBlocks struct {
+	cfg      *shared.Config
+	logger   shared.ILogger
+	repo     dal.IRepo
+	mu       sync.Mutex
+	modTime  time.Time
+	blocked  map[string]struct{} // Domains from the block list file, which are all suspended
+	dbLevels map[string]int      // Blocks stored in the DB; nil if they need to be reloaded
+}
+
+func NewDomainBlocks(cfg *shared.Config, logger shared.ILogger, repo dal.IRepo) IDomainBlocks {
+	return &domainBlocks{cfg: cfg, logger: logger, repo: repo, blocked: map[string]struct{}{}}
+}
+
+// Re-reads the block list file if it has changed since we last loaded it
+func (db *domainBlocks) reloadIfChanged() error {
+
+	if db.cfg.BlockedDomainsFile == "" {
+		return nil
+	}
+	stat, err := os.Stat(db.cfg.BlockedDomainsFile)
+	if err != nil {
+		return err
+	}
+	if stat.ModTime().Equal(db.modTime) {
+		return nil
+	}
+
+	readFile, err := os.Open(db.cfg.BlockedDomainsFile)
+	if err != nil {
+		return err
+	}
+	defer readFile.Close()
+	fileScanner := bufio.NewScanner(readFile)
+	fileScanner.Split(bufio.ScanLines)
+
+	blocked := make(map[string]struct{})
+	for fileScanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(fileScanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocked[line] = struct{}{}
+	}
+	if err = fileScanner.Err(); err != nil {
+		return err
+	}
+	db.blocked = blocked
+	db.modTime = stat.ModTime()
+	return nil
+}
+
+func (db *domainBlocks) loadDbLevels() error {
+
+	if db.dbLevels != nil {
+		return nil
+	}
+	blocks, err := db.repo.GetDomainBlocks()
+	if err != nil {
+		return err
+	}
+	db.dbLevels = make(map[string]int, len(blocks))
+	for _, b := range blocks {
+		db.dbLevels[b.Domain] = b.Level
+	}
+	return nil
+}
+
+func (db *domainBlocks) GetLevel(host string) (int, error) {
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.reloadIfChanged(); err != nil {
+		return 0, err
+	}
+	if err := db.loadDbLevels(); err != nil {
+		return 0, err
+	}
+
+	res := 0
+	host = strings.ToLower(host)
+	for {
+		if _, found := db.blocked[host]; found {
+			return dal.DomainSuspend, nil
+		}
+		res = max(res, db.dbLevels[host])
+		dotIx := strings.IndexByte(host, '.')
+		if dotIx == -1 {
+			return res, nil
+		}
+		host = host[dotIx+1:]
+	}
+}
+
+func (db *domainBlocks) IsBlocked(host string) (bool, error) {
+	level, err := db.GetLevel(host)
+	return level >= dal.DomainSuspend, err
+}
+
+func (db *domainBlocks) GetBlocks() ([]*dal.DomainBlock, error) {
+	return db.repo.GetDomainBlocks()
+}
+
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" || strings.ContainsAny(domain, "/:@* \t") {
+		return "", fmt.Errorf("invalid domain: '%s'", domain)
+	}
+	return domain, nil
+}
+
+func (db *domainBlocks) SetBlock(domain string, level int, reason string) error {
+
+	var err error
+	if domain, err = normalizeDomain(domain); err != nil {
+		return err
+	}
+	if _, ok := domainLevelNames[level]; !ok {
+		return fmt.Errorf("invalid block level: %d", level)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.dbLevels = nil
+	db.logger.Infof("Blocking domain %s: %s", domain, DomainLevelName(level))
+	return db.repo.SaveDomainBlock(&dal.DomainBlock{
+		Domain:    domain,
+		Level:     level,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func (db *domainBlocks) RemoveBlock(domain string) error {
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.dbLevels = nil
+	db.logger.Infof("Unblocking domain %s", domain)
+	return db.repo.DeleteDomainBlock(strings.ToLower(domain))
+}
+
+// Columns are found by the header's names, with or without a leading '#'. Without a header, the first column
+// is the domain and the optional second one the severity. Mastodon's "noop" and obfuscated domains are skipped.
+func (db *domainBlocks) ImportCsv(r io.Reader) (int, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, errors.New("CSV file is empty")
+	}
+
+	domainIx, severityIx, reasonIx := 0, 1, -1
+	header := records[0]
+	if strings.HasPrefix(header[0], "#") || strings.EqualFold(header[0], "domain") {
+		domainIx, severityIx = -1, -1
+		for i, name := range header {
+			switch strings.ToLower(strings.TrimPrefix(name, "#")) {
+			case "domain":
+				domainIx = i
+			case "severity":
+				severityIx = i
+			case "public_comment", "comment", "reason":
+				reasonIx = i
+			}
+		}
+		if domainIx == -1 {
+			return 0, errors.New("CSV header has no domain column")
+		}
+		records = records[1:]
+	}
+
+	count := 0
+	for _, rec := range records {
+		field := func(ix int) string {
+			if ix < 0 || ix >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[ix])
+		}
+		domain, severity := field(domainIx), field(severityIx)
+		if domain == "" || strings.Contains(domain, "*") || strings.EqualFold(severity, "noop") {
+			continue
+		}
+		level := dal.DomainSuspend
+		if severity != "" {
+			if level = ParseDomainLevel(severity); level == 0 {
+				return count, fmt.Errorf("unknown severity for %s: '%s'", domain, severity)
+			}
+		}
+		if err = db.SetBlock(domain, level, field(reasonIx)); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
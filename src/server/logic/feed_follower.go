@@ -1,17 +1,21 @@
 package logic
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/mmcdole/gofeed"
 	"github.com/spaolacci/murmur3"
 	"html"
+	"io"
 	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
 	"rss_parrot/dal"
+	"rss_parrot/dto"
 	"rss_parrot/shared"
 	"rss_parrot/texts"
 	"sort"
@@ -37,13 +41,20 @@ const (
 )
 
 const (
-	feedOrSiteTimeoutSec  = 10
-	allowedFuturePostDays = 2
+	feedOrSiteTimeoutSec         = 10
+	maxFeedBytes                 = 10 * 1024 * 1024 // Most we read for diagnostics; scheduled checks read the whole feed
+	allowedFuturePostDays        = 2
+	defaultProfileUpdateMinHours = 24
 )
 
 type IFeedFollower interface {
 	GetAccountForFeed(urlStr string) (acct *dal.Account, status FeedStatus, err error)
+	// Fetches and parses the feed; FsError with a nil error means the feed can be parroted
+	ValidateFeedUrl(feedUrl string) (status FeedStatus, err error)
+	// Checks the account's feed right away and reports what happened
+	RefreshFeed(acct *dal.Account) *dto.FeedDiagnostics
 	PurgeOldPosts(acct *dal.Account, minCount, minAgeDays int) error
+	PurgeAccount(acct *dal.Account) error
 }
 
 type SiteInfo struct {
@@ -62,9 +73,12 @@ type feedFollower struct {
 	repo                 dal.IRepo
 	blockedFeeds         IBlockedFeeds
 	messenger            IMessenger
+	udir                 IUserDirectory
 	txt                  texts.ITexts
 	keyStore             IKeyStore
 	metrics              IMetrics
+	relays               IRelays
+	bundles              IBundles
 	lastCheckedPostCount time.Time
 	muPurgingOldPosts    sync.Mutex
 	isPurgingOldPosts    bool
@@ -79,9 +93,12 @@ func NewFeedFollower(
 	repo dal.IRepo,
 	blockedFeeds IBlockedFeeds,
 	messenger IMessenger,
+	udir IUserDirectory,
 	txt texts.ITexts,
 	keyStore IKeyStore,
 	metrics IMetrics,
+	relays IRelays,
+	bundles IBundles,
 ) IFeedFollower {
 
 	ff := feedFollower{
@@ -91,9 +108,12 @@ func NewFeedFollower(
 		repo:                repo,
 		blockedFeeds:        blockedFeeds,
 		messenger:           messenger,
+		udir:                udir,
 		txt:                 txt,
 		keyStore:            keyStore,
 		metrics:             metrics,
+		relays:              relays,
+		bundles:             bundles,
 		isPurgingUnfollowed: false,
 	}
 
@@ -234,7 +254,7 @@ func (ff *feedFollower) getSiteInfo(urlStr string) (*SiteInfo, *gofeed.Feed, err
 	if noQueryUrlStr, err = ff.trimQueryParamsStr(urlStr); err != nil {
 		return nil, nil, err
 	}
-	feed, err = ff.fetchParseFeed(noQueryUrlStr)
+	feed, err = ff.fetchParseFeed(noQueryUrlStr, nil)
 	if err == nil {
 		res.FeedUrl = noQueryUrlStr
 		res.LastUpdated = getLastUpdated(feed)
@@ -284,7 +304,7 @@ func (ff *feedFollower) getSiteInfo(urlStr string) (*SiteInfo, *gofeed.Feed, err
 	ff.getMetas(doc, &res)
 
 	// Get the feed to make sure it's there, and know when it's last changed
-	feed, err = ff.fetchParseFeed(res.FeedUrl)
+	feed, err = ff.fetchParseFeed(res.FeedUrl, nil)
 	if err != nil {
 		ff.logger.Warnf("Failed to retrieve and parse feed: %s, %v", res.FeedUrl, err)
 		return nil, nil, err
@@ -294,6 +314,19 @@ func (ff *feedFollower) getSiteInfo(urlStr string) (*SiteInfo, *gofeed.Feed, err
 	return &res, feed, nil
 }
 
+// Hash of the feed-level metadata that ends up in the account's public profile
+func getFeedMetaHash(feed *gofeed.Feed) int64 {
+	str := feed.Title + "\t" + feed.Description + "\t" + getFeedImageUrl(feed)
+	return int64(murmur3.Sum64([]byte(str)))
+}
+
+func getFeedImageUrl(feed *gofeed.Feed) string {
+	if feed.Image == nil {
+		return ""
+	}
+	return feed.Image.URL
+}
+
 func getItemHash(itm *gofeed.Item) uint {
 	str := itm.GUID + "\t" + itm.Link
 	hasher := murmur3.New32()
@@ -302,21 +335,25 @@ func getItemHash(itm *gofeed.Item) uint {
 }
 
 func (ff *feedFollower) updateAccountPosts(
-	accountId int,
-	accountHandle string,
+	acct *dal.Account,
 	feed *gofeed.Feed,
 	tootNew bool,
+	diag *dto.FeedDiagnostics,
 ) (err error) {
 	err = nil
+	accountId, accountHandle := acct.Id, acct.Handle
 	var lastKnownFeedUpdated time.Time
 
 	if lastKnownFeedUpdated, err = ff.repo.GetFeedLastUpdated(accountId); err != nil {
 		return
 	}
+	if diag != nil {
+		diag.LastSeenBefore = lastKnownFeedUpdated
+	}
 
 	// Deal with feed items newer than our last seen
 	// This goes from older to newer
-	keepers, newLastUpdated := getSortedPosts(feed.Items, lastKnownFeedUpdated)
+	keepers, newLastUpdated := getSortedPosts(feed.Items, lastKnownFeedUpdated, diag)
 	for _, k := range keepers {
 		fixPodcastLink(k.itm)
 		if err = ff.storePostIfNew(accountId, accountHandle, k.postTime, k.itm, tootNew); err != nil {
@@ -324,10 +361,13 @@ func (ff *feedFollower) updateAccountPosts(
 		}
 	}
 
-	nextCheckDue := ff.getNextCheckTime(newLastUpdated)
+	nextCheckDue := ff.getNextCheckTime(newLastUpdated, acct.CheckIntervalMin)
 	if err = ff.repo.UpdateAccountFeedTimes(accountId, newLastUpdated, nextCheckDue); err != nil {
 		return
 	}
+	if diag != nil {
+		diag.NextCheckDue = nextCheckDue
+	}
 	return
 }
 
@@ -354,13 +394,33 @@ type sortedPost struct {
 	postTime time.Time
 }
 
-func getSortedPosts(items []*gofeed.Item, lastKnownFeedUpdated time.Time) ([]sortedPost, time.Time) {
+// Reasons why a feed item is or isn't a new post
+const (
+	ItemNew      = "new"
+	ItemNotNewer = "not_newer" // Not newer than the newest post we have seen
+	ItemFuture   = "future"    // Too far in the future
+	ItemNoDate   = "no_date"   // Neither published nor updated date could be parsed
+)
+
+// If diag is not nil, it receives a verdict about each item
+func getSortedPosts(items []*gofeed.Item, lastKnownFeedUpdated time.Time,
+	diag *dto.FeedDiagnostics) ([]sortedPost, time.Time) {
+
 	var keepers []sortedPost
 	newLastUpdated := lastKnownFeedUpdated
 	futureLimit := time.Now().Add(allowedFuturePostDays * time.Hour * 24)
 
 	for _, itm := range items {
-		keeper, postTime := checkItemTime(itm, lastKnownFeedUpdated, futureLimit)
+		keeper, postTime, reason := checkItemTime(itm, lastKnownFeedUpdated, futureLimit)
+		if diag != nil {
+			diag.Items = append(diag.Items, dto.FeedItemVerdict{
+				Title:     itm.Title,
+				Link:      itm.Link,
+				Published: itm.PublishedParsed,
+				Updated:   itm.UpdatedParsed,
+				Verdict:   reason,
+			})
+		}
 		if !keeper {
 			continue
 		}
@@ -377,9 +437,14 @@ func getSortedPosts(items []*gofeed.Item, lastKnownFeedUpdated time.Time) ([]sor
 	return keepers, newLastUpdated
 }
 
-func checkItemTime(itm *gofeed.Item, latestKown, futureLimit time.Time) (keeper bool, postTime time.Time) {
+func checkItemTime(itm *gofeed.Item, latestKown, futureLimit time.Time) (keeper bool, postTime time.Time, reason string) {
 	keeper = false
 	postTime = time.Time{}
+	reason = ItemNotNewer
+	if itm.PublishedParsed == nil && itm.UpdatedParsed == nil {
+		reason = ItemNoDate
+		return
+	}
 	if itm.PublishedParsed != nil && itm.PublishedParsed.After(latestKown) {
 		keeper = true
 		postTime = *itm.PublishedParsed
@@ -390,15 +455,24 @@ func checkItemTime(itm *gofeed.Item, latestKown, futureLimit time.Time) (keeper
 			postTime = *itm.UpdatedParsed
 		}
 	}
+	if keeper {
+		reason = ItemNew
+	}
 	// Accept posts a little bit into the future, but not far ahead
 	// Future posts would prevent us from routinely purging old posts
 	if futureLimit.Sub(postTime) < 0 {
 		keeper = false
+		reason = ItemFuture
 	}
 	return
 }
 
-func (ff *feedFollower) getNextCheckTime(lastChanged time.Time) time.Time {
+func (ff *feedFollower) getNextCheckTime(lastChanged time.Time, intervalMin int) time.Time {
+
+	// An admin has set a fixed interval
+	if intervalMin > 0 {
+		return time.Now().Add(time.Duration(intervalMin) * time.Minute)
+	}
 
 	// Active in the last day: 1 hour
 	// Active in the last week: 3 hours
@@ -489,6 +563,8 @@ func (ff *feedFollower) createToot(accountId int, accountHandle string, itm *gof
 		if err = ff.messenger.EnqueueBroadcast(accountHandle, statusId, tootedAt, content); err != nil {
 			return err
 		}
+		ff.relays.AnnounceToot(accountHandle, statusId)
+		ff.bundles.AnnounceToot(accountId, statusId)
 	}
 	return nil
 }
@@ -549,24 +625,37 @@ func (ff *feedFollower) GetAccountForFeed(urlStr string) (acct *dal.Account, sta
 
 	idb := shared.IdBuilder{ff.cfg.Host}
 
+	// If this handle existed before, it gets its old key back
+	// Remote servers may still have the old key cached; a new one would break signature verification
 	var pubKey string
 	var privKey string
-	pubKey, privKey, err = ff.keyStore.MakeKeyPair()
-	if err != nil {
-		ff.logger.Errorf("Failed to create key pair: %v", err)
+	var tomb *dal.AccountTombstone
+	if tomb, err = ff.repo.GetAccountTombstone(si.ParrotHandle); err != nil {
+		ff.logger.Errorf("Failed to check tombstone for %s: %v", si.ParrotHandle, err)
 		return
 	}
+	if tomb != nil {
+		pubKey, privKey = tomb.PubKey, tomb.PrivKey
+	} else {
+		pubKey, privKey, err = ff.keyStore.MakeKeyPair()
+		if err != nil {
+			ff.logger.Errorf("Failed to create key pair: %v", err)
+			return
+		}
+	}
 
 	var isNew bool
 	isNew, err = ff.repo.AddAccountIfNotExist(&dal.Account{
-		CreatedAt:   time.Now(),
-		Handle:      si.ParrotHandle,
-		UserUrl:     idb.UserUrl(si.ParrotHandle),
-		FeedName:    si.Title,
-		FeedSummary: si.Description,
-		SiteUrl:     si.Url,
-		FeedUrl:     si.FeedUrl,
-		PubKey:      pubKey,
+		CreatedAt:       time.Now(),
+		Handle:          si.ParrotHandle,
+		UserUrl:         idb.UserUrl(si.ParrotHandle),
+		FeedName:        si.Title,
+		FeedSummary:     si.Description,
+		SiteUrl:         si.Url,
+		FeedUrl:         si.FeedUrl,
+		PubKey:          pubKey,
+		ProfileImageUrl: getFeedImageUrl(feed),
+		FeedMetaHash:    getFeedMetaHash(feed),
 	}, privKey)
 
 	if err != nil {
@@ -576,14 +665,36 @@ func (ff *feedFollower) GetAccountForFeed(urlStr string) (acct *dal.Account, sta
 
 	ff.logger.Infof("Account is %s; newly created: %v", si.ParrotHandle, isNew)
 
+	if isNew {
+		if err = ff.keyStore.CreateEdKeys(si.ParrotHandle); err != nil {
+			ff.logger.Errorf("Failed to create Ed25519 key for %s: %v", si.ParrotHandle, err)
+			return
+		}
+	}
+
+	if isNew && tomb != nil {
+		ff.logger.Infof("Account %s re-created from tombstone", si.ParrotHandle)
+		if err = ff.repo.DeleteAccountTombstone(si.ParrotHandle); err != nil {
+			ff.logger.Errorf("Failed to remove tombstone of %s: %v", si.ParrotHandle, err)
+			return
+		}
+	}
+
 	acct, err = ff.repo.GetAccount(si.ParrotHandle)
 	if err != nil {
 		ff.logger.Errorf("Failed to load account for %s; was newly created: %v", si.ParrotHandle, isNew)
 		acct = nil
 		return
 	}
+	// The handle may belong to a bundle; we must not toot the feed's posts as that
+	if acct.IsBundle() {
+		err = fmt.Errorf("handle is taken by a bundle: %s", si.ParrotHandle)
+		ff.logger.Warnf("Cannot parrot %s: %v", si.FeedUrl, err)
+		acct = nil
+		return
+	}
 
-	err = ff.updateAccountPosts(acct.Id, si.ParrotHandle, feed, !isNew)
+	err = ff.updateAccountPosts(acct, feed, !isNew, nil)
 	if err != nil {
 		ff.logger.Errorf("Failed to update account's posts: %s: %v", acct.Handle, err)
 		acct = nil
@@ -593,6 +704,7 @@ func (ff *feedFollower) GetAccountForFeed(urlStr string) (acct *dal.Account, sta
 	if isNew {
 		status = FsNew
 		feedLabel = "new"
+		ff.relays.AnnounceAccount(si.ParrotHandle)
 	} else {
 		status = FsAlreadyFollowed
 		feedLabel = "existing"
@@ -600,7 +712,8 @@ func (ff *feedFollower) GetAccountForFeed(urlStr string) (acct *dal.Account, sta
 	return
 }
 
-func (ff *feedFollower) fetchParseFeed(feedUrl string) (feed *gofeed.Feed, err error) {
+// If diag is not nil, it receives details about the HTTP request and the parsed feed
+func (ff *feedFollower) fetchParseFeed(feedUrl string, diag *dto.FeedDiagnostics) (feed *gofeed.Feed, err error) {
 
 	var req *http.Request
 	if req, err = http.NewRequest("GET", feedUrl, nil); err != nil {
@@ -610,34 +723,91 @@ func (ff *feedFollower) fetchParseFeed(feedUrl string) (feed *gofeed.Feed, err e
 
 	client := http.Client{}
 	client.Timeout = time.Second * feedOrSiteTimeoutSec
+	if diag != nil {
+		diag.FeedUrl = feedUrl
+		diag.Redirects = []string{}
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			diag.Redirects = append(diag.Redirects, req.URL.String())
+			// Same limit as the default policy
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	startTime := time.Now()
 	var resp *http.Response
 	if resp, err = client.Do(req); err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if diag != nil {
+		diag.HttpStatus = resp.StatusCode
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("request failed with status %v", resp.StatusCode)
 	}
+	// Only diagnostics are capped; one byte over the limit tells us the feed was cut off
+	var body []byte
+	if diag == nil {
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	} else {
+		if body, err = io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes+1)); err != nil {
+			return nil, err
+		}
+		truncated := len(body) > maxFeedBytes
+		if truncated {
+			body = body[:maxFeedBytes]
+		}
+		diag.ResponseTimeMs = time.Since(startTime).Milliseconds()
+		diag.ResponseBytes = len(body)
+		diag.Truncated = truncated
+		if truncated {
+			return nil, fmt.Errorf("feed is larger than %d bytes", maxFeedBytes)
+		}
+	}
 
 	fp := gofeed.NewParser()
-	return fp.Parse(resp.Body)
+	if feed, err = fp.Parse(bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+	if diag != nil {
+		diag.ItemCount = len(feed.Items)
+	}
+	return feed, nil
+}
+
+func (ff *feedFollower) ValidateFeedUrl(feedUrl string) (FeedStatus, error) {
+
+	feed, err := ff.fetchParseFeed(feedUrl, nil)
+	if err != nil {
+		return FsError, err
+	}
+	return ff.filterFeed(feedUrl, feed)
 }
 
-func (ff *feedFollower) updateFeed(acct *dal.Account) error {
+func (ff *feedFollower) updateFeed(acct *dal.Account, diag *dto.FeedDiagnostics) error {
 
 	var err error
 	ff.logger.Infof("Updating account %s: %s", acct.Handle, acct.FeedUrl)
 	ff.metrics.FeedUpdated()
 
 	var feed *gofeed.Feed
-	if feed, err = ff.fetchParseFeed(acct.FeedUrl); err != nil {
+	if feed, err = ff.fetchParseFeed(acct.FeedUrl, diag); err != nil {
 		return err
 	}
 
-	if err = ff.updateAccountPosts(acct.Id, acct.Handle, feed, true); err != nil {
+	if err = ff.updateAccountPosts(acct, feed, true, diag); err != nil {
 		return err
 	}
 
+	if err = ff.updateProfileIfChanged(acct, feed); err != nil {
+		// Posts have been updated; don't fail the whole update because of the profile
+		ff.logger.Errorf("Error updating profile for account %s: %v", acct.Handle, err)
+	}
+
 	go func() {
 		if err = ff.PurgeOldPosts(acct, ff.cfg.PostsMinCountKept, ff.cfg.PostsMinDaysKept); err != nil {
 			// If purging errors out: swallow it (updateFeed still succeeds); just log
@@ -648,6 +818,53 @@ func (ff *feedFollower) updateFeed(acct *dal.Account) error {
 	return nil
 }
 
+// Detects changes in the feed's title, description and image. These make up the account's public profile, so
+// when they change, we store the new values and send an actor Update to followers so remote caches refresh.
+func (ff *feedFollower) updateProfileIfChanged(acct *dal.Account, feed *gofeed.Feed) error {
+
+	metaHash := getFeedMetaHash(feed)
+	if metaHash == acct.FeedMetaHash {
+		return nil
+	}
+
+	// Account was created before we tracked feed metadata: just remember what we see now
+	if acct.FeedMetaHash == 0 {
+		return ff.repo.UpdateAccountFeedMeta(acct.Id, metaHash)
+	}
+
+	// Don't let a flapping feed title spam followers with updates
+	// We don't store the new hash, so the change is picked up again once the interval has passed
+	minHours := ff.cfg.ProfileUpdateMinHr
+	if minHours <= 0 {
+		minHours = defaultProfileUpdateMinHours
+	}
+	if time.Since(acct.ProfileUpdatedAt) < time.Duration(minHours)*time.Hour {
+		ff.logger.Infof("Feed metadata changed for %s, but profile was updated recently; deferring", acct.Handle)
+		return nil
+	}
+
+	// Empty values in the feed don't wipe what we already have, and neither do values for fields an admin has set
+	name, summary, imageUrl := acct.FeedName, acct.FeedSummary, acct.ProfileImageUrl
+	if feed.Title != "" && !acct.IsLocked(dal.LockFeedName) {
+		name = feed.Title
+	}
+	if feed.Description != "" && !acct.IsLocked(dal.LockFeedSummary) {
+		summary = feed.Description
+	}
+	if feedImageUrl := getFeedImageUrl(feed); feedImageUrl != "" && !acct.IsLocked(dal.LockProfileImage) {
+		imageUrl = feedImageUrl
+	}
+	if name == acct.FeedName && summary == acct.FeedSummary && imageUrl == acct.ProfileImageUrl {
+		return ff.repo.UpdateAccountFeedMeta(acct.Id, metaHash)
+	}
+
+	ff.logger.Infof("Profile of %s changed; storing and broadcasting Update", acct.Handle)
+	if err := ff.repo.UpdateAccountProfile(acct.Id, name, summary, imageUrl, metaHash, time.Now()); err != nil {
+		return err
+	}
+	return ff.udir.BroadcastUpdate(acct.Handle)
+}
+
 func (ff *feedFollower) PurgeOldPosts(acct *dal.Account, minCount, minAgeDays int) error {
 
 	if minCount <= 0 || minAgeDays <= 0 {
@@ -740,6 +957,7 @@ func (ff *feedFollower) purgeUnfollowedAccount(acct *dal.Account) {
 	}
 	defer signalDone()
 
+	// A pending follow request keeps the feed; banned followers don't
 	followerCount, err := ff.repo.GetFollowerCount(acct.Handle, false)
 	if err != nil {
 		ff.logger.Errorf("Error getting follower count of feed: %s: %v", acct.Handle, err)
@@ -748,9 +966,18 @@ func (ff *feedFollower) purgeUnfollowedAccount(acct *dal.Account) {
 	if followerCount != 0 {
 		return
 	}
+	// Feeds in a bundle are followed through the bundle
+	bundleAccts, err := ff.repo.GetBundlesOfMember(acct.Id)
+	if err != nil {
+		ff.logger.Errorf("Error getting bundles of feed: %s: %v", acct.Handle, err)
+		return
+	}
+	if len(bundleAccts) != 0 {
+		return
+	}
 	ff.logger.Infof("Deleting account with 0 followers: %s", acct.Handle)
-	if err = ff.repo.BruteDeleteAccount(acct.Id); err != nil {
-		ff.logger.Errorf("Failed to brute-delete account: %s: %v", acct.Handle, err)
+	if err = ff.PurgeAccount(acct); err != nil {
+		ff.logger.Errorf("Failed to purge account: %s: %v", acct.Handle, err)
 		return
 	}
 	if ff.cfg.PurgeWaitSec > 0 {
@@ -758,6 +985,22 @@ func (ff *feedFollower) purgeUnfollowedAccount(acct *dal.Account) {
 	}
 }
 
+// Deletes an account for good. Followers' servers get a Delete of the actor first; we keep a tombstone
+// so the actor URL answers 410 Gone, and so the handle gets its old key back if it's ever re-created.
+func (ff *feedFollower) PurgeAccount(acct *dal.Account) error {
+
+	if acct.Handle == ff.cfg.Birb.User {
+		return fmt.Errorf("built-in account cannot be deleted: %s", acct.Handle)
+	}
+	if err := ff.udir.BroadcastDelete(acct.Handle); err != nil {
+		return err
+	}
+	if err := ff.repo.TombstoneAccount(acct.Id, time.Now()); err != nil {
+		return err
+	}
+	return ff.repo.BruteDeleteAccount(acct.Id)
+}
+
 func (ff *feedFollower) updateDBSizeMetric() {
 
 	// In case feed follower is running on a mock config in a unit test: don't bother
@@ -829,17 +1072,42 @@ func (ff *feedFollower) feedCheckLoopInner() {
 		time.Sleep(feedCheckLoopIdleWakeSec * time.Second)
 		return
 	}
+	ff.checkFeed(acct, nil)
+	// Delete account if no followers; purge old posts
+	go ff.purgeUnfollowedAccount(acct)
+}
+
+// Updates the account from its feed and records the outcome. Returns the error that made the update fail, if any.
+func (ff *feedFollower) checkFeed(acct *dal.Account, diag *dto.FeedDiagnostics) error {
+
 	lastUpdated := acct.FeedLastUpdated
-	err = ff.updateFeed(acct)
-	if err != nil {
-		ff.logger.Errorf("Error updating feed: %s: %v", acct.Handle, err)
+	updateErr := ff.updateFeed(acct, diag)
+	checkErr := ""
+	if updateErr != nil {
+		ff.logger.Errorf("Error updating feed: %s: %v", acct.Handle, updateErr)
+		checkErr = updateErr.Error()
 		// Reschedule for updating as if there was no new post
-		nextCheckDue := ff.getNextCheckTime(lastUpdated)
-		if err = ff.repo.UpdateAccountFeedTimes(acct.Id, lastUpdated, nextCheckDue); err != nil {
+		nextCheckDue := ff.getNextCheckTime(lastUpdated, acct.CheckIntervalMin)
+		if err := ff.repo.UpdateAccountFeedTimes(acct.Id, lastUpdated, nextCheckDue); err != nil {
 			ff.logger.Errorf("Failed to reschedule for checking after error: %s: %v", acct.Handle, err)
 		}
+		if diag != nil {
+			diag.NextCheckDue = nextCheckDue
+		}
 	}
 	// If no error, updateFeed has set next due date for checking
-	// Delete account if no followers; purge old posts
-	go ff.purgeUnfollowedAccount(acct)
+	if err := ff.repo.RecordFeedCheck(acct.Id, time.Now().UTC(), checkErr); err != nil {
+		ff.logger.Errorf("Failed to record outcome of feed check: %s: %v", acct.Handle, err)
+	}
+	return updateErr
+}
+
+func (ff *feedFollower) RefreshFeed(acct *dal.Account) *dto.FeedDiagnostics {
+
+	ff.logger.Infof("Refreshing feed on request: %s", acct.Handle)
+	diag := &dto.FeedDiagnostics{Items: []dto.FeedItemVerdict{}}
+	if err := ff.checkFeed(acct, diag); err != nil {
+		diag.Error = err.Error()
+	}
+	return diag
 }
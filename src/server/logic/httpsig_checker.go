@@ -6,64 +6,355 @@ import (
 	"fmt"
 	"github.com/go-fed/httpsig"
 	"net/http"
-	"regexp"
 	"rss_parrot/dto"
 	"rss_parrot/shared"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type IHttpSigChecker interface {
-	Check(actor string, w http.ResponseWriter, r *http.Request) (*dto.UserInfo, string, error)
+	// Verifies the signature of an inbox POST, which must be signed by the activity's actor
+	Check(actor string, body []byte, r *http.Request) (*dto.UserInfo, string, error)
+	// Verifies the signature of a GET, where the signing actor is derived from the key ID
+	CheckFetch(r *http.Request) (*dto.UserInfo, string, error)
+	// Returns the host of the key ID in the request's signature, without verifying anything; "" if unsigned
+	GetSigningHost(r *http.Request) string
+	// Verifies the activity's eddsa-jcs-2022 integrity proof, which must be made with a key of the actor
+	CheckProof(actor string, body []byte) (*dto.UserInfo, string, error)
 }
 
+const defaultSigMaxSkewSec = 60 * 60
+
 type httpSigChecker struct {
+	cfg           *shared.Config
 	logger        shared.ILogger
 	userRetriever IUserRetriever
-	reKeyId       *regexp.Regexp
+	sigSchemes    ISigSchemes
+	maxSkew       time.Duration
 }
 
-func NewHttpSigChecker(logger shared.ILogger, userRetriever IUserRetriever) IHttpSigChecker {
-	reKeyId := regexp.MustCompile("keyId=['\"]([^'\"]+)['\"]")
-	return &httpSigChecker{logger, userRetriever, reKeyId}
+// What we learned from the signature headers before verifying the signature itself
+type sigHeaderInfo struct {
+	keyId   string
+	rfc9421 *shared.Rfc9421Sig // nil if request uses draft-cavage signature
 }
 
-func (chk *httpSigChecker) Check(actor string, w http.ResponseWriter, r *http.Request) (*dto.UserInfo, string, error) {
+func NewHttpSigChecker(
+	cfg *shared.Config,
+	logger shared.ILogger,
+	userRetriever IUserRetriever,
+	sigSchemes ISigSchemes,
+) IHttpSigChecker {
+	maxSkew := time.Duration(orDefault(cfg.SigMaxSkewSec, defaultSigMaxSkewSec)) * time.Second
+	return &httpSigChecker{cfg, logger, userRetriever, sigSchemes, maxSkew}
+}
 
-	var err error
+func (chk *httpSigChecker) GetSigningHost(r *http.Request) string {
+	shi, problem := chk.parseSigHeaders(nil, r)
+	if problem != "" {
+		return ""
+	}
+	host, err := shared.GetHostName(shi.keyId)
+	if err != nil {
+		return ""
+	}
+	return host
+}
 
-	var sigHeader = r.Header.Get("Signature")
-	groups := chk.reKeyId.FindStringSubmatch(sigHeader)
-	if groups == nil {
-		return nil, "Missing or invalid 'Signature' header", nil
+func (chk *httpSigChecker) Check(actor string, body []byte, r *http.Request) (*dto.UserInfo, string, error) {
+
+	shi, problem := chk.parseSigHeaders(body, r)
+	if problem != "" {
+		return nil, problem, nil
 	}
-	keyId := groups[1]
 
-	if !strings.HasPrefix(keyId, actor) {
-		return nil, fmt.Sprintf("Actor is not prefix of keyId; actor: %s, keyId: %s", actor, keyId), nil
+	if !strings.HasPrefix(shi.keyId, actor) {
+		return nil, fmt.Sprintf("Actor is not prefix of keyId; actor: %s, keyId: %s", actor, shi.keyId), nil
+	}
+
+	return chk.retrieveAndVerify(actor, r, shi)
+}
+
+func (chk *httpSigChecker) CheckFetch(r *http.Request) (*dto.UserInfo, string, error) {
+
+	shi, problem := chk.parseSigHeaders(nil, r)
+	if problem != "" {
+		return nil, problem, nil
+	}
+
+	actor, problem := chk.getKeyOwner(shi.keyId)
+	if problem != "" {
+		return nil, problem, nil
+	}
+
+	return chk.retrieveAndVerify(actor, r, shi)
+}
+
+// Finds the actor behind a key ID. Mastodon's key IDs are the actor's URL with a #main-key fragment; others,
+// like GoToSocial, use a path of their own, where we fetch the key and take its owner. Either way, the owner's
+// actor must then name the key as its own before the signature counts.
+func (chk *httpSigChecker) getKeyOwner(keyId string) (string, string) {
+
+	if actor, _, found := strings.Cut(keyId, "#"); found {
+		return actor, ""
+	}
+
+	keyInfo, _, err := chk.userRetriever.RetrieveCached(keyId)
+	if err != nil {
+		return "", fmt.Sprintf("Failed to retrieve key: %s: %v", keyId, err)
+	}
+	// Key document may be the actor itself
+	owner := keyInfo.PublicKey.Owner
+	if owner == "" {
+		owner = keyInfo.Id
+	}
+	if owner == "" {
+		return "", fmt.Sprintf("Key has no owner: %s", keyId)
 	}
+	return owner, ""
+}
+
+// Verifies the signature with the actor's key, taken from the cache if we have it. If that fails,
+// the actor may have rotated their key since, so we fetch them again and give it one more go.
+func (chk *httpSigChecker) retrieveAndVerify(
+	actor string,
+	r *http.Request,
+	shi *sigHeaderInfo,
+) (*dto.UserInfo, string, error) {
 
-	var userInfo *dto.UserInfo
+	userInfo, fromCache, err := chk.userRetriever.RetrieveCached(actor)
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to retrieve user info for actor: %s: %v", actor, err), nil
+	}
+
+	res, problem, err := chk.verifyWithActor(r, shi, userInfo)
+	if err != nil || problem == "" || !fromCache {
+		return res, problem, err
+	}
+
+	chk.logger.Infof("Signature check failed with cached key of %s; fetching actor again", actor)
 	if userInfo, err = chk.userRetriever.Retrieve(actor); err != nil {
 		return nil, fmt.Sprintf("Failed to retrieve user info for actor: %s: %v", actor, err), nil
 	}
+	return chk.verifyWithActor(r, shi, userInfo)
+}
 
-	verifier, err := httpsig.NewVerifier(r)
+func (chk *httpSigChecker) verifyWithActor(
+	r *http.Request,
+	shi *sigHeaderInfo,
+	userInfo *dto.UserInfo,
+) (*dto.UserInfo, string, error) {
+	if userInfo.PublicKey.Id != shi.keyId && !strings.HasPrefix(shi.keyId, userInfo.Id) {
+		return nil, fmt.Sprintf("Key does not belong to actor; actor: %s, keyId: %s", userInfo.Id, shi.keyId), nil
+	}
+	return chk.verify(r, shi, userInfo)
+}
+
+// Checks everything about the signature that doesn't need the signer's key: that the right things are covered,
+// the body digest, and the signature's age. If body is not nil, it must be covered by a digest.
+func (chk *httpSigChecker) parseSigHeaders(body []byte, r *http.Request) (*sigHeaderInfo, string) {
+	if r.Header.Get("Signature-Input") != "" {
+		return chk.parseRfc9421Headers(body, r)
+	}
+	return chk.parseCavageHeaders(body, r)
+}
+
+func (chk *httpSigChecker) parseCavageHeaders(body []byte, r *http.Request) (*sigHeaderInfo, string) {
+
+	params := shared.ParseCavageParams(r.Header.Get("Signature"))
+	keyId := params["keyId"]
+	if keyId == "" || params["signature"] == "" {
+		return nil, "Missing or invalid 'Signature' header"
+	}
+
+	// hs2019 means "figure it out from the key"; our keys are all RSA
+	if algo := params["algorithm"]; algo != "" && algo != "rsa-sha256" && algo != "hs2019" {
+		return nil, fmt.Sprintf("Unsupported signature algorithm: %s", algo)
+	}
+
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	covers := func(name string) bool {
+		for _, h := range headers {
+			if h == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !covers("(request-target)") {
+		return nil, "Signature must cover (request-target)"
+	}
+
+	if covers("(created)") {
+		created, err := strconv.ParseInt(params["created"], 10, 64)
+		if err != nil {
+			return nil, "Signature covers (created) but has no valid created parameter"
+		}
+		if err = shared.CheckSigTime(time.Unix(created, 0), chk.maxSkew); err != nil {
+			return nil, err.Error()
+		}
+	} else if covers("date") {
+		date, err := http.ParseTime(r.Header.Get("Date"))
+		if err != nil {
+			return nil, "Missing or invalid 'Date' header"
+		}
+		if err = shared.CheckSigTime(date, chk.maxSkew); err != nil {
+			return nil, err.Error()
+		}
+	} else {
+		return nil, "Signature must cover date or (created)"
+	}
+	if expires, err := strconv.ParseInt(params["expires"], 10, 64); err == nil && time.Now().Unix() > expires {
+		return nil, "Signature has expired"
+	}
+
+	if body != nil {
+		if !covers("digest") {
+			return nil, "Signature must cover digest"
+		}
+		if err := shared.VerifyDigest(r.Header.Get("Digest"), body); err != nil {
+			return nil, err.Error()
+		}
+	}
+
+	return &sigHeaderInfo{keyId: keyId}, ""
+}
+
+func (chk *httpSigChecker) parseRfc9421Headers(body []byte, r *http.Request) (*sigHeaderInfo, string) {
+
+	sig, err := shared.ParseRfc9421(r.Header.Get("Signature-Input"), r.Header.Get("Signature"))
 	if err != nil {
-		chk.logger.Errorf("Failed to create signature verifier: %v", err)
-		return nil, "", err
+		return nil, err.Error()
+	}
+	if sig.KeyId == "" {
+		return nil, "Signature has no keyid"
+	}
+
+	if !sig.Covers("@method") {
+		return nil, "Signature must cover @method"
+	}
+	if !sig.Covers("@target-uri") && !sig.Covers("@request-target") && !sig.Covers("@path") {
+		return nil, "Signature must cover @target-uri"
+	}
+
+	if sig.Created == 0 {
+		return nil, "Signature has no created parameter"
+	}
+	if err = shared.CheckSigTime(time.Unix(sig.Created, 0), chk.maxSkew); err != nil {
+		return nil, err.Error()
+	}
+	if sig.Expires != 0 && time.Now().Unix() > sig.Expires {
+		return nil, "Signature has expired"
+	}
+
+	if body != nil {
+		if sig.Covers("content-digest") {
+			err = shared.VerifyContentDigest(r.Header.Get("Content-Digest"), body)
+		} else if sig.Covers("digest") {
+			err = shared.VerifyDigest(r.Header.Get("Digest"), body)
+		} else {
+			return nil, "Signature must cover content-digest"
+		}
+		if err != nil {
+			return nil, err.Error()
+		}
 	}
 
+	return &sigHeaderInfo{keyId: sig.KeyId, rfc9421: sig}, ""
+}
+
+func (chk *httpSigChecker) verify(
+	r *http.Request,
+	shi *sigHeaderInfo,
+	userInfo *dto.UserInfo,
+) (*dto.UserInfo, string, error) {
+
+	var err error
+
 	pubKeyStr := userInfo.PublicKey.PublicKeyPem
 	block, _ := pem.Decode([]byte(pubKeyStr))
+	if block == nil {
+		return nil, "Sender has no valid public key", nil
+	}
 
 	var pubKey interface{}
 	if pubKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
 		return nil, fmt.Sprintf("Failed to parse sender's public key: %v", err), nil
 	}
 
+	if shi.rfc9421 != nil {
+		if err = shi.rfc9421.Verify(r, pubKey); err != nil {
+			return nil, fmt.Sprintf("Incorrect signature: %v", err), nil
+		}
+		if host, err := shared.GetHostName(userInfo.Id); err == nil {
+			chk.sigSchemes.MarkRfc9421(host)
+		}
+		return userInfo, "", nil
+	}
+
+	verifier, err := httpsig.NewVerifier(r)
+	if err != nil {
+		chk.logger.Errorf("Failed to create signature verifier: %v", err)
+		return nil, "", err
+	}
+
 	if err = verifier.Verify(pubKey, httpsig.RSA_SHA256); err != nil {
 		return nil, fmt.Sprintf("Incorrect signature: %v", err), nil
 	}
 
 	return userInfo, "", nil
 }
+
+// An activity with an integrity proof can be verified no matter who delivered it, or when, e.g., when it was
+// forwarded or relayed. The proof's age is not checked: our own queued deliveries carry the proof made when
+// they were queued, for as long as retries go on. Replays are caught by the handled-activity records instead.
+func (chk *httpSigChecker) CheckProof(actor string, body []byte) (*dto.UserInfo, string, error) {
+
+	keyId := shared.GetProofVerificationMethod(body)
+	if keyId == "" {
+		return nil, "Activity has no eddsa-jcs-2022 proof", nil
+	}
+	if !strings.HasPrefix(keyId, actor) {
+		return nil, fmt.Sprintf("Actor is not prefix of proof's key; actor: %s, key: %s", actor, keyId), nil
+	}
+
+	userInfo, fromCache, err := chk.userRetriever.RetrieveCached(actor)
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to retrieve user info for actor: %s: %v", actor, err), nil
+	}
+	problem := verifyProofWithActor(body, keyId, userInfo)
+	if problem == "" || !fromCache {
+		return userInfo, problem, nil
+	}
+
+	chk.logger.Infof("Proof check failed with cached key of %s; fetching actor again", actor)
+	if userInfo, err = chk.userRetriever.Retrieve(actor); err != nil {
+		return nil, fmt.Sprintf("Failed to retrieve user info for actor: %s: %v", actor, err), nil
+	}
+	return userInfo, verifyProofWithActor(body, keyId, userInfo), nil
+}
+
+func verifyProofWithActor(body []byte, keyId string, userInfo *dto.UserInfo) string {
+	for _, mk := range userInfo.AssertionMethod {
+		if mk.Id != keyId {
+			continue
+		}
+		if mk.Controller != userInfo.Id {
+			return fmt.Sprintf("Key is not controlled by actor; actor: %s, key: %s", userInfo.Id, keyId)
+		}
+		pubKey, err := shared.DecodeEd25519Multikey(mk.PublicKeyMultibase)
+		if err != nil {
+			return fmt.Sprintf("Failed to parse actor's Ed25519 key: %v", err)
+		}
+		if err = shared.VerifyEddsaJcsProof(body, pubKey); err != nil {
+			return fmt.Sprintf("Incorrect proof: %v", err)
+		}
+		return ""
+	}
+	return fmt.Sprintf("Actor %s has no assertion method %s", userInfo.Id, keyId)
+}
@@ -0,0 +1,256 @@
+package logic
+
+import (
+	"encoding/json"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/shared"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_inbound_queue.go -package mocks rss_parrot/logic IInboundQueue
+
+type IInboundQueue interface {
+	// Stores an activity whose signature has been verified; it is processed in the background.
+	Enqueue(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) error
+}
+
+const inboundLoopIdleWakeSec = 5
+
+const (
+	defaultInboundWorkers     = 8
+	defaultInboundMaxAttempts = 6
+	defaultInboundRetryBase   = 30
+	inboundRetryMaxSec        = 60 * 60
+)
+
+type inboundResult struct {
+	item *dal.InboundItem
+	err  error
+}
+
+type inboundQueue struct {
+	cfg          *shared.Config
+	logger       shared.ILogger
+	repo         dal.IRepo
+	metrics      IMetrics
+	inbox        IInbox
+	newItems     chan struct{}
+	inProgress   map[int]struct{} // IDs of items being processed
+	workers      int
+	maxAttempts  int
+	retryBase    time.Duration
+	retryMax     time.Duration
+	itemFinished chan inboundResult
+}
+
+func NewInboundQueue(
+	cfg *shared.Config,
+	logger shared.ILogger,
+	repo dal.IRepo,
+	metrics IMetrics,
+	inbox IInbox,
+) IInboundQueue {
+
+	q := inboundQueue{
+		cfg:     cfg,
+		logger:  logger,
+		repo:    repo,
+		metrics: metrics,
+		inbox:   inbox,
+	}
+	q.workers = orDefault(cfg.Inbound.Workers, defaultInboundWorkers)
+	q.maxAttempts = orDefault(cfg.Inbound.MaxAttempts, defaultInboundMaxAttempts)
+	q.retryBase = time.Duration(orDefault(cfg.Inbound.RetryBaseSec, defaultInboundRetryBase)) * time.Second
+	q.retryMax = inboundRetryMaxSec * time.Second
+
+	// One pending signal is enough: the loop fetches all due items it has room for when it wakes
+	q.newItems = make(chan struct{}, 1)
+	q.inProgress = make(map[int]struct{})
+	q.itemFinished = make(chan inboundResult)
+	go q.loop()
+
+	return &q
+}
+
+func (q *inboundQueue) Enqueue(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) error {
+
+	senderJson, err := json.Marshal(senderInfo)
+	if err != nil {
+		return err
+	}
+	err = q.repo.AddInboundItem(&dal.InboundItem{
+		ReceivingUser: receivingUser,
+		Sender:        string(senderJson),
+		SenderId:      senderInfo.Id,
+		Activity:      string(bodyBytes),
+		ReceivedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case q.newItems <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Returns the wait before the next attempt after the given number of failed attempts
+func (q *inboundQueue) getRetryDelay(attempts int) time.Duration {
+	delay := q.retryBase
+	for i := 1; i < attempts && delay < q.retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, q.retryMax)
+}
+
+func (q *inboundQueue) loop() {
+
+	startItems := func() {
+		freeSlots := q.workers - len(q.inProgress)
+		if freeSlots <= 0 {
+			return
+		}
+		skipIds := make([]int, 0, len(q.inProgress))
+		for id := range q.inProgress {
+			skipIds = append(skipIds, id)
+		}
+		items, qlen, err := q.repo.GetInboundItems(time.Now().UTC(), skipIds, freeSlots)
+		if err != nil {
+			q.logger.Errorf("Failed to get inbound queue items: %v", err)
+			return
+		}
+		q.metrics.InboundQueueLength(qlen)
+		for _, item := range items {
+			q.inProgress[item.Id] = struct{}{}
+			go func() {
+				q.itemFinished <- inboundResult{item, q.process(item)}
+			}()
+		}
+	}
+
+	handleResult := func(res inboundResult) {
+		item := res.item
+		defer delete(q.inProgress, item.Id)
+		latency := time.Since(item.ReceivedAt)
+
+		if res.err == nil {
+			if err := q.repo.DeleteInboundItem(item.Id); err != nil {
+				q.logger.Errorf("Failed to remove processed activity from inbound queue: %d: %v", item.Id, err)
+			}
+			q.metrics.InboundProcessed("processed", latency)
+			return
+		}
+
+		attempts := item.Attempts + 1
+		if attempts >= q.maxAttempts {
+			q.logger.Warnf("Giving up on inbound activity %d after %d attempts: %v", item.Id, attempts, res.err)
+			if err := q.repo.DeleteInboundItem(item.Id); err != nil {
+				q.logger.Errorf("Failed to remove activity from inbound queue: %d: %v", item.Id, err)
+			}
+			q.metrics.InboundProcessed("dropped", latency)
+			return
+		}
+		nextAttemptAt := time.Now().UTC().Add(q.getRetryDelay(attempts))
+		if err := q.repo.RescheduleInboundItem(item.Id, attempts, nextAttemptAt, res.err.Error()); err != nil {
+			q.logger.Errorf("Failed to reschedule inbound activity: %d: %v", item.Id, err)
+		}
+		q.metrics.InboundProcessed("retry", latency)
+	}
+
+	for {
+		select {
+		case <-q.newItems:
+			startItems()
+		case <-time.After(inboundLoopIdleWakeSec * time.Second):
+			startItems()
+		case res := <-q.itemFinished:
+			handleResult(res)
+			startItems()
+		}
+	}
+}
+
+// Hands the activity to the inbox. Requests that are invalid are logged and count as processed;
+// only an error means the item should be tried again.
+func (q *inboundQueue) process(item *dal.InboundItem) error {
+
+	var senderInfo dto.UserInfo
+	if err := json.Unmarshal([]byte(item.Sender), &senderInfo); err != nil {
+		q.logger.Errorf("Invalid sender in inbound queue item %d: %v", item.Id, err)
+		return nil
+	}
+	bodyBytes := []byte(item.Activity)
+	var act dto.ActivityInBase
+	if err := json.Unmarshal(bodyBytes, &act); err != nil {
+		q.logger.Errorf("Invalid activity in inbound queue item %d: %v", item.Id, err)
+		return nil
+	}
+
+	reqProblem, err := q.dispatch(item.ReceivingUser, &senderInfo, act, bodyBytes)
+	if err != nil {
+		q.logger.Errorf("Error handling inbox activity %s: %v", act.Id, err)
+		return err
+	}
+	if reqProblem != "" {
+		q.logger.Infof("Invalid '%s' request: %s", act.Type, reqProblem)
+	}
+	return nil
+}
+
+func (q *inboundQueue) dispatch(
+	userName string,
+	senderInfo *dto.UserInfo,
+	act dto.ActivityInBase,
+	bodyBytes []byte,
+) (reqProblem string, err error) {
+
+	// Handle different activities
+	// IsInboundActivityHandled must agree with what we do here
+	switch act.Type {
+	case "Follow":
+		return q.inbox.HandleFollow(userName, senderInfo, bodyBytes)
+	case "Undo":
+		return q.inbox.HandleUndo(userName, senderInfo, bodyBytes)
+	case "Create":
+		if getObjectType(act) == "Note" {
+			return q.inbox.HandleCreateNote(act, senderInfo, bodyBytes)
+		}
+	case "Accept", "Reject":
+		return q.inbox.HandleAcceptReject(userName, act, senderInfo)
+	case "Block":
+		return q.inbox.HandleBlock(userName, senderInfo, bodyBytes)
+	case "Move":
+		return q.inbox.HandleMove(senderInfo, bodyBytes)
+	case "Flag":
+		return q.inbox.HandleFlag(act, senderInfo, bodyBytes)
+	default:
+		q.logger.Debugf("Ignoring '%s' activity", act.Type)
+	}
+	return "", nil
+}
+
+// Returns the type of the activity's object if it is embedded; empty string otherwise
+func getObjectType(act dto.ActivityInBase) string {
+	if objMap, ok := act.Object.(map[string]interface{}); ok {
+		if objTypeStr, ok := objMap["type"].(string); ok {
+			return objTypeStr
+		}
+	}
+	return ""
+}
+
+// True if the inbound queue does something with the activity. Others, like Delete, Like or Announce,
+// are not worth storing. The activity must be normalized.
+func IsInboundActivityHandled(act dto.ActivityInBase) bool {
+	switch act.Type {
+	case "Follow", "Undo", "Accept", "Reject", "Block", "Move", "Flag":
+		return true
+	case "Create":
+		return getObjectType(act) == "Note"
+	}
+	return false
+}
@@ -3,26 +3,37 @@ package logic
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/microcosm-cc/bluemonday"
+	"github.com/PuerkitoBio/goquery"
 	"net/url"
 	"regexp"
 	"rss_parrot/dal"
 	"rss_parrot/dto"
 	"rss_parrot/shared"
 	"rss_parrot/texts"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
+//go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_inbox.go -package mocks rss_parrot/logic IInbox
+
 type IInbox interface {
 	HandleFollow(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error)
 	HandleUndo(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error)
 	HandleCreateNote(actBase dto.ActivityInBase, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error)
+	HandleAcceptReject(receivingUser string, actBase dto.ActivityInBase, senderInfo *dto.UserInfo) (string, error)
+	HandleBlock(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error)
+	HandleMove(senderInfo *dto.UserInfo, bodyBytes []byte) (string, error)
+	HandleFlag(actBase dto.ActivityInBase, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error)
 }
 
 const (
 	firstPurgeDelayMin     = 1
 	purgeActivitiesLoopMin = 60
 	activitiesKeptHr       = 48
+	actorCacheKeptDays     = 30
+	defaultMaxUrlsPerMsg   = 5
 )
 
 type inbox struct {
@@ -37,7 +48,12 @@ type inbox struct {
 	sender          IActivitySender
 	messenger       IMessenger
 	fdfol           IFeedFollower
+	relays          IRelays
+	dblocks         IDomainBlocks
+	reports         IReports
+	userRetriever   IUserRetriever
 	reUserUrlParser *regexp.Regexp
+	reStatusUrl     *regexp.Regexp
 	reHttps         *regexp.Regexp
 }
 
@@ -52,13 +68,18 @@ func NewInbox(
 	sender IActivitySender,
 	messenger IMessenger,
 	fdfol IFeedFollower,
+	relays IRelays,
+	dblocks IDomainBlocks,
+	reports IReports,
+	userRetriever IUserRetriever,
 ) IInbox {
 
 	reUserUrlParser := regexp.MustCompile("https://" + cfg.Host + "/u/([^/]+)/?")
-	reHttps := regexp.MustCompile("https?://[^ ]+")
+	reStatusUrl := regexp.MustCompile("^https://" + regexp.QuoteMeta(cfg.Host) + "/u/[^/]+/status/[0-9]+$")
+	reHttps := regexp.MustCompile(`https?://[^\s<>"]+`)
 	res := inbox{cfg, logger, shared.IdBuilder{cfg.Host}, repo, txt, metrics, udir,
-		keyStore, sender, messenger, fdfol,
-		reUserUrlParser, reHttps}
+		keyStore, sender, messenger, fdfol, relays, dblocks, reports, userRetriever,
+		reUserUrlParser, reStatusUrl, reHttps}
 
 	go res.purgeOldAvititiesLoop()
 
@@ -77,6 +98,10 @@ func (ib *inbox) purgeOldAvititiesLoop() {
 		if err != nil {
 			ib.logger.Errorf("Failed to purge old handled activities: %v", err)
 		}
+		err = ib.repo.DeleteCachedActors(time.Now().Add(-actorCacheKeptDays * 24 * time.Hour))
+		if err != nil {
+			ib.logger.Errorf("Failed to purge old cached actors: %v", err)
+		}
 		time.Sleep(time.Minute * purgeActivitiesLoopMin)
 	}
 }
@@ -155,6 +180,7 @@ func (ib *inbox) HandleFollow(
 		ib.logger.Infof("Activity has already been handled: %s", actFollow.Id)
 		return
 	}
+	defer ib.unmarkIfFailed(actFollow.Id, &err)
 
 	// Is object the ID if this account?
 	myUserUrl := ib.idb.UserUrl(receivingUser)
@@ -175,6 +201,39 @@ func (ib *inbox) HandleFollow(
 		return
 	}
 
+	// Follows from domains that are blocked at this level get a Reject
+	var blockLevel int
+	if blockLevel, err = ib.dblocks.GetLevel(actorHostName); err != nil {
+		return
+	}
+	if blockLevel >= dal.DomainRejectFollows {
+		ib.logger.Infof("Rejecting Follow from blocked domain %s", actorHostName)
+		err = ib.rejectFollow(receivingUser, senderInfo, &actFollow)
+		return
+	}
+
+	// Actor blocked this account: they don't get to follow it again until they unblock it
+	var isBlocked bool
+	if isBlocked, err = ib.repo.IsRemoteBlocked(receivingUser, actFollow.Actor); err != nil {
+		return
+	}
+	if isBlocked {
+		ib.logger.Infof("Rejecting Follow from %s, who blocked %s", actFollow.Actor, receivingUser)
+		err = ib.rejectFollow(receivingUser, senderInfo, &actFollow)
+		return
+	}
+
+	// Rejected earlier through the API: stays rejected until approved there
+	var existing *dal.FollowerInfo
+	if existing, err = ib.repo.GetFollower(receivingUser, actFollow.Actor); err != nil {
+		return
+	}
+	if existing != nil && existing.ApproveStatus < 0 {
+		ib.logger.Infof("Rejecting Follow from %s, who was rejected by %s before", actFollow.Actor, receivingUser)
+		err = ib.rejectFollow(receivingUser, senderInfo, &actFollow)
+		return
+	}
+
 	flwr := dal.FollowerInfo{
 		RequestId:     actFollow.Id,
 		ApproveStatus: 0,
@@ -190,23 +249,42 @@ func (ib *inbox) HandleFollow(
 	}
 	ib.updateFollowerMetric()
 
-	autoAccept := true
-	if account.Handle == ib.cfg.Birb.User && ib.cfg.Birb.ManuallyApprovesFollows {
-		autoAccept = false
-	}
-	if autoAccept {
-		go func() {
-			time.Sleep(1000)
-			err := ib.udir.AcceptFollower(flwr.RequestId, flwr.UserUrl, flwr.UserInbox, receivingUser)
-			if err != nil {
-				ib.logger.Errorf("Error accepting follower: %v", err)
-			}
-		}()
+	if !ib.needsApproval(account) {
+		err = ib.udir.AcceptFollower(flwr.RequestId, flwr.UserUrl, flwr.UserInbox, receivingUser)
+		if err != nil {
+			ib.logger.Errorf("Error accepting follower: %v", err)
+			err = nil
+		}
 	}
 
 	return
 }
 
+// True if the account's owner must approve new followers
+func (ib *inbox) needsApproval(account *dal.Account) bool {
+	if account.Handle == ib.cfg.Birb.User && ib.cfg.Birb.ManuallyApprovesFollows {
+		return true
+	}
+	return account.ManuallyApproves
+}
+
+func (ib *inbox) rejectFollow(receivingUser string, senderInfo *dto.UserInfo, actFollow *dto.ActivityIn[string]) error {
+
+	actReject := dto.ActivityOut{
+		Context: "https://www.w3.org/ns/activitystreams",
+		Id:      ib.idb.ActivityUrl(ib.repo.GetNextId()),
+		Type:    "Reject",
+		Actor:   ib.idb.UserUrl(receivingUser),
+		Object: dto.ActivityOut{
+			Id:     actFollow.Id,
+			Type:   "Follow",
+			Actor:  actFollow.Actor,
+			Object: actFollow.Object,
+		},
+	}
+	return ib.messenger.EnqueueActivity(receivingUser, senderInfo.Inbox, &actReject, PriorityHigh)
+}
+
 func (ib *inbox) updateFollowerMetric() {
 	if count, err := ib.repo.GetFeedFollowerCount(); err != nil {
 		ib.logger.Errorf("Error getting feed follower count: %v", err)
@@ -248,10 +326,16 @@ func (ib *inbox) HandleUndo(
 		ib.logger.Infof("Activity has already been handled: %s", actUndo.Id)
 		return
 	}
+	defer ib.unmarkIfFailed(actUndo.Id, &err)
 
 	// Undoing what?
 	if actUndo.Object.Type == "Follow" {
 		reqProblem, err = ib.handleUnfollow(receivingUser, bodyBytes)
+	} else if actUndo.Object.Type == "Block" {
+		reqProblem, err = ib.handleUnblock(receivingUser, senderInfo, &actUndo.Object)
+	} else if actUndo.Object.Type == "Accept" {
+		// Sender's side no longer considers the follow accepted
+		reqProblem, err = ib.removeFollow(receivingUser, senderInfo, actUndo.Object.Object)
 	}
 
 	return
@@ -338,6 +422,7 @@ func (ib *inbox) HandleCreateNote(
 		ib.logger.Infof("Activity has already been handled: %s", actBase.Id)
 		return
 	}
+	defer ib.unmarkIfFailed(actBase.Id, &err)
 
 	// Is it addressed to both me, and "public"?
 	birbUsrUrl := ib.idb.UserUrl(ib.cfg.Birb.User)
@@ -377,20 +462,30 @@ func (ib *inbox) HandleCreateNote(
 	// What goes into to and cc
 	to, cc := ib.getRecipients(act.Actor, senderInfo.Followers, !toPublicOrFollowers)
 
-	// Look for exactly 1 valid URL in message
-	blogUrl := ib.getUrl(act.Object.Content)
-	if blogUrl == "" {
-		ib.logger.Info("No single URL found in message")
-		msg := ib.txt.WithVals("reply_no_single_url.html", map[string]string{
+	// Look for website URLs in message
+	blogUrls := ib.getUrls(&act.Object)
+	maxUrls := orDefault(ib.cfg.MaxUrlsPerMention, defaultMaxUrlsPerMsg)
+	if len(blogUrls) == 0 || len(blogUrls) > maxUrls {
+		ib.logger.Infof("Found %d URLs in message; expected between 1 and %d", len(blogUrls), maxUrls)
+		template := "reply_no_single_url.html"
+		if len(blogUrls) > maxUrls {
+			template = "reply_too_many_urls.html"
+		}
+		msg := ib.txt.WithVals(template, map[string]string{
 			"moniker": moniker,
 			"userUrl": senderInfo.Id,
+			"maxUrls": fmt.Sprintf("%d", maxUrls),
 		})
 		ib.messenger.SendMessageAsync(ib.cfg.Birb.User, senderInfo.Inbox, msg,
 			[]*MsgMention{{moniker, act.Actor}}, to, cc, act.Object.Id)
 		return
 	}
 
-	go ib.handleSiteRequest(senderInfo, act, to, cc, moniker, blogUrl)
+	if len(blogUrls) == 1 {
+		ib.handleSiteRequest(senderInfo, act, to, cc, moniker, blogUrls[0])
+	} else {
+		ib.handleSiteRequests(senderInfo, act, to, cc, moniker, blogUrls)
+	}
 
 	return
 }
@@ -446,23 +541,438 @@ func (ib *inbox) handleSiteRequest(senderInfo *dto.UserInfo, act dto.ActivityIn[
 		to, cc, act.Object.Id)
 }
 
-func (ib *inbox) getUrl(content string) string {
+type siteRequestResult struct {
+	url    string
+	acct   *dal.Account
+	status FeedStatus
+}
 
-	pol := bluemonday.StrictPolicy()
-	plain := pol.Sanitize(content)
-	matches := ib.reHttps.FindAllString(plain, -1)
+// Snippet for each outcome in a reply about several sites
+var siteResultTemplates = map[FeedStatus]string{
+	FsNew:             "reply_multi_new.html",
+	FsAlreadyFollowed: "reply_multi_existing.html",
+	FsError:           "reply_multi_not_found.html",
+	FsMastodon:        "reply_multi_mastodon.html",
+	FsBanned:          "reply_multi_banned.html",
+	FsOptOut:          "reply_multi_optout.html",
+}
 
-	// Looking for exactly one valid Url
-	res := ""
-	for _, str := range matches {
-		_, err := url.Parse(str)
-		if err != nil {
-			continue
+// Creates or retrieves the accounts for several sites at once, and sends a single reply listing the outcomes
+func (ib *inbox) handleSiteRequests(senderInfo *dto.UserInfo, act dto.ActivityIn[dto.Note],
+	to, cc []string, moniker string, blogUrls []string) {
+
+	results := make([]siteRequestResult, len(blogUrls))
+	var wg sync.WaitGroup
+	for i, blogUrl := range blogUrls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acct, status, err := ib.fdfol.GetAccountForFeed(blogUrl)
+			if status < 0 {
+				ib.logger.Infof("Could not create/retrieve account for site: %s: %v", blogUrl, err)
+				acct = nil
+			} else {
+				ib.logger.Infof("Account for site created/retrieved: %s -> %s", blogUrl, acct.Handle)
+			}
+			results[i] = siteRequestResult{blogUrl, acct, status}
+		}()
+	}
+	wg.Wait()
+
+	msg := ib.txt.WithVals("reply_multi_header.html", map[string]string{
+		"moniker": moniker,
+		"userUrl": senderInfo.Id,
+	})
+	mentions := []*MsgMention{{moniker, act.Actor}}
+	for _, res := range results {
+		template, ok := siteResultTemplates[res.status]
+		if !ok {
+			template = siteResultTemplates[FsError]
+		}
+		vals := map[string]string{"url": res.url}
+		if res.acct != nil {
+			accountUrl := ib.idb.UserUrl(res.acct.Handle)
+			vals["accountName"] = res.acct.FeedName
+			vals["accountMoniker"] = "@" + res.acct.Handle
+			vals["host"] = ib.cfg.Host
+			vals["accountUrl"] = accountUrl
+			mentions = append(mentions, &MsgMention{shared.MakeFullMoniker(ib.cfg.Host, res.acct.Handle), accountUrl})
+		}
+		msg += "\n" + ib.txt.WithVals(template, vals)
+	}
+	ib.messenger.SendMessageAsync(ib.cfg.Birb.User, senderInfo.Inbox, msg, mentions, to, cc, act.Object.Id)
+}
+
+// Returns the distinct website URLs in the note's content.
+// Mentions and hashtags are links too, but they don't count.
+func (ib *inbox) getUrls(note *dto.Note) []string {
+
+	var res []string
+	ignored := map[string]bool{}
+	if note.Tag != nil {
+		for _, tag := range *note.Tag {
+			ignored[tag.Href] = true
 		}
-		if res != "" {
-			return ""
+	}
+	addUrl := func(str string) {
+		parsed, err := url.Parse(str)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return
+		}
+		if ignored[str] {
+			return
 		}
-		res = str
+		ignored[str] = true
+		res = append(res, str)
 	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(note.Content))
+	if err != nil {
+		return nil
+	}
+
+	// Links: Mastodon marks mentions with the "mention" class, hashtags with "hashtag" and rel="tag"
+	doc.Find("a").Each(func(_ int, s *goquery.Selection) {
+		class := " " + s.AttrOr("class", "") + " "
+		rel := " " + s.AttrOr("rel", "") + " "
+		isTag := strings.Contains(class, " mention ") || strings.Contains(class, " hashtag ") ||
+			strings.Contains(rel, " tag ")
+		if href, ok := s.Attr("href"); ok && !isTag {
+			addUrl(href)
+		}
+		// Link text is not looked at again below: a mention's text is not an address to parrot
+		s.Remove()
+	})
+
+	// Addresses in plain text that the sender's server did not turn into links
+	doc.Find("*").Contents().Each(func(_ int, s *goquery.Selection) {
+		if goquery.NodeName(s) != "#text" {
+			return
+		}
+		for _, str := range ib.reHttps.FindAllString(s.Text(), -1) {
+			addUrl(strings.TrimRight(str, ".,;:!?)"))
+		}
+	})
+
 	return res
 }
+
+// If handling the activity failed, the inbound queue tries it again; it must not be skipped as handled then
+func (ib *inbox) unmarkIfFailed(activityId string, err *error) {
+	if *err == nil {
+		return
+	}
+	if unmarkErr := ib.repo.UnmarkActivityHandled(activityId); unmarkErr != nil {
+		ib.logger.Errorf("Failed to unmark activity as handled: %s: %v", activityId, unmarkErr)
+	}
+}
+
+// The only thing we ever follow is relays, so an Accept or Reject is usually about one of those.
+// A Reject from anyone else is about a follow of one of our accounts that their side no longer recognizes.
+func (ib *inbox) HandleAcceptReject(
+	receivingUser string,
+	actBase dto.ActivityInBase,
+	senderInfo *dto.UserInfo) (reqProblem string, err error) {
+
+	var alreadyHandled bool
+	alreadyHandled, err = ib.repo.MarkActivityHandled(actBase.Id, time.Now())
+	if err != nil {
+		return
+	}
+	if alreadyHandled {
+		ib.logger.Infof("Activity has already been handled: %s", actBase.Id)
+		return
+	}
+	defer ib.unmarkIfFailed(actBase.Id, &err)
+
+	var isRelay bool
+	if isRelay, err = ib.relays.HandleResponse(senderInfo, &actBase); err != nil || isRelay {
+		return
+	}
+	if actBase.Type != "Reject" {
+		ib.logger.Infof("Ignoring %s from %s, which is not a relay we follow", actBase.Type, senderInfo.Id)
+		return
+	}
+	return ib.removeFollow(receivingUser, senderInfo, actBase.Object)
+}
+
+// Removes the follower relationship between the sender and one of our accounts. The Follow is either
+// embedded, or just its ID, in which case the account is whose inbox the activity was sent to.
+func (ib *inbox) removeFollow(receivingUser string, senderInfo *dto.UserInfo, follow any) (reqProblem string, err error) {
+
+	user := receivingUser
+	if followMap, ok := follow.(map[string]any); ok {
+		actor, _ := followMap["actor"].(string)
+		object, _ := followMap["object"].(string)
+		var groups []string
+		if actor == senderInfo.Id {
+			groups = ib.reUserUrlParser.FindStringSubmatch(object)
+		} else if object == senderInfo.Id {
+			groups = ib.reUserUrlParser.FindStringSubmatch(actor)
+		}
+		if groups == nil {
+			reqProblem = fmt.Sprintf("Follow is not between sender and one of our accounts: %s -> %s", actor, object)
+			return
+		}
+		user = groups[1]
+	}
+	if user == "" {
+		ib.logger.Infof("Ignoring rejection of unknown follow from %s", senderInfo.Id)
+		return
+	}
+
+	var userExists bool
+	if userExists, err = ib.repo.DoesAccountExist(user); err != nil {
+		return
+	}
+	if !userExists {
+		reqProblem = fmt.Sprintf("User does not exist: %s", user)
+		return
+	}
+
+	ib.logger.Infof("Removing follower %s of %s, whose side no longer recognizes the follow", senderInfo.Id, user)
+	if err = ib.repo.RemoveFollower(user, senderInfo.Id); err != nil {
+		return
+	}
+	ib.updateFollowerMetric()
+	return
+}
+
+// Remote actor blocked one of our accounts: they are no longer a follower, and we stop delivering to them
+func (ib *inbox) HandleBlock(
+	receivingUser string,
+	senderInfo *dto.UserInfo,
+	bodyBytes []byte) (reqProblem string, err error) {
+
+	ib.logger.Infof("Handling Block activity to %s", receivingUser)
+
+	var actBlock dto.ActivityIn[string]
+	if jsonErr := json.Unmarshal(bodyBytes, &actBlock); jsonErr != nil {
+		ib.logger.Info("Invalid JSON in Block activity body")
+		reqProblem = fmt.Sprintf("Invalid JSON: %v", jsonErr)
+		return
+	}
+
+	var alreadyHandled bool
+	alreadyHandled, err = ib.repo.MarkActivityHandled(actBlock.Id, time.Now())
+	if err != nil {
+		return
+	}
+	if alreadyHandled {
+		ib.logger.Infof("Activity has already been handled: %s", actBlock.Id)
+		return
+	}
+	defer ib.unmarkIfFailed(actBlock.Id, &err)
+
+	user, reqProblem := ib.getBlockedUser(receivingUser, actBlock.Actor, actBlock.Object, senderInfo)
+	if reqProblem != "" {
+		return
+	}
+
+	if err = ib.repo.RemoveFollower(user, actBlock.Actor); err != nil {
+		return
+	}
+	if err = ib.repo.AddRemoteBlock(user, actBlock.Actor, time.Now().UTC()); err != nil {
+		return
+	}
+	ib.updateFollowerMetric()
+	return
+}
+
+func (ib *inbox) handleUnblock(
+	receivingUser string,
+	senderInfo *dto.UserInfo,
+	actBlock *dto.ActivityInBase) (reqProblem string, err error) {
+
+	ib.logger.Infof("Handling Undo Block activity to %s", receivingUser)
+
+	object, _ := actBlock.Object.(string)
+	user, reqProblem := ib.getBlockedUser(receivingUser, actBlock.Actor, object, senderInfo)
+	if reqProblem != "" {
+		return
+	}
+	err = ib.repo.RemoveRemoteBlock(user, actBlock.Actor)
+	return
+}
+
+// Checks that the sender is the one blocking, and that the blocked user is one of our existing accounts
+func (ib *inbox) getBlockedUser(
+	receivingUser, actor, object string,
+	senderInfo *dto.UserInfo) (user, reqProblem string) {
+
+	if actor != senderInfo.Id {
+		reqProblem = fmt.Sprintf("Block actor %s is not the sender %s", actor, senderInfo.Id)
+		return
+	}
+	groups := ib.reUserUrlParser.FindStringSubmatch(object)
+	if groups == nil {
+		reqProblem = fmt.Sprintf("Cannot parse Block object as one of our users: %s", object)
+		return
+	}
+	user = groups[1]
+	if receivingUser != "" && user != receivingUser {
+		reqProblem = fmt.Sprintf("Block sent to '%s' but object is %s", receivingUser, object)
+		return
+	}
+	userExists, err := ib.repo.DoesAccountExist(user)
+	if err != nil || !userExists {
+		reqProblem = fmt.Sprintf("User does not exist: %s", user)
+	}
+	return
+}
+
+// Flag is a report about one of our accounts, or some of its statuses, usually sent by a remote instance's moderators
+func (ib *inbox) HandleFlag(
+	actBase dto.ActivityInBase,
+	senderInfo *dto.UserInfo,
+	bodyBytes []byte) (reqProblem string, err error) {
+
+	ib.logger.Infof("Handling Flag activity from %s", senderInfo.Id)
+
+	var act dto.Flag
+	if jsonErr := json.Unmarshal(bodyBytes, &act); jsonErr != nil {
+		ib.logger.Info("Invalid JSON in Flag activity body")
+		reqProblem = fmt.Sprintf("Invalid JSON: %v", jsonErr)
+		return
+	}
+
+	// Object lists the reported actor and statuses; a Flag is about one account, so we go with the first one
+	report := dal.Report{
+		ActivityId: actBase.Id,
+		Reporter:   senderInfo.Id,
+		Comment:    act.Content,
+		CreatedAt:  time.Now().UTC(),
+	}
+	for _, objId := range act.ObjectIds() {
+		groups := ib.reUserUrlParser.FindStringSubmatch(objId)
+		if groups == nil {
+			continue
+		}
+		if report.AccountHandle == "" {
+			report.AccountHandle = groups[1]
+		} else if groups[1] != report.AccountHandle {
+			ib.logger.Infof("Flag is about more than one account; ignoring %s", objId)
+			continue
+		}
+		if ib.reStatusUrl.MatchString(objId) {
+			report.StatusIds = append(report.StatusIds, objId)
+		}
+	}
+	if report.AccountHandle == "" {
+		reqProblem = "Flag is not about any of our accounts"
+		return
+	}
+
+	var account *dal.Account
+	if account, err = ib.repo.GetAccount(report.AccountHandle); err != nil {
+		return
+	}
+	if account == nil {
+		reqProblem = fmt.Sprintf("Reported account does not exist: %s", report.AccountHandle)
+		return
+	}
+
+	err = ib.reports.AddReport(&report)
+	return
+}
+
+// A follower moved their account: the new account takes over their follows, if it claims the old one as an alias
+func (ib *inbox) HandleMove(senderInfo *dto.UserInfo, bodyBytes []byte) (reqProblem string, err error) {
+
+	ib.logger.Infof("Handling Move activity from %s", senderInfo.Id)
+
+	var actMove dto.Move
+	if jsonErr := json.Unmarshal(bodyBytes, &actMove); jsonErr != nil {
+		ib.logger.Info("Invalid JSON in Move activity body")
+		reqProblem = fmt.Sprintf("Invalid JSON: %v", jsonErr)
+		return
+	}
+	if actMove.Actor != senderInfo.Id || actMove.Object != senderInfo.Id {
+		reqProblem = fmt.Sprintf("Move must be sent by the account that moves: %s", senderInfo.Id)
+		return
+	}
+	if actMove.Target == "" || actMove.Target == actMove.Object {
+		reqProblem = "Move has no valid target"
+		return
+	}
+
+	var alreadyHandled bool
+	alreadyHandled, err = ib.repo.MarkActivityHandled(actMove.Id, time.Now())
+	if err != nil {
+		return
+	}
+	if alreadyHandled {
+		ib.logger.Infof("Activity has already been handled: %s", actMove.Id)
+		return
+	}
+	defer ib.unmarkIfFailed(actMove.Id, &err)
+
+	// Fetch the target fresh: the alias was likely added just before the move
+	var target *dto.UserInfo
+	if target, err = ib.userRetriever.Retrieve(actMove.Target); err != nil {
+		ib.logger.Infof("Failed to retrieve Move target %s: %v", actMove.Target, err)
+		reqProblem = fmt.Sprintf("Cannot retrieve Move target: %s", actMove.Target)
+		err = nil
+		return
+	}
+	if !slices.Contains(target.AlsoKnownAs, actMove.Object) {
+		reqProblem = fmt.Sprintf("Move target %s does not list %s in alsoKnownAs", actMove.Target, actMove.Object)
+		return
+	}
+	targetHost, urlError := shared.GetHostName(target.Id)
+	if urlError != nil {
+		reqProblem = urlError.Error()
+		return
+	}
+
+	// The new account gets no follow that it could not get by following: the same blocks apply, and accounts
+	// that approve followers must approve it. For those, its own Follow (sent after the Move) is a new request.
+	var blockLevel int
+	if blockLevel, err = ib.dblocks.GetLevel(targetHost); err != nil {
+		return
+	}
+	var accounts []*dal.Account
+	if accounts, err = ib.repo.GetFollowedAccounts(actMove.Object); err != nil {
+		return
+	}
+	flwr := dal.FollowerInfo{
+		UserUrl:     target.Id,
+		Handle:      target.PreferredUserName,
+		Host:        targetHost,
+		UserInbox:   target.Inbox,
+		SharedInbox: target.Endpoints.SharedInbox,
+	}
+	count := 0
+	for _, acct := range accounts {
+		var isBlocked bool
+		if isBlocked, err = ib.repo.IsRemoteBlocked(acct.Handle, target.Id); err != nil {
+			return
+		}
+		if blockLevel >= dal.DomainRejectFollows || isBlocked || ib.needsApproval(acct) {
+			// A ban moves with the account, so moving can't be used to shed it
+			var oldFlwr *dal.FollowerInfo
+			if oldFlwr, err = ib.repo.GetFollower(acct.Handle, actMove.Object); err != nil {
+				return
+			}
+			if oldFlwr != nil && oldFlwr.ApproveStatus < 0 {
+				ib.logger.Infof("Moving ban of %s on %s to %s", actMove.Object, acct.Handle, target.Id)
+				if err = ib.repo.MoveFollower(acct.Handle, actMove.Object, &flwr); err != nil {
+					return
+				}
+				continue
+			}
+			ib.logger.Infof("Not moving follow of %s to %s; dropping it", acct.Handle, target.Id)
+			if err = ib.repo.RemoveFollower(acct.Handle, actMove.Object); err != nil {
+				return
+			}
+			continue
+		}
+		if err = ib.repo.MoveFollower(acct.Handle, actMove.Object, &flwr); err != nil {
+			return
+		}
+		count++
+	}
+	ib.logger.Infof("Follower %s moved to %s; updated follows of %d accounts", actMove.Object, target.Id, count)
+	ib.updateFollowerMetric()
+	return
+}
@@ -1,10 +1,12 @@
 package logic
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"rss_parrot/dal"
 	"rss_parrot/shared"
 )
@@ -14,6 +16,12 @@ import (
 type IKeyStore interface {
 	GetPrivKey(user string) (*rsa.PrivateKey, error)
 	MakeKeyPair() (pubKey, privKey string, err error)
+	// Returns the account's Ed25519 key for object integrity proofs; creates one if the account has none yet
+	GetEdPrivKey(user string) (ed25519.PrivateKey, error)
+	// Returns the account's Ed25519 public key in Multikey format; empty string if the account has no key yet
+	GetEdPubKey(user string) (string, error)
+	// Creates the account's Ed25519 key pair unless it already has one
+	CreateEdKeys(user string) error
 }
 
 type keyStore struct {
@@ -45,6 +53,9 @@ func (ks *keyStore) GetPrivKey(user string) (*rsa.PrivateKey, error) {
 	}
 
 	block, _ := pem.Decode([]byte(privKeyStr))
+	if block == nil {
+		return nil, fmt.Errorf("no private key found for user %s", user)
+	}
 	privKeyBytes := block.Bytes
 	if x509.IsEncryptedPEMBlock(block) {
 		privKeyBytes, err = x509.DecryptPEMBlock(block, []byte(ks.cfg.Secrets.BirdPrivKeyPass))
@@ -97,3 +108,84 @@ func (ks *keyStore) MakeKeyPair() (pubKey, privKey string, err error) {
 
 	return
 }
+
+func (ks *keyStore) makeEdKeyPair() (pubKey, privKey string, err error) {
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	keyRaw, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	encBlock, err := x509.EncryptPEMBlock(
+		rand.Reader, "PRIVATE KEY", keyRaw,
+		[]byte(ks.cfg.Secrets.BirdPrivKeyPass), x509.PEMCipherAES256)
+	if err != nil {
+		return "", "", err
+	}
+	return shared.EncodeEd25519Multikey(pub), string(pem.EncodeToMemory(encBlock)), nil
+}
+
+// Gets the account's Ed25519 keys, generating and storing them first if needed
+func (ks *keyStore) getEdKeys(user string) (pubKey, privKey string, err error) {
+
+	if pubKey, privKey, err = ks.repo.GetEdKeys(user); err != nil || privKey != "" {
+		return
+	}
+	var newPub, newPriv string
+	if newPub, newPriv, err = ks.makeEdKeyPair(); err != nil {
+		return
+	}
+	if err = ks.repo.SetEdKeysIfMissing(user, newPub, newPriv); err != nil {
+		return
+	}
+	// Read back: a concurrent request may have stored its keys first
+	if pubKey, privKey, err = ks.repo.GetEdKeys(user); err != nil {
+		return
+	}
+	if privKey == "" {
+		err = fmt.Errorf("no account for user %s", user)
+	}
+	return
+}
+
+func (ks *keyStore) GetEdPrivKey(user string) (ed25519.PrivateKey, error) {
+
+	_, privKeyStr, err := ks.getEdKeys(user)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(privKeyStr))
+	if block == nil {
+		return nil, fmt.Errorf("no Ed25519 key found for user %s", user)
+	}
+	privKeyBytes := block.Bytes
+	if x509.IsEncryptedPEMBlock(block) {
+		privKeyBytes, err = x509.DecryptPEMBlock(block, []byte(ks.cfg.Secrets.BirdPrivKeyPass))
+		if err != nil {
+			return nil, err
+		}
+	}
+	key, err := x509.ParsePKCS8PrivateKey(privKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("stored key of user %s is not an Ed25519 key", user)
+	}
+	return edKey, nil
+}
+
+// Serving an actor must not write to the DB, so this never creates keys
+func (ks *keyStore) GetEdPubKey(user string) (string, error) {
+	pubKey, _, err := ks.repo.GetEdKeys(user)
+	return pubKey, err
+}
+
+func (ks *keyStore) CreateEdKeys(user string) error {
+	_, _, err := ks.getEdKeys(user)
+	return err
+}
@@ -1,11 +1,13 @@
 package logic
 
 import (
+	"encoding/json"
 	"regexp"
 	"rss_parrot/dal"
 	"rss_parrot/dto"
 	"rss_parrot/shared"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -13,7 +15,13 @@ import (
 
 type IMessenger interface {
 	SendMessageAsync(byUser string, toInbox, msg string, mentions []*MsgMention, to, cc []string, inReplyTo string)
+	EnqueueActivity(byUser, toInbox string, act *dto.ActivityOut, priority int) error
+	// Queues the same activity for each inbox; it is serialized and signed only once
+	EnqueueActivityToMany(byUser string, toInboxes []string, act *dto.ActivityOut, priority int) error
 	EnqueueBroadcast(user string, statusId string, tootedAt time.Time, msg string) error
+	ResumeHost(host string)
+	GetQueueSummary() (*dto.DeliveryQueue, error)
+	GetFailingInboxes() ([]*dto.InboxHealth, error)
 }
 
 type MsgMention struct {
@@ -21,8 +29,28 @@ type MsgMention struct {
 	UserUrl string
 }
 
-const maxParallelSends = 5
 const tootLoopIdleWakeSec = 5
+const inboxHealthMetricSec = 60
+
+// Queue priorities: replies and follow-ups that someone is waiting for go before bulk broadcasts
+const (
+	PriorityBulk = 0
+	PriorityHigh = 10
+)
+
+const (
+	defaultMaxParallel   = 20
+	defaultMaxPerHost    = 2
+	defaultMaxAttempts   = 12
+	defaultRetryBaseSec  = 60
+	defaultRetryMaxSec   = 6 * 60 * 60
+	defaultDeadInboxDays = 7
+)
+
+type tootResult struct {
+	item *dal.TootQueueItem
+	err  error
+}
 
 type messenger struct {
 	cfg             *shared.Config
@@ -31,10 +59,20 @@ type messenger struct {
 	keyStore        IKeyStore
 	sender          IActivitySender
 	metrics         IMetrics
+	dblocks         IDomainBlocks
 	idb             shared.IdBuilder
 	reStatusId      *regexp.Regexp
 	newTootsInQueue chan struct{}
-	tqProgress      map[int]interface{}
+	tqProgress      map[int]string // Items being sent: ID to host
+	hostsInFlight   map[string]int // Number of items being sent to each host
+	maxParallel     int
+	maxPerHost      int
+	maxAttempts     int
+	retryBase       time.Duration
+	retryMax        time.Duration
+	deadInboxAge    time.Duration
+	muFailingHosts  sync.Mutex
+	failingHosts    map[string]struct{} // Hosts with inboxes in a failure streak; nil until loaded from the DB
 }
 
 func NewMessenger(
@@ -44,6 +82,7 @@ func NewMessenger(
 	keyStore IKeyStore,
 	sender IActivitySender,
 	metrics IMetrics,
+	dblocks IDomainBlocks,
 ) IMessenger {
 
 	m := messenger{
@@ -53,26 +92,30 @@ func NewMessenger(
 		keyStore: keyStore,
 		sender:   sender,
 		metrics:  metrics,
+		dblocks:  dblocks,
 		idb:      shared.IdBuilder{cfg.Host},
 	}
 
+	m.maxParallel = orDefault(cfg.Delivery.MaxParallel, defaultMaxParallel)
+	m.maxPerHost = orDefault(cfg.Delivery.MaxPerHost, defaultMaxPerHost)
+	m.maxAttempts = orDefault(cfg.Delivery.MaxAttempts, defaultMaxAttempts)
+	m.retryBase = time.Duration(orDefault(cfg.Delivery.RetryBaseSec, defaultRetryBaseSec)) * time.Second
+	m.retryMax = time.Duration(orDefault(cfg.Delivery.RetryMaxSec, defaultRetryMaxSec)) * time.Second
+	m.deadInboxAge = time.Duration(orDefault(cfg.Delivery.DeadInboxDays, defaultDeadInboxDays)) * 24 * time.Hour
+
 	m.reStatusId = regexp.MustCompile("^https://[^/]+/u/[^/]+/status/([0-9]+)$")
 
 	m.newTootsInQueue = make(chan struct{})
-	m.tqProgress = make(map[int]interface{})
+	m.tqProgress = make(map[int]string)
+	m.hostsInFlight = make(map[string]int)
 	go m.tootQueueLoop()
 
 	return &m
 }
 
+// Queues a reply Note with high priority; errors are only logged.
 func (m *messenger) SendMessageAsync(byUser string, toInbox, msg string,
 	mentions []*MsgMention, to, cc []string, inReplyTo string,
-) {
-	go m.sendMessage(byUser, toInbox, msg, mentions, to, cc, inReplyTo)
-}
-
-func (m *messenger) sendMessage(byUser string, toInbox, msg string,
-	mentions []*MsgMention, to, cc []string, inReplyTo string,
 ) {
 	published := time.Now().UTC().Format(time.RFC3339)
 	var tags []dto.Tag
@@ -84,40 +127,99 @@ func (m *messenger) sendMessage(byUser string, toInbox, msg string,
 		ptags = &tags
 	}
 	id := m.repo.GetNextId()
-	err := m.sendToInbox(byUser, id, to, cc, toInbox, &inReplyTo, published, msg, ptags)
-	if err != nil {
-		m.logger.Errorf("Failed to send message to inbox %s", toInbox)
+	act := m.makeCreateNote(byUser, id, to, cc, &inReplyTo, published, msg, ptags)
+	if err := m.EnqueueActivity(byUser, toInbox, act, PriorityHigh); err != nil {
+		m.logger.Errorf("Failed to queue message to inbox %s: %v", toInbox, err)
 	}
 }
 
-func (m *messenger) EnqueueBroadcast(user string, statusId string, tootedAt time.Time, msg string) error {
+// Stores the activity in the outbox queue; it is delivered with the same retries as broadcast toots.
+func (m *messenger) EnqueueActivity(byUser, toInbox string, act *dto.ActivityOut, priority int) error {
+	return m.EnqueueActivityToMany(byUser, []string{toInbox}, act, priority)
+}
 
-	followers, err := m.repo.GetFollowersByUser(user, true)
+func (m *messenger) EnqueueActivityToMany(byUser string, toInboxes []string, act *dto.ActivityOut, priority int) error {
+
+	if len(toInboxes) == 0 {
+		return nil
+	}
+
+	deadInboxes, err := m.getDeadInboxes()
 	if err != nil {
 		return err
 	}
-
-	// Collect distinct shared inboxes
-	inboxes := make(map[string]struct{})
-	for _, f := range followers {
-		inboxName := f.SharedInbox
-		if inboxName == "" {
-			inboxName = f.UserInbox
+	actJson, err := json.Marshal(act)
+	if err != nil {
+		return err
+	}
+	actJson = m.addProof(byUser, actJson)
+	tootedAt := time.Now().UTC()
+
+	for _, toInbox := range toInboxes {
+		if _, isDead := deadInboxes[toInbox]; isDead {
+			m.logger.Infof("Not queuing %s activity for dead inbox %s", act.Type, toInbox)
+			m.metrics.DeliveryResult("skipped")
+			continue
 		}
-		if _, exists := inboxes[inboxName]; !exists {
-			inboxes[inboxName] = struct{}{}
+		host, _ := shared.GetHostName(toInbox)
+		if m.isSuspendedHost(host) {
+			m.logger.Infof("Not queuing %s activity for inbox on suspended domain %s", act.Type, toInbox)
+			m.metrics.DeliveryResult("skipped")
+			continue
 		}
+		err = m.repo.AddTootQueueItem(&dal.TootQueueItem{
+			SendingUser: byUser,
+			ToInbox:     toInbox,
+			ToHost:      host,
+			Priority:    priority,
+			TootedAt:    tootedAt,
+			Activity:    string(actJson),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		m.newTootsInQueue <- struct{}{}
+	}()
+
+	return nil
+}
+
+func (m *messenger) EnqueueBroadcast(user string, statusId string, tootedAt time.Time, msg string) error {
+
+	followers, err := m.repo.GetFollowersByUser(user, true)
+	if err != nil {
+		return err
 	}
 
+	inboxes := getDistinctInboxes(followers)
 	if len(inboxes) == 0 {
 		return nil
 	}
 
-	// Create a queue item for each inbox
-	for inboxUrl := range inboxes {
+	deadInboxes, err := m.getDeadInboxes()
+	if err != nil {
+		return err
+	}
+
+	// Create a queue item for each inbox, except those that have been unreachable for long or are suspended
+	for _, inboxUrl := range inboxes {
+		if _, isDead := deadInboxes[inboxUrl]; isDead {
+			m.metrics.DeliveryResult("skipped")
+			continue
+		}
+		host, _ := shared.GetHostName(inboxUrl)
+		if m.isSuspendedHost(host) {
+			m.metrics.DeliveryResult("skipped")
+			continue
+		}
 		err = m.repo.AddTootQueueItem(&dal.TootQueueItem{
 			SendingUser: user,
 			ToInbox:     inboxUrl,
+			ToHost:      host,
+			Priority:    PriorityBulk,
 			TootedAt:    tootedAt,
 			StatusId:    statusId,
 			Content:     msg,
@@ -134,38 +236,265 @@ func (m *messenger) EnqueueBroadcast(user string, statusId string, tootedAt time
 	return nil
 }
 
+// Collects distinct inboxes of followers, preferring shared inboxes where known
+func getDistinctInboxes(followers []*dal.FollowerInfo) []string {
+	var res []string
+	seen := make(map[string]struct{})
+	for _, f := range followers {
+		inboxName := f.SharedInbox
+		if inboxName == "" {
+			inboxName = f.UserInbox
+		}
+		if _, exists := seen[inboxName]; !exists {
+			seen[inboxName] = struct{}{}
+			res = append(res, inboxName)
+		}
+	}
+	return res
+}
+
+func (m *messenger) isSuspendedHost(host string) bool {
+	blocked, err := m.dblocks.IsBlocked(host)
+	if err != nil {
+		m.logger.Errorf("Failed to check domain block list: %v", err)
+		return false
+	}
+	return blocked
+}
+
+func orDefault(val, defaultVal int) int {
+	if val <= 0 {
+		return defaultVal
+	}
+	return val
+}
+
+// Returns the wait before the next attempt after the given number of failed attempts
+func (m *messenger) getRetryDelay(attempts int) time.Duration {
+	delay := m.retryBase
+	for i := 1; i < attempts && delay < m.retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, m.retryMax)
+}
+
+func (m *messenger) getDeadInboxes() (map[string]struct{}, error) {
+	inboxes, err := m.repo.GetDeadInboxes(time.Now().UTC().Add(-m.deadInboxAge))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]struct{}, len(inboxes))
+	for _, inbox := range inboxes {
+		res[inbox] = struct{}{}
+	}
+	return res, nil
+}
+
+// Called when we hear from a host: if we had given up on its inboxes, deliveries resume.
+// This runs for every inbox POST, so the DB is only written if the host is known to be failing.
+func (m *messenger) ResumeHost(host string) {
+
+	m.muFailingHosts.Lock()
+	if m.failingHosts == nil {
+		inboxes, err := m.repo.GetFailingInboxes()
+		if err != nil {
+			m.muFailingHosts.Unlock()
+			m.logger.Errorf("Failed to get failing inboxes: %v", err)
+			return
+		}
+		m.setFailingHosts(inboxes)
+	}
+	_, isFailing := m.failingHosts[host]
+	delete(m.failingHosts, host)
+	m.muFailingHosts.Unlock()
+	if !isFailing {
+		return
+	}
+
+	count, err := m.repo.ResetInboxFailuresForHost(host)
+	if err != nil {
+		m.logger.Errorf("Failed to reset inbox failures for host %s: %v", host, err)
+		return
+	}
+	if count > 0 {
+		m.logger.Infof("Host %s is back; cleared failure streak of %d inbox(es)", host, count)
+	}
+}
+
+func (m *messenger) GetQueueSummary() (*dto.DeliveryQueue, error) {
+	summary, err := m.repo.GetTootQueueSummary(time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	return &dto.DeliveryQueue{
+		Total:    summary.Total,
+		Due:      summary.Due,
+		Retrying: summary.Retrying,
+	}, nil
+}
+
+func (m *messenger) GetFailingInboxes() ([]*dto.InboxHealth, error) {
+	inboxes, err := m.repo.GetFailingInboxes()
+	if err != nil {
+		return nil, err
+	}
+	deadBefore := time.Now().UTC().Add(-m.deadInboxAge)
+	res := make([]*dto.InboxHealth, 0, len(inboxes))
+	for _, ih := range inboxes {
+		res = append(res, &dto.InboxHealth{
+			Inbox:         ih.Inbox,
+			Host:          ih.Host,
+			LastSuccess:   ih.LastSuccess,
+			FirstFailure:  ih.FirstFailure,
+			FailureStreak: ih.FailureStreak,
+			LastError:     ih.LastError,
+			Dead:          ih.FirstFailure.Before(deadBefore),
+		})
+	}
+	return res, nil
+}
+
+// Must be called with muFailingHosts held
+func (m *messenger) setFailingHosts(inboxes []*dal.InboxHealth) {
+	m.failingHosts = make(map[string]struct{}, len(inboxes))
+	for _, ih := range inboxes {
+		m.failingHosts[ih.Host] = struct{}{}
+	}
+}
+
+func (m *messenger) markHostFailing(host string) {
+	m.muFailingHosts.Lock()
+	defer m.muFailingHosts.Unlock()
+	if m.failingHosts != nil {
+		m.failingHosts[host] = struct{}{}
+	}
+}
+
+// Also refreshes the set of failing hosts, which the DB has the final word on
+func (m *messenger) updateInboxHealthMetrics() {
+	inboxes, err := m.repo.GetFailingInboxes()
+	if err != nil {
+		m.logger.Errorf("Failed to get failing inboxes: %v", err)
+		return
+	}
+	m.muFailingHosts.Lock()
+	m.setFailingHosts(inboxes)
+	m.muFailingHosts.Unlock()
+	deadBefore := time.Now().UTC().Add(-m.deadInboxAge)
+	dead := 0
+	for _, ih := range inboxes {
+		if ih.FirstFailure.Before(deadBefore) {
+			dead += 1
+		}
+	}
+	m.metrics.FailingInboxes(len(inboxes), dead)
+}
+
 func (m *messenger) tootQueueLoop() {
 
-	tootSent := make(chan int)
+	tootSent := make(chan tootResult)
+	lastHealthMetric := time.Time{}
 
 	sendToots := func() {
-		if len(m.tqProgress) >= maxParallelSends {
+		freeSlots := m.maxParallel - len(m.tqProgress)
+		if freeSlots <= 0 {
 			return
 		}
-		maxId := -1
+		skipIds := make([]int, 0, len(m.tqProgress))
 		for id := range m.tqProgress {
-			maxId = max(maxId, id)
+			skipIds = append(skipIds, id)
+		}
+		skipHosts := make([]string, 0)
+		for host, count := range m.hostsInFlight {
+			if count >= m.maxPerHost {
+				skipHosts = append(skipHosts, host)
+			}
 		}
 		var err error
 		var items []*dal.TootQueueItem
 		var qlen int
-		items, qlen, err = m.repo.GetTootQueueItems(maxId, maxParallelSends-len(m.tqProgress))
+		now := time.Now().UTC()
+		// Hosts with some items already in flight may return more than what they have room for;
+		// ask for more than the free slots so we can fill those from other hosts
+		items, qlen, err = m.repo.GetTootQueueItems(now, skipIds, skipHosts, m.maxPerHost, freeSlots*m.maxPerHost)
 		if err != nil {
 			m.logger.Errorf("Failed to get toot queue items: %v", err)
 			return
 		}
 		m.metrics.TootQueueLength(qlen)
+		if len(items) == 0 {
+			return
+		}
+		deadInboxes, err := m.getDeadInboxes()
+		if err != nil {
+			m.logger.Errorf("Failed to get dead inboxes: %v", err)
+			return
+		}
 		for _, item := range items {
-			m.tqProgress[item.Id] = struct{}{}
+			if len(m.tqProgress) >= m.maxParallel {
+				break
+			}
+			if m.hostsInFlight[item.ToHost] >= m.maxPerHost {
+				continue
+			}
+			if _, isDead := deadInboxes[item.ToInbox]; isDead {
+				m.logger.Infof("Dropping queued toot %d for dead inbox %s", item.Id, item.ToInbox)
+				if err := m.repo.DeleteTootQueueItem(item.Id); err != nil {
+					m.logger.Errorf("Failed to remove toot from queue: %d: %v", item.Id, err)
+				}
+				m.metrics.DeliveryResult("skipped")
+				continue
+			}
+			m.tqProgress[item.Id] = item.ToHost
+			m.hostsInFlight[item.ToHost] += 1
 			go m.sendQueuedToot(item, tootSent)
 		}
 	}
 
-	removeSentToot := func(id int) {
-		if err := m.repo.DeleteTootQueueItem(id); err != nil {
-			m.logger.Errorf("Failed to remove sent toot from queue: %d: %v", id, err)
+	handleResult := func(res tootResult) {
+		item := res.item
+		defer func() {
+			delete(m.tqProgress, item.Id)
+			if m.hostsInFlight[item.ToHost] -= 1; m.hostsInFlight[item.ToHost] <= 0 {
+				delete(m.hostsInFlight, item.ToHost)
+			}
+		}()
+		now := time.Now().UTC()
+		host := item.ToHost
+		if host == "" {
+			host, _ = shared.GetHostName(item.ToInbox)
 		}
-		delete(m.tqProgress, id)
+
+		if res.err == nil {
+			if err := m.repo.DeleteTootQueueItem(item.Id); err != nil {
+				m.logger.Errorf("Failed to remove sent toot from queue: %d: %v", item.Id, err)
+			}
+			if err := m.repo.RecordInboxSuccess(item.ToInbox, host, now); err != nil {
+				m.logger.Errorf("Failed to record delivery success for %s: %v", item.ToInbox, err)
+			}
+			m.metrics.DeliveryResult("delivered")
+			return
+		}
+
+		errStr := res.err.Error()
+		if err := m.repo.RecordInboxFailure(item.ToInbox, host, now, errStr); err != nil {
+			m.logger.Errorf("Failed to record delivery failure for %s: %v", item.ToInbox, err)
+		}
+		m.markHostFailing(host)
+		attempts := item.Attempts + 1
+		if attempts >= m.maxAttempts {
+			m.logger.Warnf("Giving up on queued toot %d to %s after %d attempts", item.Id, item.ToInbox, attempts)
+			if err := m.repo.DeleteTootQueueItem(item.Id); err != nil {
+				m.logger.Errorf("Failed to remove toot from queue: %d: %v", item.Id, err)
+			}
+			m.metrics.DeliveryResult("dropped")
+			return
+		}
+		nextAttemptAt := now.Add(m.getRetryDelay(attempts))
+		if err := m.repo.RescheduleTootQueueItem(item.Id, attempts, nextAttemptAt, errStr); err != nil {
+			m.logger.Errorf("Failed to reschedule queued toot: %d: %v", item.Id, err)
+		}
+		m.metrics.DeliveryResult("retry")
 	}
 
 	for {
@@ -175,10 +504,14 @@ func (m *messenger) tootQueueLoop() {
 			sendToots()
 		case <-time.After(tootLoopIdleWakeSec * time.Second):
 			m.logger.Debug("Toot queue idle loop")
+			if time.Since(lastHealthMetric) > inboxHealthMetricSec*time.Second {
+				m.updateInboxHealthMetrics()
+				lastHealthMetric = time.Now()
+			}
 			sendToots()
-		case id := <-tootSent:
-			m.logger.Debugf("Toot sent: %d", id)
-			removeSentToot(id)
+		case res := <-tootSent:
+			m.logger.Debugf("Toot sent: %d; error: %v", res.item.Id, res.err)
+			handleResult(res)
 			sendToots()
 		}
 	}
@@ -198,44 +531,73 @@ func (m *messenger) getIdVal(statusIdUrl string) uint64 {
 	return uint64(idVal)
 }
 
-func (m *messenger) sendQueuedToot(item *dal.TootQueueItem, tootSent chan int) {
+func (m *messenger) sendQueuedToot(item *dal.TootQueueItem, tootSent chan tootResult) {
 
-	var err error
-	idb := shared.IdBuilder{m.cfg.Host}
+	m.logger.Infof("Sending to inbox: %s", item.ToInbox)
+
+	privKey, err := m.keyStore.GetPrivKey(item.SendingUser)
+	if err != nil {
+		tootSent <- tootResult{item, err}
+		return
+	}
+
+	// Activity stored as-is
+	if item.Activity != "" {
+		err = m.sender.SendJson(privKey, item.SendingUser, item.ToInbox, []byte(item.Activity))
+		if err != nil {
+			m.logger.Errorf("Failed to send queued activity: %v", err)
+		}
+		tootSent <- tootResult{item, err}
+		return
+	}
+
+	// Feed post toot: we build the Create Note now
 	to := []string{shared.ActivityPublic}
-	userFollowers := idb.UserFollowers(item.SendingUser)
+	userFollowers := m.idb.UserFollowers(item.SendingUser)
 
 	// This should never fail, but if it does, we just make up a new ID
 	idVal := m.getIdVal(item.StatusId)
 
-	err = m.sendToInbox(
+	act := m.makeCreateNote(
 		item.SendingUser,
 		idVal,
 		to,
 		[]string{userFollowers},
-		item.ToInbox,
 		nil,
 		item.TootedAt.UTC().Format(time.RFC3339),
 		item.Content,
 		nil)
+	actJson, _ := json.Marshal(act)
+	actJson = m.addProof(item.SendingUser, actJson)
+	err = m.sender.SendJson(privKey, item.SendingUser, item.ToInbox, actJson)
 	if err != nil {
 		m.logger.Errorf("Failed to send queued toot: %v", err)
+	} else {
+		m.metrics.FeedTootSent()
 	}
 
-	m.metrics.FeedTootSent()
-
-	tootSent <- item.Id
+	tootSent <- tootResult{item, err}
 }
 
-func (m *messenger) sendToInbox(byUser string, idVal uint64, to, cc []string, toInbox string,
-	inReplyTo *string, published, message string, tag *[]dto.Tag) error {
+// Attaches an eddsa-jcs-2022 integrity proof to the activity. Proofs are optional for recipients,
+// so if signing fails, we log it and send the activity without one.
+func (m *messenger) addProof(byUser string, actJson []byte) []byte {
 
-	m.logger.Infof("Sending to inbox: %s", toInbox)
-
-	privKey, err := m.keyStore.GetPrivKey(byUser)
+	edKey, err := m.keyStore.GetEdPrivKey(byUser)
 	if err != nil {
-		return err
+		m.logger.Errorf("Failed to get Ed25519 key of %s: %v", byUser, err)
+		return actJson
 	}
+	signed, err := shared.AddEddsaJcsProof(actJson, edKey, m.idb.UserEdKeyId(byUser), time.Now())
+	if err != nil {
+		m.logger.Errorf("Failed to add integrity proof to activity of %s: %v", byUser, err)
+		return actJson
+	}
+	return signed
+}
+
+func (m *messenger) makeCreateNote(byUser string, idVal uint64, to, cc []string,
+	inReplyTo *string, published, message string, tag *[]dto.Tag) *dto.ActivityOut {
 
 	note := &dto.Note{
 		Id:           m.idb.UserStatus(byUser, idVal),
@@ -249,7 +611,7 @@ func (m *messenger) sendToInbox(byUser string, idVal uint64, to, cc []string, to
 		Cc:           cc,
 		Tag:          tag,
 	}
-	act := &dto.ActivityOut{
+	return &dto.ActivityOut{
 		Context: "https://www.w3.org/ns/activitystreams",
 		Id:      m.idb.UserStatusActivity(byUser, idVal),
 		Type:    "Create",
@@ -258,8 +620,4 @@ func (m *messenger) sendToInbox(byUser string, idVal uint64, to, cc []string, to
 		Cc:      &cc,
 		Object:  note,
 	}
-
-	m.sender.Send(privKey, byUser, toInbox, act)
-
-	return nil
 }
@@ -22,8 +22,13 @@ type IMetrics interface {
 	ServiceStarted()
 	TotalFollowers(count int)
 	TootQueueLength(length int)
+	DeliveryResult(label string)
+	FailingInboxes(failing, dead int)
 	CheckableFeedCount(count int)
 	DbFileSize(size int64)
+	OpenReports(count int)
+	InboundQueueLength(length int)
+	InboundProcessed(label string, latency time.Duration)
 }
 
 type IRequestObserver interface {
@@ -45,8 +50,14 @@ type metrics struct {
 	totalFollowers     prometheus.Gauge
 	totalPosts         prometheus.Gauge
 	tootQueueLength    prometheus.Gauge
+	deliveryResults    *prometheus.CounterVec
+	failingInboxes     prometheus.Gauge
+	deadInboxes        prometheus.Gauge
 	checkableFeedCount prometheus.Gauge
 	dbFileSize         prometheus.Gauge
+	openReports        prometheus.Gauge
+	inboundQueueLength prometheus.Gauge
+	inboundLatency     *prometheus.HistogramVec
 }
 
 func NewMetrics(cfg *shared.Config) IMetrics {
@@ -132,6 +143,24 @@ func NewMetrics(cfg *shared.Config) IMetrics {
 	})
 	_ = prometheus.Register(res.tootQueueLength)
 
+	res.deliveryResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "delivery_results",
+		Help: "Outcome of queued deliveries: delivered, retry, dropped, skipped",
+	}, []string{"label"})
+	_ = prometheus.Register(res.deliveryResults)
+
+	res.failingInboxes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "failing_inbox_count",
+		Help: "Inboxes whose last delivery failed",
+	})
+	_ = prometheus.Register(res.failingInboxes)
+
+	res.deadInboxes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dead_inbox_count",
+		Help: "Inboxes failing for so long that we stopped delivering to them",
+	})
+	_ = prometheus.Register(res.deadInboxes)
+
 	res.checkableFeedCount = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "checkable_feed_count",
 		Help: "Number of feeds waiting to be checked",
@@ -144,6 +173,25 @@ func NewMetrics(cfg *shared.Config) IMetrics {
 	})
 	_ = prometheus.Register(res.dbFileSize)
 
+	res.openReports = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "open_report_count",
+		Help: "Reports (Flag activities) waiting to be resolved",
+	})
+	_ = prometheus.Register(res.openReports)
+
+	res.inboundQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "inbound_queue_length",
+		Help: "Received activities waiting to be processed",
+	})
+	_ = prometheus.Register(res.inboundQueueLength)
+
+	res.inboundLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "inbound_latency",
+		Help:    "Seconds from receiving an activity until it is processed, by outcome",
+		Buckets: []float64{0.1, 0.5, 1, 5, 15, 60, 300, 1800, 7200},
+	}, []string{"label"})
+	_ = prometheus.Register(res.inboundLatency)
+
 	return &res
 }
 
@@ -183,6 +231,15 @@ func (m *metrics) TootQueueLength(length int) {
 	m.tootQueueLength.Set(float64(length))
 }
 
+func (m *metrics) DeliveryResult(label string) {
+	m.deliveryResults.WithLabelValues(label).Add(1)
+}
+
+func (m *metrics) FailingInboxes(failing, dead int) {
+	m.failingInboxes.Set(float64(failing))
+	m.deadInboxes.Set(float64(dead))
+}
+
 func (m *metrics) FeedUpdated() {
 	m.feedsUpdated.Add(1)
 }
@@ -219,3 +276,15 @@ func (m *metrics) CheckableFeedCount(count int) {
 func (m *metrics) DbFileSize(size int64) {
 	m.dbFileSize.Set(float64(size))
 }
+
+func (m *metrics) OpenReports(count int) {
+	m.openReports.Set(float64(count))
+}
+
+func (m *metrics) InboundQueueLength(length int) {
+	m.inboundQueueLength.Set(float64(length))
+}
+
+func (m *metrics) InboundProcessed(label string, latency time.Duration) {
+	m.inboundLatency.WithLabelValues(label).Observe(latency.Seconds())
+}
@@ -0,0 +1,237 @@
+package logic
+
+import (
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/shared"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_relays.go -package mocks rss_parrot/logic IRelays
+
+type IRelays interface {
+	// Follows relays that are in the config but have not accepted us yet; unfollows those no longer in the config.
+	// Runs shortly after startup.
+	SyncSubscriptions()
+	// Processes a relay's Accept or Reject of our Follow. Returns false if the activity is not from a relay.
+	HandleResponse(senderInfo *dto.UserInfo, act *dto.ActivityInBase) (isRelay bool, err error)
+	// Has the birb announce a newly created account to the relays
+	AnnounceAccount(user string)
+	// Has the birb announce a toot to the relays, if the account has opted in
+	AnnounceToot(user, statusId string)
+}
+
+const relaySubscribeDelaySec = 30
+
+type relays struct {
+	cfg       *shared.Config
+	logger    shared.ILogger
+	repo      dal.IRepo
+	messenger IMessenger
+	idb       shared.IdBuilder
+}
+
+func NewRelays(
+	cfg *shared.Config,
+	logger shared.ILogger,
+	repo dal.IRepo,
+	messenger IMessenger,
+) IRelays {
+	res := relays{
+		cfg:       cfg,
+		logger:    logger,
+		repo:      repo,
+		messenger: messenger,
+		idb:       shared.IdBuilder{Host: cfg.Host},
+	}
+	go func() {
+		// Give the rest of the app time to get going
+		time.Sleep(relaySubscribeDelaySec * time.Second)
+		res.SyncSubscriptions()
+	}()
+	return &res
+}
+
+func (rl *relays) getConfigured(inbox string) *shared.Relay {
+	for i := range rl.cfg.Relays {
+		if rl.cfg.Relays[i].Inbox == inbox {
+			return &rl.cfg.Relays[i]
+		}
+	}
+	return nil
+}
+
+// Mastodon-style relays expect us to follow the public collection; LitePub relays, their own actor
+func (rl *relays) makeFollow(relay *shared.Relay) *dto.ActivityOut {
+	object := shared.ActivityPublic
+	if relay.Actor != "" {
+		object = relay.Actor
+	}
+	return &dto.ActivityOut{
+		Context: "https://www.w3.org/ns/activitystreams",
+		Id:      rl.idb.ActivityUrl(rl.repo.GetNextId()),
+		Type:    "Follow",
+		Actor:   rl.idb.UserUrl(rl.cfg.Birb.User),
+		Object:  object,
+	}
+}
+
+func (rl *relays) SyncSubscriptions() {
+
+	known, err := rl.repo.GetRelays()
+	if err != nil {
+		rl.logger.Errorf("Failed to get relays: %v", err)
+		return
+	}
+
+	knownByInbox := make(map[string]*dal.Relay)
+	for _, r := range known {
+		knownByInbox[r.Inbox] = r
+		if rl.getConfigured(r.Inbox) != nil {
+			continue
+		}
+		rl.logger.Infof("Unsubscribing from relay no longer configured: %s", r.Inbox)
+		// Relays stored before we kept the Follow's object were all Mastodon-style ones
+		followObject := r.FollowObject
+		if followObject == "" {
+			followObject = shared.ActivityPublic
+		}
+		undo := &dto.ActivityOut{
+			Context: "https://www.w3.org/ns/activitystreams",
+			Id:      rl.idb.ActivityUrl(rl.repo.GetNextId()),
+			Type:    "Undo",
+			Actor:   rl.idb.UserUrl(rl.cfg.Birb.User),
+			Object: map[string]any{
+				"id":     r.FollowId,
+				"type":   "Follow",
+				"actor":  rl.idb.UserUrl(rl.cfg.Birb.User),
+				"object": followObject,
+			},
+		}
+		if err = rl.messenger.EnqueueActivity(rl.cfg.Birb.User, r.Inbox, undo, PriorityHigh); err != nil {
+			rl.logger.Errorf("Failed to queue Undo for relay %s: %v", r.Inbox, err)
+			continue
+		}
+		if err = rl.repo.DeleteRelay(r.Inbox); err != nil {
+			rl.logger.Errorf("Failed to delete relay %s: %v", r.Inbox, err)
+		}
+	}
+
+	for i := range rl.cfg.Relays {
+		relay := &rl.cfg.Relays[i]
+		if r, ok := knownByInbox[relay.Inbox]; ok && r.Status == dal.RelayAccepted {
+			continue
+		}
+		rl.logger.Infof("Subscribing to relay: %s", relay.Inbox)
+		follow := rl.makeFollow(relay)
+		if err = rl.messenger.EnqueueActivity(rl.cfg.Birb.User, relay.Inbox, follow, PriorityHigh); err != nil {
+			rl.logger.Errorf("Failed to queue Follow for relay %s: %v", relay.Inbox, err)
+			continue
+		}
+		err = rl.repo.SaveRelay(&dal.Relay{
+			Inbox:        relay.Inbox,
+			FollowId:     follow.Id,
+			FollowObject: follow.Object.(string),
+			Status:       dal.RelayPending,
+			UpdatedAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			rl.logger.Errorf("Failed to save relay %s: %v", relay.Inbox, err)
+		}
+	}
+}
+
+func (rl *relays) HandleResponse(senderInfo *dto.UserInfo, act *dto.ActivityInBase) (bool, error) {
+
+	known, err := rl.repo.GetRelays()
+	if err != nil {
+		return false, err
+	}
+
+	// Accept or Reject refers to our Follow by ID, or embeds it
+	objectId, _ := act.Object.(string)
+	if objMap, ok := act.Object.(map[string]any); ok {
+		objectId, _ = objMap["id"].(string)
+	}
+
+	// The answer must come from the relay's host. Some relays don't echo our Follow's ID; then we only
+	// believe the relay's own actor, which we only know for relays configured with one.
+	senderHost, _ := shared.GetHostName(senderInfo.Id)
+	var relay *dal.Relay
+	for _, r := range known {
+		if inboxHost, _ := shared.GetHostName(r.Inbox); !strings.EqualFold(inboxHost, senderHost) {
+			continue
+		}
+		if objectId != "" && r.FollowId == objectId {
+			relay = r
+			break
+		}
+		if cfgRelay := rl.getConfigured(r.Inbox); objectId == "" && cfgRelay != nil && cfgRelay.Actor == senderInfo.Id {
+			relay = r
+			break
+		}
+	}
+	if relay == nil {
+		return false, nil
+	}
+
+	if act.Type == "Accept" {
+		rl.logger.Infof("Relay accepted our subscription: %s", relay.Inbox)
+		relay.Status = dal.RelayAccepted
+	} else {
+		rl.logger.Warnf("Relay rejected our subscription: %s", relay.Inbox)
+		relay.Status = dal.RelayRejected
+	}
+	relay.UpdatedAt = time.Now().UTC()
+	return true, rl.repo.SaveRelay(relay)
+}
+
+func (rl *relays) announce(objectUrl string) {
+
+	known, err := rl.repo.GetRelays()
+	if err != nil {
+		rl.logger.Errorf("Failed to get relays: %v", err)
+		return
+	}
+	for _, r := range known {
+		if r.Status != dal.RelayAccepted || rl.getConfigured(r.Inbox) == nil {
+			continue
+		}
+		to := []string{shared.ActivityPublic}
+		cc := []string{rl.idb.UserFollowers(rl.cfg.Birb.User)}
+		act := &dto.ActivityOut{
+			Context: "https://www.w3.org/ns/activitystreams",
+			Id:      rl.idb.ActivityUrl(rl.repo.GetNextId()),
+			Type:    "Announce",
+			Actor:   rl.idb.UserUrl(rl.cfg.Birb.User),
+			To:      &to,
+			Cc:      &cc,
+			Object:  objectUrl,
+		}
+		if err = rl.messenger.EnqueueActivity(rl.cfg.Birb.User, r.Inbox, act, PriorityBulk); err != nil {
+			rl.logger.Errorf("Failed to queue Announce for relay %s: %v", r.Inbox, err)
+		}
+	}
+}
+
+func (rl *relays) AnnounceAccount(user string) {
+	if len(rl.cfg.Relays) == 0 {
+		return
+	}
+	rl.announce(rl.idb.UserUrl(user))
+}
+
+func (rl *relays) AnnounceToot(user, statusId string) {
+	if len(rl.cfg.Relays) == 0 {
+		return
+	}
+	acct, err := rl.repo.GetAccount(user)
+	if err != nil {
+		rl.logger.Errorf("Failed to get account %s: %v", user, err)
+		return
+	}
+	if acct != nil && acct.RelayToots {
+		rl.announce(statusId)
+	}
+}
@@ -0,0 +1,181 @@
+package logic
+
+import (
+	"fmt"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/shared"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_reports.go -package mocks rss_parrot/logic IReports
+
+// Actions that resolve a report
+const (
+	ReportDismiss        = "dismiss"
+	ReportSuspendAccount = "suspend_account" // Account is deleted, followers get a Delete
+	ReportDeleteStatus   = "delete_status"   // Reported statuses are deleted, followers get a Delete of each
+	ReportBlockFeed      = "block_feed"      // Feed goes on the block list and the account is deleted
+)
+
+func IsReportAction(action string) bool {
+	switch action {
+	case ReportDismiss, ReportSuspendAccount, ReportDeleteStatus, ReportBlockFeed:
+		return true
+	}
+	return false
+}
+
+type IReports interface {
+	// Stores a report received in a Flag activity
+	AddReport(report *dal.Report) error
+	GetReports(onlyOpen bool) ([]*dal.Report, error)
+	// Takes the action on the reported account or statuses and closes the report. Returns false if no such report.
+	Resolve(id int, action string) (found bool, err error)
+}
+
+type reports struct {
+	cfg          *shared.Config
+	logger       shared.ILogger
+	repo         dal.IRepo
+	metrics      IMetrics
+	messenger    IMessenger
+	fdfol        IFeedFollower
+	blockedFeeds IBlockedFeeds
+	idb          shared.IdBuilder
+}
+
+func NewReports(
+	cfg *shared.Config,
+	logger shared.ILogger,
+	repo dal.IRepo,
+	metrics IMetrics,
+	messenger IMessenger,
+	fdfol IFeedFollower,
+	blockedFeeds IBlockedFeeds,
+) IReports {
+	res := reports{
+		cfg:          cfg,
+		logger:       logger,
+		repo:         repo,
+		metrics:      metrics,
+		messenger:    messenger,
+		fdfol:        fdfol,
+		blockedFeeds: blockedFeeds,
+		idb:          shared.IdBuilder{Host: cfg.Host},
+	}
+	res.updateOpenReportsMetric()
+	return &res
+}
+
+func (rp *reports) updateOpenReportsMetric() {
+	count, err := rp.repo.GetOpenReportCount()
+	if err != nil {
+		rp.logger.Errorf("Failed to get open report count: %v", err)
+		return
+	}
+	rp.metrics.OpenReports(count)
+}
+
+func (rp *reports) AddReport(report *dal.Report) error {
+
+	isNew, err := rp.repo.AddReport(report)
+	if err != nil {
+		return err
+	}
+	if isNew {
+		rp.logger.Warnf("Received report about %s from %s", report.AccountHandle, report.Reporter)
+		rp.updateOpenReportsMetric()
+	}
+	return nil
+}
+
+func (rp *reports) GetReports(onlyOpen bool) ([]*dal.Report, error) {
+	return rp.repo.GetReports(onlyOpen)
+}
+
+func (rp *reports) Resolve(id int, action string) (bool, error) {
+
+	report, err := rp.repo.GetReport(id)
+	if err != nil || report == nil {
+		return false, err
+	}
+
+	switch action {
+	case ReportDismiss:
+	case ReportSuspendAccount, ReportBlockFeed:
+		err = rp.removeAccount(report, action == ReportBlockFeed)
+	case ReportDeleteStatus:
+		err = rp.deleteStatuses(report)
+	default:
+		err = fmt.Errorf("unknown action: %s", action)
+	}
+	if err != nil {
+		return true, err
+	}
+
+	rp.logger.Infof("Resolving report %d: %s", id, action)
+	if err = rp.repo.ResolveReport(id, action, time.Now().UTC()); err != nil {
+		return true, err
+	}
+	rp.updateOpenReportsMetric()
+	return true, nil
+}
+
+func (rp *reports) removeAccount(report *dal.Report, blockFeed bool) error {
+
+	handle := report.AccountHandle
+	acct, err := rp.repo.GetAccount(handle)
+	if err != nil {
+		return err
+	}
+	// Account may be gone already, e.g., because of another report about it
+	if acct == nil {
+		return nil
+	}
+	if blockFeed {
+		if acct.FeedUrl == "" {
+			return fmt.Errorf("account has no feed to block: %s", handle)
+		}
+		reason := fmt.Sprintf("Report %d", report.Id)
+		if _, err = rp.blockedFeeds.AddBlock(dal.FeedBlockUrl, acct.FeedUrl, reason); err != nil {
+			return err
+		}
+	}
+	return rp.fdfol.PurgeAccount(acct)
+}
+
+// Deletes the toots and tells the account's followers to delete them too
+func (rp *reports) deleteStatuses(report *dal.Report) error {
+
+	if len(report.StatusIds) == 0 {
+		return fmt.Errorf("report does not name any statuses")
+	}
+	followers, err := rp.repo.GetFollowersByUser(report.AccountHandle, true)
+	if err != nil {
+		return err
+	}
+	inboxes := getDistinctInboxes(followers)
+	userUrl := rp.idb.UserUrl(report.AccountHandle)
+
+	for _, statusId := range report.StatusIds {
+		if err = rp.repo.DeleteToot(statusId); err != nil {
+			return err
+		}
+		actDelete := dto.ActivityOut{
+			Context: "https://www.w3.org/ns/activitystreams",
+			Id:      statusId + "#delete",
+			Type:    "Delete",
+			Actor:   userUrl,
+			To:      &[]string{shared.ActivityPublic},
+			Object: dto.Tombstone{
+				Id:   statusId,
+				Type: "Tombstone",
+			},
+		}
+		if err = rp.messenger.EnqueueActivityToMany(report.AccountHandle, inboxes, &actDelete, PriorityHigh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"strings"
+	"sync"
+)
+
+// Remembers which peers sign with RFC 9421 HTTP message signatures. Whoever sends us such a signature can
+// surely verify one too, so we use that scheme when delivering to them.
+type ISigSchemes interface {
+	MarkRfc9421(host string)
+	ClearRfc9421(host string)
+	PrefersRfc9421(host string) bool
+}
+
+type sigSchemes struct {
+	mu           sync.RWMutex
+	rfc9421Hosts map[string]struct{}
+}
+
+func NewSigSchemes() ISigSchemes {
+	return &sigSchemes{rfc9421Hosts: map[string]struct{}{}}
+}
+
+func (ss *sigSchemes) MarkRfc9421(host string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.rfc9421Hosts[strings.ToLower(host)] = struct{}{}
+}
+
+func (ss *sigSchemes) ClearRfc9421(host string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.rfc9421Hosts, strings.ToLower(host))
+}
+
+func (ss *sigSchemes) PrefersRfc9421(host string) bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	_, found := ss.rfc9421Hosts[strings.ToLower(host)]
+	return found
+}
@@ -2,6 +2,8 @@ package logic
 
 import (
 	"fmt"
+	"mime"
+	"path"
 	"rss_parrot/dal"
 	"rss_parrot/dto"
 	"rss_parrot/shared"
@@ -26,16 +28,20 @@ type IUserDirectory interface {
 	GetFollowingSummary(user string) *dto.OrderedListSummary
 	GetUserStatus(user, statusId string) (*dto.Note, error)
 	AcceptFollower(followActId, followerUserUrl, followerInbox, followedUser string) error
+	RejectFollower(followActId, followerUserUrl, followerInbox, followedUser string) error
+	BroadcastUpdate(user string) error
+	BroadcastDelete(user string) error
+	GetTombstone(user string) *dto.Tombstone
 }
 
 type userDirectory struct {
-	cfg      *shared.Config
-	logger   shared.ILogger
-	repo     dal.IRepo
-	idb      shared.IdBuilder
-	keyStore IKeyStore
-	sender   IActivitySender
-	txt      texts.ITexts
+	cfg       *shared.Config
+	logger    shared.ILogger
+	repo      dal.IRepo
+	idb       shared.IdBuilder
+	keyStore  IKeyStore
+	messenger IMessenger
+	txt       texts.ITexts
 }
 
 func NewUserDirectory(
@@ -43,17 +49,17 @@ func NewUserDirectory(
 	logger shared.ILogger,
 	repo dal.IRepo,
 	keyStore IKeyStore,
-	sender IActivitySender,
+	messenger IMessenger,
 	txt texts.ITexts,
 ) IUserDirectory {
 	return &userDirectory{
-		cfg:      cfg,
-		logger:   logger,
-		repo:     repo,
-		idb:      shared.IdBuilder{cfg.Host},
-		keyStore: keyStore,
-		sender:   sender,
-		txt:      txt}
+		cfg:       cfg,
+		logger:    logger,
+		repo:      repo,
+		idb:       shared.IdBuilder{cfg.Host},
+		keyStore:  keyStore,
+		messenger: messenger,
+		txt:       txt}
 }
 
 func (udir *userDirectory) GetWebfinger(user string) *dto.WebfingerResp {
@@ -82,8 +88,28 @@ func (udir *userDirectory) GetWebfinger(user string) *dto.WebfingerResp {
 				Type: "application/activity+json",
 				Href: udir.idb.UserUrl(user),
 			},
+			{
+				Rel:      "http://ostatus.org/schema/1.0/subscribe",
+				Template: udir.idb.InteractTemplate(),
+			},
 		},
 	}
+
+	avatarUrl := acct.ProfileImageUrl
+	if user == udir.cfg.Birb.User {
+		avatarUrl = udir.cfg.Birb.ProfilePic
+	}
+	if avatarUrl == "" {
+		avatarUrl = udir.cfg.FallbackProfilePic
+	}
+	if avatarUrl != "" {
+		resp.Links = append(resp.Links, dto.WebfingerLink{
+			Rel:  "http://webfinger.net/rel/avatar",
+			Type: mime.TypeByExtension(path.Ext(avatarUrl)),
+			Href: avatarUrl,
+		})
+	}
+
 	return &resp
 }
 func (udir *userDirectory) getWebsiteAttachment(url string) string {
@@ -122,7 +148,7 @@ func (udir *userDirectory) fillFeedUserInfo(ui *dto.UserInfo, acct *dal.Account)
 		"siteUrl":     udir.idb.SiteUrl(),
 		"description": acct.FeedSummary,
 	})
-	ui.ManuallyApproves = false
+	ui.ManuallyApproves = acct.ManuallyApproves
 	ui.PublicKey = dto.PublicKey{
 		Id:           udir.idb.UserKeyId(acct.Handle),
 		Owner:        ui.Id,
@@ -146,6 +172,16 @@ func (udir *userDirectory) fillFeedUserInfo(ui *dto.UserInfo, acct *dal.Account)
 	}
 }
 
+// Bundles are Group actors that boost their members' toots; their site URL is their own page here
+func (udir *userDirectory) fillBundleUserInfo(ui *dto.UserInfo, acct *dal.Account) {
+	udir.fillFeedUserInfo(ui, acct)
+	ui.Type = "Group"
+	ui.Summary = udir.txt.WithVals("bundle_bio.html", map[string]string{
+		"siteUrl":     udir.idb.SiteUrl(),
+		"description": acct.FeedSummary,
+	})
+}
+
 func (udir *userDirectory) GetUserInfo(user string) *dto.UserInfo {
 
 	user = strings.ToLower(user)
@@ -159,6 +195,7 @@ func (udir *userDirectory) GetUserInfo(user string) *dto.UserInfo {
 		Context: []string{
 			"https://www.w3.org/ns/activitystreams",
 			"https://w3id.org/security/v1",
+			shared.MultikeyContext,
 		},
 		Id:                userUrl,
 		Type:              "Service",
@@ -172,8 +209,23 @@ func (udir *userDirectory) GetUserInfo(user string) *dto.UserInfo {
 		Attachments:       []dto.Attachment{},
 	}
 
+	// Ed25519 key for object integrity proofs, next to the RSA key used for HTTP signatures
+	// Accounts get this key when they are created, or when they first sign an activity
+	if edPubKey, err := udir.keyStore.GetEdPubKey(user); err != nil {
+		udir.logger.Errorf("Failed to get Ed25519 key of %s: %v", user, err)
+	} else if edPubKey != "" {
+		resp.AssertionMethod = []dto.Multikey{{
+			Id:                 udir.idb.UserEdKeyId(user),
+			Type:               "Multikey",
+			Controller:         userUrl,
+			PublicKeyMultibase: edPubKey,
+		}}
+	}
+
 	if user == udir.cfg.Birb.User {
 		udir.fillBirbUserInfo(&resp)
+	} else if acct.IsBundle() {
+		udir.fillBundleUserInfo(&resp, acct)
 	} else {
 		udir.fillFeedUserInfo(&resp, acct)
 	}
@@ -287,12 +339,6 @@ func (udir *userDirectory) AcceptFollower(followActId, followerUserUrl, follower
 
 	udir.logger.Infof("Accepting follow %s", followerInbox)
 
-	privKey, err := udir.keyStore.GetPrivKey(followedUser)
-	if err != nil {
-		err = fmt.Errorf("failed to get private key for user %s: %v", followedUser, err)
-		return err
-	}
-
 	acceptId := udir.repo.GetNextId()
 
 	actAccept := dto.ActivityOut{
@@ -308,8 +354,10 @@ func (udir *userDirectory) AcceptFollower(followActId, followerUserUrl, follower
 		},
 	}
 
-	if err = udir.sender.Send(privKey, followedUser, followerInbox, &actAccept); err != nil {
-		err = fmt.Errorf("failed to send 'Accept' activity: %v", err)
+	// Once queued, the Accept will get there (or we give up on the inbox), so follower counts as approved
+	err := udir.messenger.EnqueueActivity(followedUser, followerInbox, &actAccept, PriorityHigh)
+	if err != nil {
+		err = fmt.Errorf("failed to queue 'Accept' activity: %v", err)
 		return err
 	}
 
@@ -320,3 +368,135 @@ func (udir *userDirectory) AcceptFollower(followActId, followerUserUrl, follower
 
 	return nil
 }
+
+// Sends a Reject of the follow request and keeps the follower on record as banned, so that a repeated
+// Follow is rejected too
+func (udir *userDirectory) RejectFollower(followActId, followerUserUrl, followerInbox, followedUser string) error {
+
+	udir.logger.Infof("Rejecting follow %s", followerInbox)
+
+	actReject := dto.ActivityOut{
+		Context: "https://www.w3.org/ns/activitystreams",
+		Id:      udir.idb.ActivityUrl(udir.repo.GetNextId()),
+		Type:    "Reject",
+		Actor:   udir.idb.UserUrl(followedUser),
+		Object: dto.ActivityOut{
+			Id:     followActId,
+			Type:   "Follow",
+			Actor:  followerUserUrl,
+			Object: udir.idb.UserUrl(followedUser),
+		},
+	}
+
+	err := udir.messenger.EnqueueActivity(followedUser, followerInbox, &actReject, PriorityHigh)
+	if err != nil {
+		err = fmt.Errorf("failed to queue 'Reject' activity: %v", err)
+		return err
+	}
+
+	if err = udir.repo.SetFollowerApproveStatus(followedUser, followerUserUrl, -1); err != nil {
+		err = fmt.Errorf("failed set follower approve status: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Sends an Update of the user's actor to all followers' inboxes, so remote servers refresh their cached profile
+func (udir *userDirectory) BroadcastUpdate(user string) error {
+
+	userInfo := udir.GetUserInfo(user)
+	if userInfo == nil {
+		return fmt.Errorf("user not found: %s", user)
+	}
+
+	followers, err := udir.repo.GetFollowersByUser(user, true)
+	if err != nil {
+		return err
+	}
+	inboxes := getDistinctInboxes(followers)
+	if len(inboxes) == 0 {
+		return nil
+	}
+
+	udir.logger.Infof("Broadcasting actor Update of %s to %d inboxes", user, len(inboxes))
+
+	actUpdate := dto.ActivityOut{
+		Context: userInfo.Context,
+		Id:      udir.idb.ActivityUrl(udir.repo.GetNextId()),
+		Type:    "Update",
+		Actor:   userInfo.Id,
+		To:      &[]string{shared.ActivityPublic},
+		Object:  userInfo,
+	}
+
+	return udir.messenger.EnqueueActivityToMany(user, inboxes, &actUpdate, PriorityBulk)
+}
+
+// Tells followers' servers that the user is going away: a Delete of the actor to every inbox, and a Reject
+// of each follower's original Follow, so that no follow relationship lingers on the remote side.
+// Must be called while the account still exists, because that's where we get the followers from.
+func (udir *userDirectory) BroadcastDelete(user string) error {
+
+	followers, err := udir.repo.GetFollowersByUser(user, false)
+	if err != nil {
+		return err
+	}
+	if len(followers) == 0 {
+		return nil
+	}
+
+	userUrl := udir.idb.UserUrl(user)
+	actDelete := dto.ActivityOut{
+		Context: "https://www.w3.org/ns/activitystreams",
+		Id:      udir.idb.ActivityUrl(udir.repo.GetNextId()),
+		Type:    "Delete",
+		Actor:   userUrl,
+		To:      &[]string{shared.ActivityPublic},
+		Object:  userUrl,
+	}
+	inboxes := getDistinctInboxes(followers)
+
+	udir.logger.Infof("Broadcasting actor Delete of %s to %d inboxes", user, len(inboxes))
+
+	// Queue is delivered after the account is gone; signing key is then taken from the tombstone
+	for _, flwr := range followers {
+		actReject := dto.ActivityOut{
+			Context: "https://www.w3.org/ns/activitystreams",
+			Id:      udir.idb.ActivityUrl(udir.repo.GetNextId()),
+			Type:    "Reject",
+			Actor:   userUrl,
+			Object: dto.ActivityOut{
+				Id:     flwr.RequestId,
+				Type:   "Follow",
+				Actor:  flwr.UserUrl,
+				Object: userUrl,
+			},
+		}
+		if err = udir.messenger.EnqueueActivity(user, flwr.UserInbox, &actReject, PriorityHigh); err != nil {
+			return err
+		}
+	}
+	return udir.messenger.EnqueueActivityToMany(user, inboxes, &actDelete, PriorityBulk)
+}
+
+// Returns a Tombstone if the user existed once but has been deleted; nil otherwise
+func (udir *userDirectory) GetTombstone(user string) *dto.Tombstone {
+
+	user = strings.ToLower(user)
+	tomb, err := udir.repo.GetAccountTombstone(user)
+	if err != nil {
+		udir.logger.Errorf("Failed to get tombstone for %s: %v", user, err)
+		return nil
+	}
+	if tomb == nil {
+		return nil
+	}
+	return &dto.Tombstone{
+		Context:    "https://www.w3.org/ns/activitystreams",
+		Id:         udir.idb.UserUrl(user),
+		Type:       "Tombstone",
+		FormerType: "Service",
+		Deleted:    tomb.DeletedAt.UTC().Format(time.RFC3339),
+	}
+}
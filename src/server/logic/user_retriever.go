@@ -8,27 +8,116 @@ import (
 	"github.com/go-fed/httpsig"
 	"io"
 	"net/http"
+	"net/url"
+	"rss_parrot/dal"
 	"rss_parrot/dto"
 	"rss_parrot/shared"
 	"strings"
 	"time"
 )
 
+//go:generate mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_user_retriever.go -package mocks rss_parrot/logic IUserRetriever
+
 type IUserRetriever interface {
+	// Fetches the actor from its server, and updates the actor cache
 	Retrieve(userUrl string) (info *dto.UserInfo, err error)
+	// Returns the cached actor if we have a fresh one; fetches it otherwise
+	RetrieveCached(userUrl string) (info *dto.UserInfo, fromCache bool, err error)
+	// Looks up user@host through the host's webfinger endpoint. Refuses hosts that resolve to non-public addresses.
+	RetrieveWebfinger(user, host string) (*dto.WebfingerResp, error)
 }
 
 const retrieveTimeoutSec = 10
+const defaultActorCacheHours = 24
 
 type userRetriever struct {
 	cfg       *shared.Config
+	logger    shared.ILogger
 	userAgent shared.IUserAgent
 	keyStore  IKeyStore
+	repo      dal.IRepo
 	idb       shared.IdBuilder
+	cacheTTL  time.Duration
+}
+
+func NewUserRetriever(
+	cfg *shared.Config,
+	logger shared.ILogger,
+	userAgent shared.IUserAgent,
+	keyStore IKeyStore,
+	repo dal.IRepo,
+) IUserRetriever {
+	cacheTTL := time.Duration(orDefault(cfg.ActorCacheHours, defaultActorCacheHours)) * time.Hour
+	return &userRetriever{cfg, logger, userAgent, keyStore, repo, shared.IdBuilder{Host: cfg.Host}, cacheTTL}
+}
+
+func (ur *userRetriever) RetrieveCached(userUrl string) (info *dto.UserInfo, fromCache bool, err error) {
+
+	var ca *dal.CachedActor
+	if ca, err = ur.repo.GetCachedActor(userUrl); err != nil {
+		ur.logger.Errorf("Failed to get cached actor %s: %v", userUrl, err)
+	}
+	if ca != nil && time.Since(ca.FetchedAt) < ur.cacheTTL {
+		info = &dto.UserInfo{
+			Id:                ca.UserUrl,
+			Type:              ca.ActorType,
+			PreferredUserName: ca.PreferredUserName,
+			Inbox:             ca.Inbox,
+			Followers:         ca.Followers,
+			Endpoints:         dto.UserEndpoints{SharedInbox: ca.SharedInbox},
+			PublicKey:         dto.PublicKey{Id: ca.KeyId, Owner: ca.KeyOwner, PublicKeyPem: ca.PublicKeyPem},
+		}
+		if ca.AssertionKeys != "" {
+			if err = json.Unmarshal([]byte(ca.AssertionKeys), &info.AssertionMethod); err != nil {
+				ur.logger.Errorf("Failed to parse cached keys of actor %s: %v", userUrl, err)
+			}
+		}
+		return info, true, nil
+	}
+
+	info, err = ur.Retrieve(userUrl)
+	return info, false, err
 }
 
-func NewUserRetriever(cfg *shared.Config, userAgent shared.IUserAgent, keyStore IKeyStore) IUserRetriever {
-	return &userRetriever{cfg, userAgent, keyStore, shared.IdBuilder{cfg.Host}}
+// Stores the freshly fetched actor. If their inboxes have changed, follower records are updated too.
+func (ur *userRetriever) updateCache(userUrl string, info *dto.UserInfo) {
+
+	if info.Inbox == "" || info.PublicKey.PublicKeyPem == "" {
+		return
+	}
+
+	prev, err := ur.repo.GetCachedActor(userUrl)
+	if err != nil {
+		ur.logger.Errorf("Failed to get cached actor %s: %v", userUrl, err)
+		return
+	}
+	ca := dal.CachedActor{
+		UserUrl:           userUrl,
+		FetchedAt:         time.Now().UTC(),
+		ActorType:         info.Type,
+		PreferredUserName: info.PreferredUserName,
+		Inbox:             info.Inbox,
+		SharedInbox:       info.Endpoints.SharedInbox,
+		Followers:         info.Followers,
+		KeyId:             info.PublicKey.Id,
+		KeyOwner:          info.PublicKey.Owner,
+		PublicKeyPem:      info.PublicKey.PublicKeyPem,
+	}
+	if len(info.AssertionMethod) != 0 {
+		keysJson, _ := json.Marshal(info.AssertionMethod)
+		ca.AssertionKeys = string(keysJson)
+	}
+	if err = ur.repo.SaveCachedActor(&ca); err != nil {
+		ur.logger.Errorf("Failed to save cached actor %s: %v", userUrl, err)
+		return
+	}
+
+	// Not in the cache yet: follower records may still predate the cache, so we update them anyway
+	if prev == nil || prev.Inbox != ca.Inbox || prev.SharedInbox != ca.SharedInbox {
+		if err = ur.repo.UpdateFollowerInboxes(userUrl, ca.Inbox, ca.SharedInbox); err != nil {
+			ur.logger.Errorf("Failed to update follower inboxes of %s: %v", userUrl, err)
+		}
+	}
 }
 
 func (ur *userRetriever) Retrieve(userUrl string) (info *dto.UserInfo, err error) {
@@ -99,5 +188,41 @@ func (ur *userRetriever) Retrieve(userUrl string) (info *dto.UserInfo, err error
 		return nil, err
 	}
 
+	ur.updateCache(userUrl, &obj)
+
 	return &obj, nil
 }
+
+func (ur *userRetriever) RetrieveWebfinger(user, host string) (*dto.WebfingerResp, error) {
+
+	resource := url.QueryEscape(fmt.Sprintf("acct:%s@%s", user, host))
+	wfUrl := fmt.Sprintf("https://%s/.well-known/webfinger?resource=%s", host, resource)
+
+	req, err := http.NewRequest("GET", wfUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	ur.userAgent.AddUserAgent(req)
+	req.Header.Set("Accept", "application/jrd+json, application/json")
+
+	// The host comes from a visitor of our website, so it must not lead to our own network
+	client := shared.NewPublicOnlyClient(time.Second * retrieveTimeoutSec)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webfinger lookup of %s@%s failed with status %v", user, host, resp.StatusCode)
+	}
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var res dto.WebfingerResp
+	if err = json.Unmarshal(bodyBytes, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
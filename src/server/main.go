@@ -46,19 +46,26 @@ func main() {
 			fx.Annotate(server.NewMux, fx.ParamTags(`group:"handler_group"`)),
 			logic.NewKeyStore,
 			logic.NewBlockedFeeds,
+			logic.NewDomainBlocks,
 			logic.NewMetrics,
 			logic.NewFeedFollower,
 			logic.NewUserDirectory,
 			logic.NewActivitySender,
 			logic.NewHttpSigChecker,
+			logic.NewSigSchemes,
 			logic.NewUserRetriever,
 			logic.NewMessenger,
 			logic.NewInbox,
+			logic.NewInboundQueue,
+			logic.NewRelays,
+			logic.NewBundles,
+			logic.NewReports,
 			logic.NewProfiler,
 			texts.NewTexts,
 			dal.NewRepo,
 			asHandlerGroupDef(server.NewApubHandlerGroup),
 			asHandlerGroupDef(server.NewApiHandlerGroup),
+			asHandlerGroupDef(server.NewInstanceHandlerGroup),
 			asHandlerGroupDef(server.NewWebHandlerGroup),
 			asHandlerGroupDef(server.NewMetricsHandlerGroup),
 		),
@@ -1,41 +1,70 @@
 package server
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"math"
 	"net/http"
+	"net/url"
+	"regexp"
 	"rss_parrot/dal"
 	"rss_parrot/dto"
 	"rss_parrot/logic"
 	"rss_parrot/shared"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // curl -X POST -H "X-API-KEY: 5QLbv8hrifgdXCEN" 'https://rss-parrot.zydeo.net/api/actions/vacuum'
 // curl -X POST -H "X-API-KEY: 5QLbv8hrifgdXCEN" 'https://rss-parrot.zydeo.net/api/actions/pprof'
 
 type apiHandlerGroup struct {
-	cfg    *shared.Config
-	logger shared.ILogger
-	fdfol  logic.IFeedFollower
-	repo   dal.IRepo
-	prof   logic.IProfiler
+	cfg            *shared.Config
+	logger         shared.ILogger
+	fdfol          logic.IFeedFollower
+	msgr           logic.IMessenger
+	repo           dal.IRepo
+	prof           logic.IProfiler
+	bundles        logic.IBundles
+	dblocks        logic.IDomainBlocks
+	fblocks        logic.IBlockedFeeds
+	udir           logic.IUserDirectory
+	reports        logic.IReports
+	reBundleHandle *regexp.Regexp
 }
 
 func NewApiHandlerGroup(
 	cfg *shared.Config,
 	logger shared.ILogger,
 	fdfol logic.IFeedFollower,
+	msgr logic.IMessenger,
 	repo dal.IRepo,
 	prof logic.IProfiler,
+	bundles logic.IBundles,
+	dblocks logic.IDomainBlocks,
+	fblocks logic.IBlockedFeeds,
+	udir logic.IUserDirectory,
+	reports logic.IReports,
 ) IHandlerGroup {
 	res := apiHandlerGroup{
-		cfg:    cfg,
-		logger: logger,
-		fdfol:  fdfol,
-		repo:   repo,
-		prof:   prof,
+		cfg:     cfg,
+		logger:  logger,
+		fdfol:   fdfol,
+		msgr:    msgr,
+		repo:    repo,
+		prof:    prof,
+		bundles: bundles,
+		dblocks: dblocks,
+		fblocks: fblocks,
+		udir:    udir,
+		reports: reports,
 	}
+	// No dots: feed handles are derived from host names, so a bundle handle must never look like one
+	res.reBundleHandle = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
 	return &res
 }
 
@@ -46,7 +75,31 @@ func (hg *apiHandlerGroup) Prefix() string {
 func (hg *apiHandlerGroup) GroupDefs() []handlerDef {
 	return []handlerDef{
 		{"POST", "/feeds", func(w http.ResponseWriter, r *http.Request) { hg.postFeeds(w, r) }},
+		{"GET", "/accounts", func(w http.ResponseWriter, r *http.Request) { hg.getAccounts(w, r) }},
+		{"GET", "/accounts/{account}", func(w http.ResponseWriter, r *http.Request) { hg.getAccountDetails(w, r) }},
+		{"PATCH", "/accounts/{account}", func(w http.ResponseWriter, r *http.Request) { hg.patchAccount(w, r) }},
 		{"DELETE", "/accounts/{account}", func(w http.ResponseWriter, r *http.Request) { hg.deleteAccount(w, r) }},
+		{"POST", "/accounts/{account}/refresh", func(w http.ResponseWriter, r *http.Request) { hg.postAccountRefresh(w, r) }},
+		{"PUT", "/accounts/{account}/relay-toots", func(w http.ResponseWriter, r *http.Request) { hg.putRelayToots(w, r) }},
+		{"POST", "/bundles", func(w http.ResponseWriter, r *http.Request) { hg.postBundles(w, r) }},
+		{"GET", "/bundles/{bundle}", func(w http.ResponseWriter, r *http.Request) { hg.getBundle(w, r) }},
+		{"PUT", "/bundles/{bundle}/members/{account}", func(w http.ResponseWriter, r *http.Request) { hg.putBundleMember(w, r) }},
+		{"DELETE", "/bundles/{bundle}/members/{account}", func(w http.ResponseWriter, r *http.Request) { hg.deleteBundleMember(w, r) }},
+		{"GET", "/domain-blocks", func(w http.ResponseWriter, r *http.Request) { hg.getDomainBlocks(w, r) }},
+		{"POST", "/domain-blocks/import", func(w http.ResponseWriter, r *http.Request) { hg.postDomainBlocksImport(w, r) }},
+		{"PUT", "/domain-blocks/{domain}", func(w http.ResponseWriter, r *http.Request) { hg.putDomainBlock(w, r) }},
+		{"DELETE", "/domain-blocks/{domain}", func(w http.ResponseWriter, r *http.Request) { hg.deleteDomainBlock(w, r) }},
+		{"GET", "/feed-blocks", func(w http.ResponseWriter, r *http.Request) { hg.getFeedBlocks(w, r) }},
+		{"POST", "/feed-blocks", func(w http.ResponseWriter, r *http.Request) { hg.postFeedBlock(w, r) }},
+		{"DELETE", "/feed-blocks/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) { hg.deleteFeedBlock(w, r) }},
+		{"PUT", "/accounts/{account}/manually-approves", func(w http.ResponseWriter, r *http.Request) { hg.putManuallyApproves(w, r) }},
+		{"GET", "/accounts/{account}/follow-requests", func(w http.ResponseWriter, r *http.Request) { hg.getFollowRequests(w, r) }},
+		{"POST", "/accounts/{account}/follow-requests/{verdict:accept|reject}", func(w http.ResponseWriter, r *http.Request) { hg.postFollowRequestVerdict(w, r) }},
+		{"GET", "/reports", func(w http.ResponseWriter, r *http.Request) { hg.getReports(w, r) }},
+		{"POST", "/reports/{id:[0-9]+}/resolve", func(w http.ResponseWriter, r *http.Request) { hg.postReportResolve(w, r) }},
+		{"GET", "/relays", func(w http.ResponseWriter, r *http.Request) { hg.getRelays(w, r) }},
+		{"GET", "/delivery/queue", func(w http.ResponseWriter, r *http.Request) { hg.getDeliveryQueue(w, r) }},
+		{"GET", "/delivery/inboxes", func(w http.ResponseWriter, r *http.Request) { hg.getDeliveryInboxes(w, r) }},
 		{"POST", "/actions/vacuum", func(w http.ResponseWriter, r *http.Request) { hg.postActionsVacuum(w, r) }},
 	}
 }
@@ -112,9 +165,9 @@ func (hg *apiHandlerGroup) deleteAccount(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = hg.repo.BruteDeleteAccount(acct.Id)
+	err = hg.fdfol.PurgeAccount(acct)
 	if err != nil {
-		msg := fmt.Sprintf("Failed to brute-delete account: %v", err)
+		msg := fmt.Sprintf("Failed to delete account: %v", err)
 		hg.logger.Error(msg)
 		writeErrorResponse(w, msg, http.StatusInternalServerError)
 		return
@@ -123,6 +176,562 @@ func (hg *apiHandlerGroup) deleteAccount(w http.ResponseWriter, r *http.Request)
 	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
 }
 
+const (
+	defaultAccountPageSize = 50
+	maxAccountPageSize     = 500
+)
+
+// Position after the last account of a page: its sort value and ID, and the order the page was listed in
+type accountCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value any    `json:"v"`
+	Id    int    `json:"id"`
+}
+
+func encodeAccountCursor(filter *dal.AccountFilter, acct *dal.AccountSummary) string {
+	cursor := accountCursor{Sort: filter.Sort, Desc: filter.Desc, Id: acct.Id}
+	switch filter.Sort {
+	case dal.AccountSortCreated:
+		cursor.Value = acct.CreatedAt.Format(time.RFC3339Nano)
+	case dal.AccountSortHandle:
+		cursor.Value = acct.Handle
+	case dal.AccountSortFollowers:
+		cursor.Value = acct.FollowerCount
+	case dal.AccountSortUpdated:
+		cursor.Value = acct.FeedLastUpdated.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(&cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Sets the filter's position from the cursor; the cursor must have been issued for the same sort key and order
+func decodeAccountCursor(str string, filter *dal.AccountFilter) error {
+	data, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return err
+	}
+	var cursor accountCursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return err
+	}
+	if cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+		return fmt.Errorf("cursor is for a different sort order")
+	}
+	filter.AfterId = cursor.Id
+	switch val := cursor.Value.(type) {
+	case string:
+		if filter.Sort == dal.AccountSortHandle {
+			filter.AfterValue = val
+		} else if filter.Sort == dal.AccountSortFollowers {
+			return fmt.Errorf("cursor has a sort value of the wrong type")
+		} else if filter.AfterValue, err = time.Parse(time.RFC3339Nano, val); err != nil {
+			return err
+		}
+	case float64:
+		if filter.Sort != dal.AccountSortFollowers {
+			return fmt.Errorf("cursor has a sort value of the wrong type")
+		}
+		filter.AfterValue = int(val)
+	}
+	if filter.AfterValue == nil {
+		return fmt.Errorf("cursor has no sort value")
+	}
+	return nil
+}
+
+// Reads the list criteria from the query string; returns an error message for invalid parameters
+func parseAccountFilter(r *http.Request) (*dal.AccountFilter, string) {
+
+	query := r.URL.Query()
+	filter := &dal.AccountFilter{
+		Search:       query.Get("q"),
+		OnlyFailing:  query.Get("failing") == "true",
+		MaxFollowers: -1,
+		Sort:         dal.AccountSortCreated,
+		Limit:        defaultAccountPageSize,
+	}
+	var err error
+
+	parseInt := func(name string, dest *int, minVal, maxVal int) string {
+		str := query.Get(name)
+		if str == "" {
+			return ""
+		}
+		val, err := strconv.Atoi(str)
+		if err != nil || val < minVal || val > maxVal {
+			return fmt.Sprintf("Invalid value for %s: '%s'", name, str)
+		}
+		*dest = val
+		return ""
+	}
+	// Accepts a full timestamp or just a date
+	parseTime := func(name string, dest *time.Time) string {
+		str := query.Get(name)
+		if str == "" {
+			return ""
+		}
+		if *dest, err = time.Parse(time.RFC3339, str); err == nil {
+			return ""
+		}
+		if *dest, err = time.Parse(time.DateOnly, str); err == nil {
+			return ""
+		}
+		return fmt.Sprintf("Invalid value for %s: '%s'", name, str)
+	}
+
+	var idleDays int
+	problems := []string{
+		parseTime("created_after", &filter.CreatedAfter),
+		parseTime("created_before", &filter.CreatedBefore),
+		parseInt("min_followers", &filter.MinFollowers, 0, math.MaxInt32),
+		parseInt("max_followers", &filter.MaxFollowers, 0, math.MaxInt32),
+		parseInt("idle_days", &idleDays, 1, 100*365),
+		parseInt("limit", &filter.Limit, 1, maxAccountPageSize),
+	}
+	for _, problem := range problems {
+		if problem != "" {
+			return nil, problem
+		}
+	}
+	if idleDays != 0 {
+		filter.IdleSince = time.Now().UTC().AddDate(0, 0, -idleDays)
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		filter.Sort = sort
+	}
+	switch filter.Sort {
+	case dal.AccountSortCreated, dal.AccountSortHandle, dal.AccountSortFollowers, dal.AccountSortUpdated:
+	default:
+		return nil, fmt.Sprintf("Invalid sort key: '%s'", filter.Sort)
+	}
+	// Newest, most followed and most recently updated come first unless asked otherwise
+	filter.Desc = filter.Sort != dal.AccountSortHandle
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		filter.Desc = false
+	case "desc":
+		filter.Desc = true
+	default:
+		return nil, fmt.Sprintf("Invalid order: '%s'", query.Get("order"))
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		if err = decodeAccountCursor(cursor, filter); err != nil {
+			return nil, fmt.Sprintf("Invalid cursor: %v", err)
+		}
+	}
+	return filter, ""
+}
+
+func makeAccountItem(acct *dal.Account, followerCount int) dto.AccountItem {
+	return dto.AccountItem{
+		Handle:          acct.Handle,
+		UserUrl:         acct.UserUrl,
+		IsBundle:        acct.IsBundle(),
+		FeedName:        acct.FeedName,
+		SiteUrl:         acct.SiteUrl,
+		FeedUrl:         acct.FeedUrl,
+		CreatedAt:       acct.CreatedAt,
+		FeedLastUpdated: acct.FeedLastUpdated,
+		LastCheckedAt:   acct.LastCheckedAt,
+		NextCheckDue:    acct.NextCheckDue,
+		FeedErrorCount:  acct.FeedErrorCount,
+		FollowerCount:   followerCount,
+		Paused:          acct.Paused,
+	}
+}
+
+// Lists accounts page by page. Query parameters (all optional):
+// q, created_after, created_before, min_followers, max_followers, failing=true, idle_days,
+// sort (created, handle, followers, updated), order (asc, desc), limit, cursor
+func (hg *apiHandlerGroup) getAccounts(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	filter, problem := parseAccountFilter(r)
+	if problem != "" {
+		hg.logger.Info(problem)
+		writeErrorResponse(w, problem, http.StatusBadRequest)
+		return
+	}
+
+	// One more than the page size tells us if there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+	accts, err := hg.repo.GetAccountSummaries(filter)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get accounts: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	res := dto.AccountList{Accounts: make([]dto.AccountItem, 0, len(accts))}
+	if len(accts) > pageSize {
+		accts = accts[:pageSize]
+		res.NextCursor = encodeAccountCursor(filter, accts[pageSize-1])
+	}
+	for _, acct := range accts {
+		res.Accounts = append(res.Accounts, makeAccountItem(acct.Account, acct.FollowerCount))
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+func (hg *apiHandlerGroup) getAccountDetails(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	acct := hg.getAccountParam(w, r, "account")
+	if acct == nil {
+		return
+	}
+
+	writeErr := func(what string, err error) {
+		msg := fmt.Sprintf("Failed to get %s: %v", what, err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+	}
+	followers, err := hg.repo.GetFollowersByUser(acct.Handle, false)
+	if err != nil {
+		writeErr("followers", err)
+		return
+	}
+	postCount, err := hg.repo.GetPostCount(acct.Handle)
+	if err != nil {
+		writeErr("post count", err)
+		return
+	}
+	queuedToots, err := hg.repo.GetTootQueueCount(acct.Handle)
+	if err != nil {
+		writeErr("delivery queue", err)
+		return
+	}
+	feedErrors, err := hg.repo.GetFeedErrors(acct.Id)
+	if err != nil {
+		writeErr("feed errors", err)
+		return
+	}
+
+	approved, pending := 0, 0
+	for _, flwr := range followers {
+		if flwr.ApproveStatus == 1 {
+			approved++
+		} else if flwr.ApproveStatus == 0 {
+			pending++
+		}
+	}
+	res := dto.AccountDetails{
+		AccountItem:      makeAccountItem(acct, approved),
+		FeedSummary:      acct.FeedSummary,
+		ProfileImageUrl:  acct.ProfileImageUrl,
+		HeaderImageUrl:   acct.HeaderImageUrl,
+		Locked:           []string{},
+		CheckIntervalMin: acct.CheckIntervalMin,
+		RelayToots:       acct.RelayToots,
+		ManuallyApproves: acct.ManuallyApproves,
+		PendingFollowers: pending,
+		PostCount:        int(postCount),
+		QueuedToots:      queuedToots,
+		RecentErrors:     make([]dto.FeedError, 0, len(feedErrors)),
+	}
+	for _, fe := range feedErrors {
+		res.RecentErrors = append(res.RecentErrors, dto.FeedError{OccurredAt: fe.OccurredAt, Error: fe.Error})
+	}
+	for _, field := range lockableFieldNames {
+		if acct.IsLocked(lockableFields[field]) {
+			res.Locked = append(res.Locked, field)
+		}
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+const maxCheckIntervalMin = 7 * 24 * 60
+
+// Profile fields that can be locked, by their name in the API
+var lockableFields = map[string]int{
+	"feed_name":         dal.LockFeedName,
+	"feed_summary":      dal.LockFeedSummary,
+	"profile_image_url": dal.LockProfileImage,
+}
+var lockableFieldNames = []string{"feed_name", "feed_summary", "profile_image_url"}
+
+func isValidImageUrl(str string) bool {
+	if str == "" {
+		return true
+	}
+	parsed, err := url.Parse(str)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// Applies the patch to the account; returns an error message if the patch is invalid
+func (hg *apiHandlerGroup) applyAccountPatch(acct *dal.Account, patch *dto.AccountPatch) string {
+
+	isFeed := !acct.IsBundle()
+	if !isFeed && (patch.FeedUrl != nil || patch.CheckIntervalMin != nil || patch.Paused != nil) {
+		return "Bundles don't have a feed"
+	}
+
+	setProfileField := func(dest *string, val *string, lock int) {
+		if val == nil {
+			return
+		}
+		*dest = *val
+		acct.LockedFields |= lock
+	}
+	setProfileField(&acct.FeedName, patch.FeedName, dal.LockFeedName)
+	setProfileField(&acct.FeedSummary, patch.FeedSummary, dal.LockFeedSummary)
+	setProfileField(&acct.ProfileImageUrl, patch.ProfileImageUrl, dal.LockProfileImage)
+	if patch.HeaderImageUrl != nil {
+		acct.HeaderImageUrl = *patch.HeaderImageUrl
+	}
+	if strings.TrimSpace(acct.FeedName) == "" {
+		return "Name must not be empty"
+	}
+	if !isValidImageUrl(acct.ProfileImageUrl) || !isValidImageUrl(acct.HeaderImageUrl) {
+		return "Image URLs must be absolute http or https URLs"
+	}
+
+	// Next feed check will bring unlocked fields up to date
+	for _, field := range patch.Unlock {
+		lock, ok := lockableFields[field]
+		if !ok {
+			return fmt.Sprintf("Field cannot be unlocked: '%s'", field)
+		}
+		if acct.IsLocked(lock) {
+			acct.LockedFields &^= lock
+			acct.FeedMetaHash = dal.FeedMetaHashStale
+			acct.NextCheckDue = time.Now()
+		}
+	}
+
+	if patch.CheckIntervalMin != nil {
+		if *patch.CheckIntervalMin < 0 || *patch.CheckIntervalMin > maxCheckIntervalMin {
+			return fmt.Sprintf("Check interval must be between 0 and %d minutes", maxCheckIntervalMin)
+		}
+		acct.CheckIntervalMin = *patch.CheckIntervalMin
+		acct.NextCheckDue = time.Now()
+	}
+	if patch.Paused != nil {
+		acct.Paused = *patch.Paused
+	}
+	if patch.RelayToots != nil {
+		acct.RelayToots = *patch.RelayToots
+	}
+	return ""
+}
+
+// Collects the settings that differ between the account as loaded and as patched
+func getChangedSettings(orig, acct *dal.Account) *dal.AccountSettings {
+	var res dal.AccountSettings
+	if acct.FeedName != orig.FeedName {
+		res.FeedName = &acct.FeedName
+	}
+	if acct.FeedSummary != orig.FeedSummary {
+		res.FeedSummary = &acct.FeedSummary
+	}
+	if acct.ProfileImageUrl != orig.ProfileImageUrl {
+		res.ProfileImageUrl = &acct.ProfileImageUrl
+	}
+	if acct.HeaderImageUrl != orig.HeaderImageUrl {
+		res.HeaderImageUrl = &acct.HeaderImageUrl
+	}
+	if acct.FeedUrl != orig.FeedUrl {
+		res.FeedUrl = &acct.FeedUrl
+	}
+	if acct.FeedMetaHash != orig.FeedMetaHash {
+		res.FeedMetaHash = &acct.FeedMetaHash
+	}
+	if acct.LockedFields != orig.LockedFields {
+		res.LockedFields = &acct.LockedFields
+	}
+	if acct.Paused != orig.Paused {
+		res.Paused = &acct.Paused
+	}
+	if acct.CheckIntervalMin != orig.CheckIntervalMin {
+		res.CheckIntervalMin = &acct.CheckIntervalMin
+	}
+	if !acct.NextCheckDue.Equal(orig.NextCheckDue) {
+		res.NextCheckDue = &acct.NextCheckDue
+	}
+	if acct.RelayToots != orig.RelayToots {
+		res.RelayToots = &acct.RelayToots
+	}
+	return &res
+}
+
+// Changes the account's settings. A new feed URL is only accepted if we can fetch and parse the feed.
+func (hg *apiHandlerGroup) patchAccount(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		return
+	}
+	var patch dto.AccountPatch
+	if err = json.Unmarshal(bodyBytes, &patch); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	acct := hg.getAccountParam(w, r, "account")
+	if acct == nil {
+		return
+	}
+	if acct.Handle == hg.cfg.Birb.User {
+		writeErrorResponse(w, "The built-in account's settings come from the config", http.StatusBadRequest)
+		return
+	}
+
+	orig := *acct
+	if problem := hg.applyAccountPatch(acct, &patch); problem != "" {
+		hg.logger.Info(problem)
+		writeErrorResponse(w, problem, http.StatusBadRequest)
+		return
+	}
+
+	if patch.FeedUrl != nil && *patch.FeedUrl != orig.FeedUrl {
+		status, feedErr := hg.fdfol.ValidateFeedUrl(*patch.FeedUrl)
+		if feedErr != nil || status != logic.FsError {
+			msg := fmt.Sprintf("Feed cannot be parroted: %d", status)
+			if feedErr != nil {
+				msg = fmt.Sprintf("Failed to get feed: %v", feedErr)
+			}
+			hg.logger.Info(msg)
+			writeErrorResponse(w, msg, http.StatusBadRequest)
+			return
+		}
+		acct.FeedUrl = *patch.FeedUrl
+		acct.NextCheckDue = time.Now()
+	}
+
+	if err = hg.repo.UpdateAccountSettings(acct.Id, getChangedSettings(&orig, acct)); err != nil {
+		msg := fmt.Sprintf("Failed to update account: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	// Remote servers only learn about a new profile through an actor Update
+	profileChanged := acct.FeedName != orig.FeedName || acct.FeedSummary != orig.FeedSummary ||
+		acct.ProfileImageUrl != orig.ProfileImageUrl || acct.HeaderImageUrl != orig.HeaderImageUrl
+	if profileChanged {
+		if err = hg.udir.BroadcastUpdate(acct.Handle); err != nil {
+			hg.logger.Errorf("Failed to broadcast actor Update of %s: %v", acct.Handle, err)
+		}
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+// Checks the account's feed right away. A feed that cannot be fetched or parsed is not an error of the request:
+// the response describes what went wrong.
+func (hg *apiHandlerGroup) postAccountRefresh(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	acct := hg.getAccountParam(w, r, "account")
+	if acct == nil {
+		return
+	}
+	if acct.IsBundle() || acct.FeedUrl == "" {
+		writeErrorResponse(w, "Account has no feed to refresh", http.StatusBadRequest)
+		return
+	}
+
+	diag := hg.fdfol.RefreshFeed(acct)
+	writeJsonResponse(hg.logger, w, rtPlainJson, diag)
+}
+
+// Opts the account in or out of having its toots announced to relays
+func (hg *apiHandlerGroup) putRelayToots(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	accountName := mux.Vars(r)["account"]
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		return
+	}
+	var req dto.RelayToots
+	if err = json.Unmarshal(bodyBytes, &req); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	var acct *dal.Account
+	if acct, err = hg.repo.GetAccount(accountName); err != nil {
+		msg := fmt.Sprintf("Failed to get account: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	if acct == nil {
+		msg := fmt.Sprintf("Account not found: %s", accountName)
+		writeErrorResponse(w, msg, http.StatusNotFound)
+		return
+	}
+
+	if err = hg.repo.SetAccountRelayToots(acct.Id, req.Enabled); err != nil {
+		msg := fmt.Sprintf("Failed to update account: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+func (hg *apiHandlerGroup) getRelays(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	relays, err := hg.repo.GetRelays()
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get relays: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	statusNames := map[int]string{dal.RelayPending: "pending", dal.RelayAccepted: "accepted", dal.RelayRejected: "rejected"}
+	res := make([]dto.Relay, 0, len(relays))
+	for _, relay := range relays {
+		res = append(res, dto.Relay{
+			Inbox:     relay.Inbox,
+			Status:    statusNames[relay.Status],
+			UpdatedAt: relay.UpdatedAt,
+		})
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+func (hg *apiHandlerGroup) getDeliveryQueue(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	summary, err := hg.msgr.GetQueueSummary()
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get delivery queue summary: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, summary)
+}
+
+func (hg *apiHandlerGroup) getDeliveryInboxes(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	inboxes, err := hg.msgr.GetFailingInboxes()
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get failing inboxes: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, inboxes)
+}
+
 func (hg *apiHandlerGroup) postActionsVacuum(w http.ResponseWriter, r *http.Request) {
 	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
 
@@ -170,16 +779,18 @@ func (hg *apiHandlerGroup) postFeeds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	res := dto.Feed{
-		CreatedAt:       acct.CreatedAt,
-		UserUrl:         acct.UserUrl,
-		Handle:          acct.Handle,
-		FeedName:        acct.FeedName,
-		FeedSummary:     acct.FeedSummary,
-		ProfileImageUrl: acct.ProfileImageUrl,
-		SiteUrl:         acct.SiteUrl,
-		FeedUrl:         acct.FeedUrl,
-		FeedLastUpdated: acct.FeedLastUpdated,
-		NextCheckDue:    acct.NextCheckDue,
+		CreatedAt:        acct.CreatedAt,
+		UserUrl:          acct.UserUrl,
+		Handle:           acct.Handle,
+		FeedName:         acct.FeedName,
+		FeedSummary:      acct.FeedSummary,
+		ProfileImageUrl:  acct.ProfileImageUrl,
+		SiteUrl:          acct.SiteUrl,
+		FeedUrl:          acct.FeedUrl,
+		FeedLastUpdated:  acct.FeedLastUpdated,
+		NextCheckDue:     acct.NextCheckDue,
+		RelayToots:       acct.RelayToots,
+		ManuallyApproves: acct.ManuallyApproves,
 	}
 
 	if status == logic.FsNew {
@@ -189,3 +800,565 @@ func (hg *apiHandlerGroup) postFeeds(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJsonResponse(hg.logger, w, rtPlainJson, res)
 }
+
+func (hg *apiHandlerGroup) postBundles(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		hg.logger.Info("Empty request body")
+		writeErrorResponse(w, "Request body must not be empty", http.StatusBadRequest)
+		return
+	}
+	var req dto.Bundle
+	if err = json.Unmarshal(bodyBytes, &req); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+	handle := strings.ToLower(req.Handle)
+	if !hg.reBundleHandle.MatchString(handle) || handle == hg.cfg.Birb.User {
+		msg := fmt.Sprintf("Invalid bundle handle: '%s'", req.Handle)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		writeErrorResponse(w, "Bundle name must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	var existing *dal.Account
+	if existing, err = hg.repo.GetAccount(handle); err != nil {
+		msg := fmt.Sprintf("Failed to get account: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	if existing != nil && !existing.IsBundle() {
+		msg := fmt.Sprintf("Handle is taken by a feed: %s", handle)
+		writeErrorResponse(w, msg, http.StatusConflict)
+		return
+	}
+
+	acct, isNew, err := hg.bundles.CreateBundle(handle, req.Name, req.Summary)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to create bundle: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	res := hg.makeBundleResponse(w, acct)
+	if res == nil {
+		return
+	}
+	status := http.StatusOK
+	if isNew {
+		status = http.StatusCreated
+	}
+	writeJsonStatusResponse(hg.logger, w, rtPlainJson, status, res)
+}
+
+func (hg *apiHandlerGroup) makeBundleResponse(w http.ResponseWriter, acct *dal.Account) *dto.Bundle {
+
+	members, err := hg.repo.GetBundleMembers(acct.Id)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get bundle members: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return nil
+	}
+	res := &dto.Bundle{
+		CreatedAt: acct.CreatedAt,
+		UserUrl:   acct.UserUrl,
+		Handle:    acct.Handle,
+		Name:      acct.FeedName,
+		Summary:   acct.FeedSummary,
+		Members:   make([]string, 0, len(members)),
+	}
+	for _, m := range members {
+		res.Members = append(res.Members, m.Handle)
+	}
+	return res
+}
+
+// Gets the account in the request's {bundle} or {account} parameter; nil if an error response has been sent.
+func (hg *apiHandlerGroup) getAccountParam(w http.ResponseWriter, r *http.Request, param string) *dal.Account {
+
+	name := mux.Vars(r)[param]
+	acct, err := hg.repo.GetAccount(name)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get account: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return nil
+	}
+	if acct == nil || param == "bundle" && !acct.IsBundle() {
+		msg := fmt.Sprintf("Account not found: %s", name)
+		writeErrorResponse(w, msg, http.StatusNotFound)
+		return nil
+	}
+	return acct
+}
+
+func (hg *apiHandlerGroup) getBundle(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bundle := hg.getAccountParam(w, r, "bundle")
+	if bundle == nil {
+		return
+	}
+	if res := hg.makeBundleResponse(w, bundle); res != nil {
+		writeJsonResponse(hg.logger, w, rtPlainJson, res)
+	}
+}
+
+func (hg *apiHandlerGroup) putBundleMember(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bundle := hg.getAccountParam(w, r, "bundle")
+	if bundle == nil {
+		return
+	}
+	member := hg.getAccountParam(w, r, "account")
+	if member == nil {
+		return
+	}
+	if member.IsBundle() || member.Handle == hg.cfg.Birb.User {
+		msg := fmt.Sprintf("Only feed accounts can be bundle members: %s", member.Handle)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := hg.repo.AddBundleMember(bundle.Id, member.Id, time.Now()); err != nil {
+		msg := fmt.Sprintf("Failed to add bundle member: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+func (hg *apiHandlerGroup) deleteBundleMember(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bundle := hg.getAccountParam(w, r, "bundle")
+	if bundle == nil {
+		return
+	}
+	member := hg.getAccountParam(w, r, "account")
+	if member == nil {
+		return
+	}
+
+	if err := hg.repo.RemoveBundleMember(bundle.Id, member.Id); err != nil {
+		msg := fmt.Sprintf("Failed to remove bundle member: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+func (hg *apiHandlerGroup) getDomainBlocks(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	blocks, err := hg.dblocks.GetBlocks()
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get domain blocks: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	res := make([]dto.DomainBlock, 0, len(blocks))
+	for _, b := range blocks {
+		res = append(res, dto.DomainBlock{
+			Domain:    b.Domain,
+			Level:     logic.DomainLevelName(b.Level),
+			Reason:    b.Reason,
+			CreatedAt: b.CreatedAt,
+		})
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+func (hg *apiHandlerGroup) putDomainBlock(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		return
+	}
+	var req dto.DomainBlock
+	if err = json.Unmarshal(bodyBytes, &req); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+	level := logic.ParseDomainLevel(req.Level)
+	if level == 0 {
+		msg := fmt.Sprintf("Invalid block level: '%s'", req.Level)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err = hg.dblocks.SetBlock(mux.Vars(r)["domain"], level, req.Reason); err != nil {
+		msg := fmt.Sprintf("Failed to block domain: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+func (hg *apiHandlerGroup) deleteDomainBlock(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	if err := hg.dblocks.RemoveBlock(mux.Vars(r)["domain"]); err != nil {
+		msg := fmt.Sprintf("Failed to unblock domain: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+// Body is a CSV block list, e.g., as exported by Mastodon
+func (hg *apiHandlerGroup) postDomainBlocksImport(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		hg.logger.Info("Empty request body")
+		writeErrorResponse(w, "Request body must not be empty", http.StatusBadRequest)
+		return
+	}
+	count, err := hg.dblocks.ImportCsv(bytes.NewReader(bodyBytes))
+	if err != nil {
+		msg := fmt.Sprintf("Failed to import block list after %d domains: %v", count, err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, dto.ImportResult{Imported: count})
+}
+
+func feedBlockToDto(b *dal.FeedBlock) dto.FeedBlock {
+	return dto.FeedBlock{
+		Id:        b.Id,
+		Kind:      b.Kind,
+		Pattern:   b.Pattern,
+		Reason:    b.Reason,
+		CreatedAt: b.CreatedAt,
+	}
+}
+
+func (hg *apiHandlerGroup) getFeedBlocks(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	blocks, err := hg.fblocks.GetBlocks()
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get feed blocks: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	res := make([]dto.FeedBlock, 0, len(blocks))
+	for _, b := range blocks {
+		res = append(res, feedBlockToDto(b))
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+// Adds a rule and lists the existing accounts it matches. If the request asks for it, those accounts are purged;
+// otherwise the same request can be repeated with purge set once the list has been reviewed.
+func (hg *apiHandlerGroup) postFeedBlock(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		return
+	}
+	var req dto.FeedBlockRequest
+	if err = json.Unmarshal(bodyBytes, &req); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	block, err := hg.fblocks.AddBlock(req.Kind, req.Pattern, req.Reason)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to block feeds: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+	accounts, err := hg.fblocks.GetMatchingAccounts(block)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to find matching accounts: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	res := dto.FeedBlockResult{Block: feedBlockToDto(block), Matching: []string{}, Purged: req.Purge}
+	for _, acct := range accounts {
+		res.Matching = append(res.Matching, acct.Handle)
+		if !req.Purge {
+			continue
+		}
+		if err = hg.fdfol.PurgeAccount(acct); err != nil {
+			msg := fmt.Sprintf("Failed to purge account %s: %v", acct.Handle, err)
+			hg.logger.Error(msg)
+			writeErrorResponse(w, msg, http.StatusInternalServerError)
+			return
+		}
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+func (hg *apiHandlerGroup) deleteFeedBlock(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	found, err := hg.fblocks.RemoveBlock(id)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to remove feed block: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		msg := fmt.Sprintf("Feed block not found: %d", id)
+		writeErrorResponse(w, msg, http.StatusNotFound)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+// Turns the queue for follow requests on or off; remote servers learn about it through an actor Update.
+// Turning it off accepts the requests still in the queue, as if they had come in after the change.
+func (hg *apiHandlerGroup) putManuallyApproves(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		return
+	}
+	var req dto.ManuallyApproves
+	if err = json.Unmarshal(bodyBytes, &req); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	acct := hg.getAccountParam(w, r, "account")
+	if acct == nil {
+		return
+	}
+	if acct.Handle == hg.cfg.Birb.User {
+		writeErrorResponse(w, "The built-in account's approval mode comes from the config", http.StatusBadRequest)
+		return
+	}
+
+	if err = hg.repo.SetAccountManuallyApproves(acct.Id, req.Enabled); err != nil {
+		msg := fmt.Sprintf("Failed to update account: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	if !req.Enabled {
+		hg.acceptPendingFollowers(acct)
+	}
+	if err = hg.udir.BroadcastUpdate(acct.Handle); err != nil {
+		hg.logger.Errorf("Failed to broadcast actor Update of %s: %v", acct.Handle, err)
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+// Accepts every pending follow request of the account; a failure with one doesn't hold up the rest
+func (hg *apiHandlerGroup) acceptPendingFollowers(acct *dal.Account) {
+
+	followers, err := hg.repo.GetFollowersByUser(acct.Handle, false)
+	if err != nil {
+		hg.logger.Errorf("Failed to get pending followers of %s: %v", acct.Handle, err)
+		return
+	}
+	for _, flwr := range followers {
+		if flwr.ApproveStatus != 0 {
+			continue
+		}
+		if err = hg.udir.AcceptFollower(flwr.RequestId, flwr.UserUrl, flwr.UserInbox, acct.Handle); err != nil {
+			hg.logger.Errorf("Failed to accept follower %s of %s: %v", flwr.UserUrl, acct.Handle, err)
+		}
+	}
+}
+
+func (hg *apiHandlerGroup) getPendingFollowers(w http.ResponseWriter, acct *dal.Account) []*dal.FollowerInfo {
+
+	followers, err := hg.repo.GetFollowersByUser(acct.Handle, false)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get followers: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return nil
+	}
+	res := make([]*dal.FollowerInfo, 0)
+	for _, flwr := range followers {
+		if flwr.ApproveStatus == 0 {
+			res = append(res, flwr)
+		}
+	}
+	return res
+}
+
+func (hg *apiHandlerGroup) getFollowRequests(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	acct := hg.getAccountParam(w, r, "account")
+	if acct == nil {
+		return
+	}
+	pending := hg.getPendingFollowers(w, acct)
+	if pending == nil {
+		return
+	}
+	res := make([]dto.FollowRequest, 0, len(pending))
+	for _, flwr := range pending {
+		res = append(res, dto.FollowRequest{
+			UserUrl:   flwr.UserUrl,
+			Handle:    flwr.Handle,
+			Host:      flwr.Host,
+			RequestId: flwr.RequestId,
+		})
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+// Accepts or rejects a pending follow request; body identifies the follower by user_url.
+// A rejected follower's later Follows are rejected automatically, until they are accepted here.
+func (hg *apiHandlerGroup) postFollowRequestVerdict(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		return
+	}
+	var req dto.FollowRequest
+	if err = json.Unmarshal(bodyBytes, &req); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	acct := hg.getAccountParam(w, r, "account")
+	if acct == nil {
+		return
+	}
+	flwr, err := hg.repo.GetFollower(acct.Handle, req.UserUrl)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get follower: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	accept := mux.Vars(r)["verdict"] == "accept"
+	// A rejected follower can still be accepted, which lifts the ban on following again
+	if flwr == nil || flwr.ApproveStatus > 0 || (flwr.ApproveStatus < 0 && !accept) {
+		msg := fmt.Sprintf("No pending follow request from %s", req.UserUrl)
+		writeErrorResponse(w, msg, http.StatusNotFound)
+		return
+	}
+
+	if accept {
+		err = hg.udir.AcceptFollower(flwr.RequestId, flwr.UserUrl, flwr.UserInbox, acct.Handle)
+	} else {
+		err = hg.udir.RejectFollower(flwr.RequestId, flwr.UserUrl, flwr.UserInbox, acct.Handle)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Failed to handle follow request: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
+
+// With ?open=true, only lists reports that have not been resolved yet
+func (hg *apiHandlerGroup) getReports(w http.ResponseWriter, r *http.Request) {
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	reports, err := hg.reports.GetReports(r.URL.Query().Get("open") == "true")
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get reports: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	res := make([]dto.Report, 0, len(reports))
+	for _, rep := range reports {
+		item := dto.Report{
+			Id:         rep.Id,
+			ActivityId: rep.ActivityId,
+			Reporter:   rep.Reporter,
+			Account:    rep.AccountHandle,
+			StatusIds:  rep.StatusIds,
+			Comment:    rep.Comment,
+			CreatedAt:  rep.CreatedAt,
+			Resolution: rep.Resolution,
+		}
+		if rep.Resolution != "" {
+			item.ResolvedAt = rep.ResolvedAt
+		}
+		if item.StatusIds == nil {
+			item.StatusIds = []string{}
+		}
+		res = append(res, item)
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, res)
+}
+
+func (hg *apiHandlerGroup) postReportResolve(w http.ResponseWriter, r *http.Request) {
+	var err error
+	hg.logger.Infof("Handling %s %s", r.Method, r.URL.Path)
+
+	bodyBytes := readBody(hg.logger, w, r)
+	if bodyBytes == nil {
+		return
+	}
+	var req dto.ReportResolution
+	if err = json.Unmarshal(bodyBytes, &req); err != nil {
+		msg := fmt.Sprintf("Invalid JSON in request body: %v", err)
+		hg.logger.Info(msg)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !logic.IsReportAction(req.Action) {
+		msg := fmt.Sprintf("Invalid action: '%s'", req.Action)
+		writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	found, err := hg.reports.Resolve(id, req.Action)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to resolve report: %v", err)
+		hg.logger.Error(msg)
+		writeErrorResponse(w, msg, http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		writeErrorResponse(w, notFoundStr, http.StatusNotFound)
+		return
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, "OK")
+}
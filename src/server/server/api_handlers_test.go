@@ -0,0 +1,146 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"testing"
+	"time"
+)
+
+func parseFilterQuery(query string) (*dal.AccountFilter, string) {
+	return parseAccountFilter(httptest.NewRequest("GET", "/api/accounts?"+query, nil))
+}
+
+func Test_Parse_Account_Filter(t *testing.T) {
+
+	cases := []struct {
+		query   string
+		problem bool
+		check   func(t *testing.T, f *dal.AccountFilter)
+	}{
+		{"", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, dal.AccountSortCreated, f.Sort)
+			assert.True(t, f.Desc)
+			assert.Equal(t, defaultAccountPageSize, f.Limit)
+			assert.Equal(t, -1, f.MaxFollowers)
+			assert.Nil(t, f.AfterValue)
+		}},
+		{"sort=handle", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, dal.AccountSortHandle, f.Sort)
+			assert.False(t, f.Desc)
+		}},
+		{"sort=handle&order=desc", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.True(t, f.Desc)
+		}},
+		{"sort=followers&order=asc", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, dal.AccountSortFollowers, f.Sort)
+			assert.False(t, f.Desc)
+		}},
+		{"sort=popularity", true, nil},
+		{"order=sideways", true, nil},
+		{"limit=10", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, 10, f.Limit)
+		}},
+		{"limit=0", true, nil},
+		{"limit=501", true, nil},
+		{"min_followers=5&max_followers=10", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, 5, f.MinFollowers)
+			assert.Equal(t, 10, f.MaxFollowers)
+		}},
+		{"min_followers=-1", true, nil},
+		{"max_followers=lots", true, nil},
+		{"created_after=2024-01-02", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), f.CreatedAfter)
+		}},
+		{"created_before=2024-01-02T03:04:05Z", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), f.CreatedBefore)
+		}},
+		{"created_after=yesterday", true, nil},
+		{"idle_days=7", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.WithinDuration(t, time.Now().UTC().AddDate(0, 0, -7), f.IdleSince, time.Minute)
+		}},
+		{"idle_days=0", true, nil},
+		{"q=blog&failing=true", false, func(t *testing.T, f *dal.AccountFilter) {
+			assert.Equal(t, "blog", f.Search)
+			assert.True(t, f.OnlyFailing)
+		}},
+		{"cursor=not-a-cursor", true, nil},
+	}
+	for _, c := range cases {
+		filter, problem := parseFilterQuery(c.query)
+		if c.problem {
+			assert.NotEqual(t, "", problem, c.query)
+			assert.Nil(t, filter, c.query)
+			continue
+		}
+		assert.Equal(t, "", problem, c.query)
+		c.check(t, filter)
+	}
+}
+
+func Test_Account_Cursor_Round_Trip(t *testing.T) {
+
+	created := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	updated := time.Date(2024, 6, 7, 8, 9, 10, 0, time.UTC)
+	acct := &dal.AccountSummary{
+		Account:       &dal.Account{Id: 17, Handle: "some.blog.com", CreatedAt: created, FeedLastUpdated: updated},
+		FollowerCount: 12,
+	}
+
+	cases := []struct {
+		query string
+		value any
+	}{
+		{"sort=created", created},
+		{"sort=created&order=asc", created},
+		{"sort=handle", "some.blog.com"},
+		{"sort=followers", 12},
+		{"sort=updated&order=asc", updated},
+	}
+	for _, c := range cases {
+		filter, problem := parseFilterQuery(c.query)
+		assert.Equal(t, "", problem, c.query)
+		cursor := encodeAccountCursor(filter, acct)
+
+		next, problem := parseFilterQuery(c.query + "&cursor=" + url.QueryEscape(cursor))
+		assert.Equal(t, "", problem, c.query)
+		assert.Equal(t, 17, next.AfterId, c.query)
+		assert.Equal(t, c.value, next.AfterValue, c.query)
+	}
+
+	// A cursor only continues the listing it came from
+	filter, _ := parseFilterQuery("sort=created")
+	cursor := url.QueryEscape(encodeAccountCursor(filter, acct))
+	for _, query := range []string{"sort=handle", "sort=followers", "sort=created&order=asc"} {
+		next, problem := parseFilterQuery(query + "&cursor=" + cursor)
+		assert.NotEqual(t, "", problem, query)
+		assert.Nil(t, next, query)
+	}
+}
+
+func Test_Account_Patch_Changed_Settings(t *testing.T) {
+
+	hg := &apiHandlerGroup{}
+	orig := dal.Account{Id: 3, Handle: "blog", FeedName: "Blog", FeedSummary: "About", FeedMetaHash: 42}
+	acct := orig
+	name := "Better blog"
+	paused := true
+	problem := hg.applyAccountPatch(&acct, &dto.AccountPatch{FeedName: &name, Paused: &paused})
+	assert.Equal(t, "", problem)
+
+	// Only what the patch changed is written; a feed check may be updating the rest meanwhile
+	settings := getChangedSettings(&orig, &acct)
+	assert.Equal(t, "Better blog", *settings.FeedName)
+	assert.Equal(t, dal.LockFeedName, *settings.LockedFields)
+	assert.Equal(t, true, *settings.Paused)
+	assert.Nil(t, settings.FeedSummary)
+	assert.Nil(t, settings.ProfileImageUrl)
+	assert.Nil(t, settings.FeedUrl)
+	assert.Nil(t, settings.FeedMetaHash)
+	assert.Nil(t, settings.CheckIntervalMin)
+	assert.Nil(t, settings.NextCheckDue)
+	assert.Nil(t, settings.RelayToots)
+}
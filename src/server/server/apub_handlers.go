@@ -6,9 +6,11 @@ import (
 	"github.com/gorilla/mux"
 	"net/http"
 	"regexp"
+	"rss_parrot/dal"
 	"rss_parrot/dto"
 	"rss_parrot/logic"
 	"rss_parrot/shared"
+	"strings"
 )
 
 // Groups together the handlers needed to implement an ActivityPub server.
@@ -19,7 +21,9 @@ type apubHandlerGroup struct {
 	sender     logic.IActivitySender
 	sigChecker logic.IHttpSigChecker
 	udir       logic.IUserDirectory
-	inbox      logic.IInbox
+	inq        logic.IInboundQueue
+	msgr       logic.IMessenger
+	dblocks    logic.IDomainBlocks
 	reResource *regexp.Regexp
 }
 
@@ -30,7 +34,9 @@ func NewApubHandlerGroup(
 	sender logic.IActivitySender,
 	sigChecker logic.IHttpSigChecker,
 	udir logic.IUserDirectory,
-	ibox logic.IInbox,
+	inq logic.IInboundQueue,
+	msgr logic.IMessenger,
+	dblocks logic.IDomainBlocks,
 ) IHandlerGroup {
 	res := apubHandlerGroup{
 		cfg:        cfg,
@@ -39,7 +45,9 @@ func NewApubHandlerGroup(
 		sender:     sender,
 		sigChecker: sigChecker,
 		udir:       udir,
-		inbox:      ibox,
+		inq:        inq,
+		msgr:       msgr,
+		dblocks:    dblocks,
 	}
 	res.reResource = regexp.MustCompile("^acct:([^@]+)@([^@]+)$")
 	return &res
@@ -66,6 +74,54 @@ func (hg *apubHandlerGroup) AuthMW() func(next http.Handler) http.Handler {
 	return emptyMW
 }
 
+func (hg *apubHandlerGroup) isBlockedHost(host string) bool {
+	blocked, err := hg.dblocks.IsBlocked(host)
+	if err != nil {
+		hg.logger.Errorf("Failed to check domain block list: %v", err)
+		return false
+	}
+	return blocked
+}
+
+// Enforces authorized fetch on ActivityPub GETs. Returns false if request was refused; the response has then
+// been written.
+func (hg *apubHandlerGroup) checkFetchAllowed(w http.ResponseWriter, r *http.Request, userName string) bool {
+
+	// The instance actor stays public: servers in secure mode fetch it to verify our own signed requests
+	if strings.EqualFold(userName, hg.cfg.Birb.User) {
+		return true
+	}
+
+	if !hg.cfg.AuthorizedFetch {
+		// Not verifying anything, but if the request claims to come from a blocked domain, we take its word
+		if host := hg.sigChecker.GetSigningHost(r); host != "" && hg.isBlockedHost(host) {
+			hg.logger.Infof("Refusing GET from blocked domain %s: %s", host, r.URL.Path)
+			writeErrorResponse(w, "Forbidden", http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+
+	senderInfo, sigProblem, err := hg.sigChecker.CheckFetch(r)
+	if err != nil {
+		hg.logger.Errorf("Unexpected error trying to verify signature: %v", err)
+		writeErrorResponse(w, internalErrorStr, http.StatusInternalServerError)
+		return false
+	}
+	if sigProblem != "" {
+		hg.logger.Infof("Refusing unsigned or incorrectly signed GET: %s: %s", r.URL.Path, sigProblem)
+		msg := fmt.Sprintf("Signed request required: %s", sigProblem)
+		writeErrorResponse(w, msg, http.StatusUnauthorized)
+		return false
+	}
+	if host, err := shared.GetHostName(senderInfo.Id); err != nil || hg.isBlockedHost(host) {
+		hg.logger.Infof("Refusing GET from blocked domain %s: %s", host, r.URL.Path)
+		writeErrorResponse(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
 func (hg *apubHandlerGroup) getWebfinger(w http.ResponseWriter, r *http.Request) {
 
 	hg.logger.Infof("Handling webfinger GET: %s", r.URL.Path)
@@ -108,9 +164,18 @@ func (hg *apubHandlerGroup) getUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !hg.checkFetchAllowed(w, r, userName) {
+		return
+	}
+
 	userInfo := hg.udir.GetUserInfo(userName)
 
 	if userInfo == nil {
+		if tomb := hg.udir.GetTombstone(userName); tomb != nil {
+			hg.logger.Infof("Info requested for deleted user: '%s'", userName)
+			writeJsonStatusResponse(hg.logger, w, rtActivityJson, http.StatusGone, tomb)
+			return
+		}
 		hg.logger.Infof("Info requested for unknown user: '%s'", userName)
 		writeErrorResponse(w, "No such user", http.StatusNotFound)
 		return
@@ -136,6 +201,10 @@ func (hg *apubHandlerGroup) getUserStatus(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !hg.checkFetchAllowed(w, r, userName) {
+		return
+	}
+
 	var err error
 	var note *dto.Note
 	if note, err = hg.udir.GetUserStatus(userName, statusId); err != nil {
@@ -145,6 +214,11 @@ func (hg *apubHandlerGroup) getUserStatus(w http.ResponseWriter, r *http.Request
 	}
 
 	if note == nil {
+		if tomb := hg.udir.GetTombstone(userName); tomb != nil {
+			hg.logger.Infof("Status requested for deleted user: %s/%s", userName, statusId)
+			writeErrorResponse(w, "User has been deleted", http.StatusGone)
+			return
+		}
 		hg.logger.Infof("User status not found: %s/%s", userName, statusId)
 		writeErrorResponse(w, "User or status not found", http.StatusNotFound)
 		return
@@ -162,6 +236,9 @@ func (hg *apubHandlerGroup) getUserOutbox(w http.ResponseWriter, r *http.Request
 	defer obs.Finish()
 
 	userName := mux.Vars(r)["user"]
+	if !hg.checkFetchAllowed(w, r, userName) {
+		return
+	}
 	summary := hg.udir.GetOutboxSummary(userName)
 	if summary == nil {
 		hg.logger.Infof("Outbox requested for unknown user: '%s'", userName)
@@ -178,6 +255,9 @@ func (hg *apubHandlerGroup) getUserFollowers(w http.ResponseWriter, r *http.Requ
 	defer obs.Finish()
 
 	userName := mux.Vars(r)["user"]
+	if !hg.checkFetchAllowed(w, r, userName) {
+		return
+	}
 	summary := hg.udir.GetFollowersSummary(userName)
 	if summary == nil {
 		hg.logger.Infof("Followers requested for unknown user: '%s'", userName)
@@ -194,6 +274,9 @@ func (hg *apubHandlerGroup) getUserFollowing(w http.ResponseWriter, r *http.Requ
 	defer obs.Finish()
 
 	userName := mux.Vars(r)["user"]
+	if !hg.checkFetchAllowed(w, r, userName) {
+		return
+	}
 	summary := hg.udir.GetFollowingSummary(userName)
 	if summary == nil {
 		hg.logger.Infof("Following requested for unknown user: '%s'", userName)
@@ -235,10 +318,23 @@ func (hg *apubHandlerGroup) postInbox(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify signature
+	// Verify the activity's integrity proof if it has one; the HTTP signature otherwise, or if the proof fails
+	// With a proof, we also accept activities that someone else forwarded to us
 	var senderInfo *dto.UserInfo
 	var sigProblem string
-	senderInfo, sigProblem, err = hg.sigChecker.Check(act.Actor, w, r)
+	if shared.GetProofVerificationMethod(bodyBytes) != "" {
+		senderInfo, sigProblem, err = hg.sigChecker.CheckProof(act.Actor, bodyBytes)
+		if err == nil && sigProblem != "" {
+			hg.logger.Infof("Invalid integrity proof, checking HTTP signature instead: %s", sigProblem)
+			proofProblem := sigProblem
+			senderInfo, sigProblem, err = hg.sigChecker.Check(act.Actor, bodyBytes, r)
+			if sigProblem != "" {
+				sigProblem = fmt.Sprintf("%s; integrity proof: %s", sigProblem, proofProblem)
+			}
+		}
+	} else {
+		senderInfo, sigProblem, err = hg.sigChecker.Check(act.Actor, bodyBytes, r)
+	}
 
 	if err != nil {
 		hg.logger.Errorf("Unexpected error trying to verify signature: %v", err)
@@ -258,10 +354,25 @@ func (hg *apubHandlerGroup) postInbox(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hg.processActivity(userName, bodyBytes, senderInfo, act, w)
+	// Signature and proof are checked on the bytes as received; what we store is the normalized form
+	if bodyBytes, err = dto.NormalizeActivity(bodyBytes); err != nil {
+		hg.logger.Errorf("Failed to normalize activity: %v", err)
+		writeErrorResponse(w, internalErrorStr, http.StatusInternalServerError)
+		return
+	}
+
+	// Deletes, Likes, Announces and the like arrive in great numbers; don't even queue what we would ignore
+	if !logic.IsInboundActivityHandled(act) {
+		hg.logger.Debugf("Ignoring '%s' activity", act.Type)
+		writeJsonResponse(hg.logger, w, rtActivityJson, "OK")
+		return
+	}
+
+	hg.enqueueActivity(userName, bodyBytes, senderInfo, act, w)
 }
 
-func (hg *apubHandlerGroup) processActivity(
+// Activities with a verified signature are stored and processed in the background; the sender gets 202 right away
+func (hg *apubHandlerGroup) enqueueActivity(
 	userName string,
 	bodyBytes []byte,
 	senderInfo *dto.UserInfo,
@@ -271,39 +382,38 @@ func (hg *apubHandlerGroup) processActivity(
 
 	var err error
 
-	// Find out Object's type if object field is, well, an object
-	// If yes, grab object type field
-	objectType := ""
-	if objMap, ok := act.Object.(map[string]interface{}); ok {
-		if objTypeStr, ok := objMap["type"].(string); ok {
-			objectType = objTypeStr
+	// Suspended domains are refused outright; from silenced ones, we only take follow-related activities
+	senderHost, hostErr := shared.GetHostName(senderInfo.Id)
+	var blockLevel int
+	if hostErr == nil {
+		if blockLevel, err = hg.dblocks.GetLevel(senderHost); err != nil {
+			hg.logger.Errorf("Failed to check domain block list: %v", err)
+			blockLevel = 0
 		}
 	}
-
-	// Handle different activities
-	var reqProblem string
-	if act.Type == "Follow" {
-		reqProblem, err = hg.inbox.HandleFollow(userName, senderInfo, bodyBytes)
-	} else if act.Type == "Undo" {
-		reqProblem, err = hg.inbox.HandleUndo(userName, senderInfo, bodyBytes)
-	} else if act.Type == "Create" {
-		if objectType == "Note" {
-			reqProblem, err = hg.inbox.HandleCreateNote(act, senderInfo, bodyBytes)
-		}
+	if hostErr != nil || blockLevel >= dal.DomainSuspend {
+		hg.logger.Infof("Refusing inbox POST from blocked domain %s", senderHost)
+		writeErrorResponse(w, "Forbidden", http.StatusForbidden)
+		return
 	}
-
-	if err != nil {
-		hg.logger.Errorf("Error handling inbox activity: %v", err)
-		writeErrorResponse(w, internalErrorStr, http.StatusInternalServerError)
+	// Silenced domains can still manage their follows, and report abuse
+	isFollowRelated := act.Type == "Follow" || act.Type == "Undo" || act.Type == "Accept" || act.Type == "Reject" ||
+		act.Type == "Block"
+	if blockLevel >= dal.DomainSilence && !isFollowRelated && act.Type != "Flag" {
+		hg.logger.Infof("Ignoring '%s' activity from silenced domain %s", act.Type, senderHost)
+		writeJsonResponse(hg.logger, w, rtActivityJson, "OK")
 		return
 	}
 
-	if reqProblem != "" {
-		hg.logger.Infof("Invalid '%s' request: %s", act.Type, reqProblem)
-		msg := fmt.Sprintf("Bad request: %s", reqProblem)
-		writeErrorResponse(w, msg, http.StatusBadRequest)
+	// Whoever talks to us is reachable: if we had stopped delivering to their host, resume
+	hg.msgr.ResumeHost(senderHost)
+
+	if err = hg.inq.Enqueue(userName, senderInfo, bodyBytes); err != nil {
+		hg.logger.Errorf("Failed to queue inbox activity: %v", err)
+		writeErrorResponse(w, internalErrorStr, http.StatusInternalServerError)
 		return
 	}
 
-	writeJsonResponse(hg.logger, w, rtActivityJson, "OK")
+	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
 }
@@ -28,6 +28,7 @@ const (
 	rtPlainJson = iota
 	rtActivityJson
 	rtJrdJson
+	rtNodeInfoJson
 )
 
 // Defines a single HTTP handler (endpoint)
@@ -60,10 +61,19 @@ func acceptsJson(r *http.Request) bool {
 
 // Returns the JSON serialized object as the response body; handles errors.
 func writeJsonResponse(logger shared.ILogger, w http.ResponseWriter, rt responseType, resp interface{}) {
+	writeJsonStatusResponse(logger, w, rt, http.StatusOK, resp)
+}
+
+// Like writeJsonResponse, but with a status other than 200; headers must all be set before the status is written
+func writeJsonStatusResponse(logger shared.ILogger, w http.ResponseWriter, rt responseType, status int,
+	resp interface{}) {
 	if rt == rtActivityJson {
 		w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
 	} else if rt == rtJrdJson {
 		w.Header().Set("Content-Type", "application/jrd+json; charset=utf-8")
+	} else if rt == rtNodeInfoJson {
+		w.Header().Set("Content-Type",
+			`application/json; profile="http://nodeinfo.diaspora.software/ns/schema/2.1#"; charset=utf-8`)
 	} else {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	}
@@ -74,6 +84,9 @@ func writeJsonResponse(logger shared.ILogger, w http.ResponseWriter, rt response
 		http.Error(w, internalErrorStr, http.StatusInternalServerError)
 		return
 	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
 	if _, err = fmt.Fprintln(w, string(respJson)); err != nil {
 		logger.Warnf("Failed to write response: %v\n", err)
 		http.Error(w, internalErrorStr, http.StatusInternalServerError)
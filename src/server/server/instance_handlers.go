@@ -0,0 +1,225 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/texts"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	nodeInfoSchema    = "http://nodeinfo.diaspora.software/ns/schema/2.1"
+	softwareName      = "rss-parrot"
+	softwareTitle     = "RSS Parrot"
+	softwareRepo      = "https://github.com/gugray/rss-parrot"
+	instanceStatsLife = 10 * time.Minute
+)
+
+// Serves what crawlers and clients ask about the instance itself: NodeInfo, host-meta, Mastodon's instance API.
+type instanceHandlerGroup struct {
+	cfg     *shared.Config
+	logger  shared.ILogger
+	repo    dal.IRepo
+	txt     texts.ITexts
+	metrics logic.IMetrics
+	idb     shared.IdBuilder
+	version string
+	muStats sync.Mutex
+	stats   *instanceStats
+}
+
+// Counts are expensive on a big DB, and nobody needs them to be fresh
+type instanceStats struct {
+	queriedAt       time.Time
+	accounts        int
+	posts           int
+	feedFollowers   int
+	followerDomains int
+}
+
+func NewInstanceHandlerGroup(
+	cfg *shared.Config,
+	logger shared.ILogger,
+	repo dal.IRepo,
+	txt texts.ITexts,
+	metrics logic.IMetrics,
+) IHandlerGroup {
+	res := instanceHandlerGroup{
+		cfg:     cfg,
+		logger:  logger,
+		repo:    repo,
+		txt:     txt,
+		metrics: metrics,
+		idb:     shared.IdBuilder{Host: cfg.Host},
+	}
+	versionBytes, _ := os.ReadFile(wwwPathPrefx + versionFileName)
+	res.version = strings.TrimPrefix(strings.TrimSpace(string(versionBytes)), "v")
+	return &res
+}
+
+func (hg *instanceHandlerGroup) Prefix() string {
+	return ""
+}
+
+func (hg *instanceHandlerGroup) GroupDefs() []handlerDef {
+	return []handlerDef{
+		{"GET", "/.well-known/nodeinfo", func(w http.ResponseWriter, r *http.Request) { hg.getNodeInfoLinks(w, r) }},
+		{"GET", "/nodeinfo/2.1", func(w http.ResponseWriter, r *http.Request) { hg.getNodeInfo(w, r) }},
+		{"GET", "/.well-known/host-meta", func(w http.ResponseWriter, r *http.Request) { hg.getHostMeta(w, r) }},
+		{"GET", "/api/v1/instance", func(w http.ResponseWriter, r *http.Request) { hg.getInstance(w, r) }},
+	}
+}
+
+func (hg *instanceHandlerGroup) AuthMW() func(next http.Handler) http.Handler {
+	return emptyMW
+}
+
+func (hg *instanceHandlerGroup) getStats() (*instanceStats, error) {
+
+	hg.muStats.Lock()
+	defer hg.muStats.Unlock()
+
+	if hg.stats != nil && time.Since(hg.stats.queriedAt) < instanceStatsLife {
+		return hg.stats, nil
+	}
+
+	var err error
+	stats := instanceStats{queriedAt: time.Now()}
+	if _, stats.accounts, err = hg.repo.GetAccountsPage(0, 1); err != nil {
+		return nil, err
+	}
+	var posts uint
+	if posts, err = hg.repo.GetTotalPostCount(); err != nil {
+		return nil, err
+	}
+	stats.posts = int(posts)
+	if stats.feedFollowers, err = hg.repo.GetFeedFollowerCount(); err != nil {
+		return nil, err
+	}
+	if stats.followerDomains, err = hg.repo.GetFollowerDomainCount(); err != nil {
+		return nil, err
+	}
+	hg.stats = &stats
+	return hg.stats, nil
+}
+
+func (hg *instanceHandlerGroup) getNodeInfoLinks(w http.ResponseWriter, r *http.Request) {
+
+	hg.logger.Infof("Handling NodeInfo discovery GET: %s", r.URL.Path)
+	obs := hg.metrics.StartApubRequestIn("nodeinfo")
+	defer obs.Finish()
+
+	resp := dto.NodeInfoLinks{
+		Links: []dto.NodeInfoLink{{
+			Rel:  nodeInfoSchema,
+			Href: fmt.Sprintf("https://%s/nodeinfo/2.1", hg.cfg.Host),
+		}},
+	}
+	writeJsonResponse(hg.logger, w, rtPlainJson, resp)
+}
+
+func (hg *instanceHandlerGroup) getNodeInfo(w http.ResponseWriter, r *http.Request) {
+
+	hg.logger.Infof("Handling NodeInfo GET: %s", r.URL.Path)
+	obs := hg.metrics.StartApubRequestIn("nodeinfo")
+	defer obs.Finish()
+
+	stats, err := hg.getStats()
+	if err != nil {
+		hg.logger.Errorf("Failed to get instance stats: %v", err)
+		writeErrorResponse(w, internalErrorStr, http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.NodeInfo{
+		Version: "2.1",
+		Software: dto.NodeInfoSoftware{
+			Name:       softwareName,
+			Version:    hg.version,
+			Repository: softwareRepo,
+			Homepage:   hg.idb.SiteUrl(),
+		},
+		Protocols: []string{"activitypub"},
+		// We consume feeds; we don't publish any
+		Services: dto.NodeInfoServices{
+			Inbound:  []string{"atom1.0", "rss2.0"},
+			Outbound: []string{},
+		},
+		OpenRegistrations: false,
+		Usage: dto.NodeInfoUsage{
+			Users:      dto.NodeInfoUsers{Total: stats.accounts},
+			LocalPosts: stats.posts,
+		},
+		Metadata: dto.NodeInfoMetadata{
+			NodeName:        softwareTitle,
+			NodeDesc:        hg.txt.Get("instance_desc.txt"),
+			FeedFollowers:   stats.feedFollowers,
+			FollowerServers: stats.followerDomains,
+		},
+	}
+	writeJsonResponse(hg.logger, w, rtNodeInfoJson, resp)
+}
+
+func (hg *instanceHandlerGroup) getHostMeta(w http.ResponseWriter, r *http.Request) {
+
+	hg.logger.Infof("Handling host-meta GET: %s", r.URL.Path)
+	obs := hg.metrics.StartApubRequestIn("host-meta")
+	defer obs.Finish()
+
+	w.Header().Set("Content-Type", "application/xrd+xml; charset=utf-8")
+	xrd := `<?xml version="1.0" encoding="UTF-8"?>
+<XRD xmlns="http://docs.oasis-open.org/ns/xri/xrd-1.0">
+  <Link rel="lrdd" template="https://%s/.well-known/webfinger?resource={uri}"/>
+</XRD>
+`
+	if _, err := fmt.Fprintf(w, xrd, hg.cfg.Host); err != nil {
+		hg.logger.Warnf("Failed to write response: %v", err)
+	}
+}
+
+func (hg *instanceHandlerGroup) getInstance(w http.ResponseWriter, r *http.Request) {
+
+	hg.logger.Infof("Handling instance GET: %s", r.URL.Path)
+	obs := hg.metrics.StartApubRequestIn("instance")
+	defer obs.Finish()
+
+	stats, err := hg.getStats()
+	if err != nil {
+		hg.logger.Errorf("Failed to get instance stats: %v", err)
+		writeErrorResponse(w, internalErrorStr, http.StatusInternalServerError)
+		return
+	}
+
+	desc := hg.txt.Get("instance_desc.txt")
+	resp := dto.MastodonInstance{
+		Uri:              hg.cfg.Host,
+		Title:            softwareTitle,
+		ShortDescription: desc,
+		Description:      desc,
+		Email:            "",
+		// Clients parse the version to find out what the API can do; we claim the oldest that has this entity
+		Version: fmt.Sprintf("3.0.0 (compatible; %s %s)", softwareTitle, hg.version),
+		Urls:    map[string]string{},
+		Stats: dto.MastodonInstanceStats{
+			UserCount:   stats.accounts,
+			StatusCount: stats.posts,
+			DomainCount: stats.followerDomains,
+		},
+		Thumbnail:        hg.cfg.Birb.HeaderPic,
+		Languages:        []string{"en"},
+		Registrations:    false,
+		ApprovalRequired: false,
+		InvitesEnabled:   false,
+		ContactAccount:   nil,
+		Rules:            []any{},
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	writeJsonResponse(hg.logger, w, rtPlainJson, resp)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"strings"
+	"testing"
+)
+
+type dummyObserver struct{}
+
+func (dummyObserver) Finish() {}
+
+func setupInstanceTest(t *testing.T) (*gomock.Controller, *instanceHandlerGroup) {
+
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks.NewMockILogger(ctrl)
+	mockLogger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	mockRepo := mocks.NewMockIRepo(ctrl)
+	mockRepo.EXPECT().GetAccountsPage(gomock.Any(), gomock.Any()).Return(nil, 42, nil).AnyTimes()
+	mockRepo.EXPECT().GetTotalPostCount().Return(uint(1234), nil).AnyTimes()
+	mockRepo.EXPECT().GetFeedFollowerCount().Return(99, nil).AnyTimes()
+	mockRepo.EXPECT().GetFollowerDomainCount().Return(7, nil).AnyTimes()
+	mockTexts := mocks.NewMockITexts(ctrl)
+	mockTexts.EXPECT().Get(gomock.Eq("instance_desc.txt")).Return("Parrots feeds").AnyTimes()
+	mockMetrics := mocks.NewMockIMetrics(ctrl)
+	mockMetrics.EXPECT().StartApubRequestIn(gomock.Any()).Return(dummyObserver{}).AnyTimes()
+
+	cfg := &shared.Config{Host: "parrot.net", Birb: &shared.UserInfo{User: "birb"}}
+	hg := NewInstanceHandlerGroup(cfg, mockLogger, mockRepo, mockTexts, mockMetrics).(*instanceHandlerGroup)
+	return ctrl, hg
+}
+
+func Test_Instance_NodeInfo(t *testing.T) {
+
+	ctrl, hg := setupInstanceTest(t)
+	defer ctrl.Finish()
+
+	w := httptest.NewRecorder()
+	hg.getNodeInfoLinks(w, httptest.NewRequest("GET", "/.well-known/nodeinfo", nil))
+	var links map[string]any
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &links))
+	link := links["links"].([]any)[0].(map[string]any)
+	assert.Equal(t, "http://nodeinfo.diaspora.software/ns/schema/2.1", link["rel"])
+	assert.Equal(t, "https://parrot.net/nodeinfo/2.1", link["href"])
+
+	w = httptest.NewRecorder()
+	hg.getNodeInfo(w, httptest.NewRequest("GET", "/nodeinfo/2.1", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "application/json; profile="))
+	var ni map[string]any
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &ni))
+	assert.Equal(t, "2.1", ni["version"])
+	assert.Equal(t, "rss-parrot", ni["software"].(map[string]any)["name"])
+	assert.Equal(t, []any{"activitypub"}, ni["protocols"])
+	// We read feeds, we don't publish them; empty lists are still lists
+	services := ni["services"].(map[string]any)
+	assert.Equal(t, []any{"atom1.0", "rss2.0"}, services["inbound"])
+	assert.Equal(t, []any{}, services["outbound"])
+	assert.Equal(t, false, ni["openRegistrations"])
+	usage := ni["usage"].(map[string]any)
+	assert.Equal(t, float64(42), usage["users"].(map[string]any)["total"])
+	assert.Equal(t, float64(1234), usage["localPosts"])
+	assert.Equal(t, "Parrots feeds", ni["metadata"].(map[string]any)["nodeDescription"])
+}
+
+func Test_Instance_Host_Meta(t *testing.T) {
+
+	ctrl, hg := setupInstanceTest(t)
+	defer ctrl.Finish()
+
+	w := httptest.NewRecorder()
+	hg.getHostMeta(w, httptest.NewRequest("GET", "/.well-known/host-meta", nil))
+	assert.Equal(t, "application/xrd+xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(),
+		`<Link rel="lrdd" template="https://parrot.net/.well-known/webfinger?resource={uri}"/>`)
+}
+
+func Test_Instance_Mastodon_Api(t *testing.T) {
+
+	ctrl, hg := setupInstanceTest(t)
+	defer ctrl.Finish()
+
+	w := httptest.NewRecorder()
+	hg.getInstance(w, httptest.NewRequest("GET", "/api/v1/instance", nil))
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	var inst map[string]any
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &inst))
+	assert.Equal(t, "parrot.net", inst["uri"])
+	assert.True(t, strings.HasPrefix(inst["version"].(string), "3.0.0 (compatible; RSS Parrot"))
+	stats := inst["stats"].(map[string]any)
+	assert.Equal(t, float64(42), stats["user_count"])
+	assert.Equal(t, float64(1234), stats["status_count"])
+	assert.Equal(t, float64(7), stats["domain_count"])
+	assert.Equal(t, false, inst["registrations"])
+	assert.Equal(t, []any{}, inst["rules"])
+}
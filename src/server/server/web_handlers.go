@@ -4,22 +4,33 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	"html/template"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"rss_parrot/dal"
 	"rss_parrot/logic"
 	"rss_parrot/shared"
 	"rss_parrot/texts"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const versionFileName = "version.txt"
+const subscribeRel = "http://ostatus.org/schema/1.0/subscribe"
 const feedsPerPage = 200
 const postsPerPage = 100
 
+// Remote follow lookups fetch from hosts that visitors enter. We limit them per minute for each host,
+// so one busy (or abusive) host doesn't shut out everyone else, and under a global ceiling.
+// Per host, not per client IP, because behind a reverse proxy every visitor has the same address.
+const followLookupsPerHostPerMin = 10
+const followLookupsPerMin = 300
+
 var months = []string{
 	"January", "February", "March", "April", "May", "June",
 	"July", "August", "September", "October", "November", "December",
@@ -31,10 +42,17 @@ type webHandlerGroup struct {
 	repo          dal.IRepo
 	txt           texts.ITexts
 	metrics       logic.IMetrics
+	userRetriever logic.IUserRetriever
 	idb           shared.IdBuilder
 	version       string
 	timestamp     string
 	pageTemplates map[string]*template.Template
+	reHandle      *regexp.Regexp
+	reFeedPath    *regexp.Regexp
+	muLookups     sync.Mutex
+	lookupsSince  time.Time // Start of the current one-minute window of remote follow lookups
+	lookupCount   int
+	hostLookups   map[string]int // Lookups per visitor host in the current window
 }
 
 func NewWebHandlerGroup(
@@ -43,6 +61,7 @@ func NewWebHandlerGroup(
 	repo dal.IRepo,
 	txt texts.ITexts,
 	metrics logic.IMetrics,
+	userRetriever logic.IUserRetriever,
 ) IHandlerGroup {
 	res := webHandlerGroup{
 		cfg:           cfg,
@@ -50,12 +69,16 @@ func NewWebHandlerGroup(
 		repo:          repo,
 		txt:           txt,
 		metrics:       metrics,
+		userRetriever: userRetriever,
 		idb:           shared.IdBuilder{cfg.Host},
 		timestamp:     fmt.Sprintf("%d", time.Now().UnixMilli()),
 		pageTemplates: make(map[string]*template.Template),
+		hostLookups:   make(map[string]int),
 	}
 	versionBytes, _ := os.ReadFile(wwwPathPrefx + versionFileName)
 	res.version = string(versionBytes)
+	res.reHandle = regexp.MustCompile(`^@?([^@\s/]+)@([^@\s/:]+)$`)
+	res.reFeedPath = regexp.MustCompile(`^/(?:u|web/feeds)/([^/]+)$`)
 	res.initTemplates()
 	return &res
 }
@@ -67,6 +90,8 @@ func (hg *webHandlerGroup) Prefix() string {
 func (hg *webHandlerGroup) GroupDefs() []handlerDef {
 	return []handlerDef{
 		{"GET", "/feeds/{feed}", func(w http.ResponseWriter, r *http.Request) { hg.getOneFeed(w, r) }},
+		{"GET", "/feeds/{feed}/follow", func(w http.ResponseWriter, r *http.Request) { hg.getFollowFromInstance(w, r) }},
+		{"GET", "/interact", func(w http.ResponseWriter, r *http.Request) { hg.getInteract(w, r) }},
 		{"GET", "/feeds", func(w http.ResponseWriter, r *http.Request) { hg.getFeeds(w, r) }},
 		{"GET", "/changes", func(w http.ResponseWriter, r *http.Request) { hg.getChanges(w, r) }},
 		{"GET", "/about", func(w http.ResponseWriter, r *http.Request) { hg.getAbout(w, r) }},
@@ -310,6 +335,7 @@ func (hg *webHandlerGroup) getFeeds(w http.ResponseWriter, r *http.Request) {
 }
 
 type oneFeedModel struct {
+	Account         string
 	Handle          string
 	Name            string
 	Bio             template.HTML
@@ -321,13 +347,22 @@ type oneFeedModel struct {
 	PostCount       uint
 	Posts           []*dal.FeedPost
 	NotShownPosts   uint
+	FollowHandle    string // What the visitor entered to follow the feed from their instance
+	FollowError     string
+	IsBundle        bool
+	Members         []*dal.Account // Feeds a bundle boosts
+	Bundles         []*dal.Account // Bundles a feed is in
 }
 
 func (hg *webHandlerGroup) loadFeedData(acct *dal.Account) *oneFeedModel {
 
 	var err error
 
-	bio := hg.txt.WithVals("acct_bio.html", map[string]string{
+	bioSnippet := "acct_bio.html"
+	if acct.IsBundle() {
+		bioSnippet = "bundle_bio.html"
+	}
+	bio := hg.txt.WithVals(bioSnippet, map[string]string{
 		"siteUrl":     hg.idb.SiteUrl(),
 		"description": acct.FeedSummary,
 	})
@@ -342,6 +377,7 @@ func (hg *webHandlerGroup) loadFeedData(acct *dal.Account) *oneFeedModel {
 	}
 
 	data := oneFeedModel{
+		Account:       acct.Handle,
 		Handle:        shared.MakeFullMoniker(hg.cfg.Host, acct.Handle),
 		Name:          shared.GetNameWithParrot(acct.FeedName),
 		Bio:           template.HTML(bio),
@@ -355,6 +391,17 @@ func (hg *webHandlerGroup) loadFeedData(acct *dal.Account) *oneFeedModel {
 	data.SiteUrlNoSchema = strings.TrimPrefix(data.SiteUrl, "https://")
 	data.SiteUrlNoSchema = strings.TrimPrefix(data.SiteUrlNoSchema, "http://")
 
+	data.IsBundle = acct.IsBundle()
+	if data.IsBundle {
+		data.Members, err = hg.repo.GetBundleMembers(acct.Id)
+	} else {
+		data.Bundles, err = hg.repo.GetBundlesOfMember(acct.Id)
+	}
+	if err != nil {
+		hg.logger.Errorf("Error retrieving bundle membership for %s: %v", acct.Handle, err)
+		return nil
+	}
+
 	data.Posts, err = hg.repo.GetPostsPage(acct.Id, 0, postsPerPage)
 	if err != nil {
 		hg.logger.Errorf("Error retrieving posts for %s: %v", acct.Handle, err)
@@ -372,19 +419,16 @@ func (hg *webHandlerGroup) loadFeedData(acct *dal.Account) *oneFeedModel {
 	return &data
 }
 
-func (hg *webHandlerGroup) getOneFeed(w http.ResponseWriter, r *http.Request) {
+// Gets the feed's account for the page; nil if a redirect or error page has been sent instead
+func (hg *webHandlerGroup) getFeedAccount(w http.ResponseWriter, r *http.Request) *dal.Account {
 
-	obs := hg.metrics.StartWebRequestIn("/feeds/<feed>")
-	defer obs.Finish()
-
-	hg.logger.Infof("Handling user GET: %s", r.URL.Path)
 	feedName := mux.Vars(r)["feed"]
 	feedName = strings.ToLower(feedName)
 
 	if feedName == hg.cfg.Birb.User {
 		hg.logger.Infof("Requesting profile of '%s'; redirecting to root", hg.cfg.Birb.User)
 		http.Redirect(w, r, "/", http.StatusSeeOther)
-		return
+		return nil
 	}
 
 	acct, err := hg.repo.GetAccount(feedName)
@@ -392,12 +436,24 @@ func (hg *webHandlerGroup) getOneFeed(w http.ResponseWriter, r *http.Request) {
 		if err == nil {
 			hg.logger.Infof("Feed '%s' doesn't exist; returning a 404", feedName)
 			hg.send404(w, r)
-			return
 		} else {
 			hg.logger.Errorf("Error retrieving feed %s: %v", feedName, err)
 			hg.send500(w, r)
-			return
 		}
+		return nil
+	}
+	return acct
+}
+
+func (hg *webHandlerGroup) getOneFeed(w http.ResponseWriter, r *http.Request) {
+
+	obs := hg.metrics.StartWebRequestIn("/feeds/<feed>")
+	defer obs.Finish()
+
+	hg.logger.Infof("Handling user GET: %s", r.URL.Path)
+	acct := hg.getFeedAccount(w, r)
+	if acct == nil {
+		return
 	}
 
 	data := hg.loadFeedData(acct)
@@ -405,6 +461,10 @@ func (hg *webHandlerGroup) getOneFeed(w http.ResponseWriter, r *http.Request) {
 		hg.send500(w, r)
 		return
 	}
+	hg.sendOneFeed(w, data)
+}
+
+func (hg *webHandlerGroup) sendOneFeed(w http.ResponseWriter, data *oneFeedModel) {
 
 	t, model := hg.mustGetPageTemplate("one-feed")
 	model.LnkFeedsClass = "selected"
@@ -413,3 +473,114 @@ func (hg *webHandlerGroup) getOneFeed(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Robots-Tag", "noindex")
 	t.ExecuteTemplate(w, "index.tmpl", model)
 }
+
+// Visitor wants to follow the feed from their own instance: we find out where their instance handles
+// remote follows, and send them there.
+func (hg *webHandlerGroup) getFollowFromInstance(w http.ResponseWriter, r *http.Request) {
+
+	obs := hg.metrics.StartWebRequestIn("/feeds/<feed>/follow")
+	defer obs.Finish()
+
+	hg.logger.Infof("Handling remote follow GET: %s", r.URL.Path)
+	acct := hg.getFeedAccount(w, r)
+	if acct == nil {
+		return
+	}
+
+	handle := strings.TrimSpace(r.URL.Query().Get("handle"))
+	subscribeUrl, problem := hg.getSubscribeUrl(handle, hg.idb.UserUrl(acct.Handle))
+	if problem == "" {
+		hg.logger.Infof("Redirecting %s to follow %s: %s", handle, acct.Handle, subscribeUrl)
+		http.Redirect(w, r, subscribeUrl, http.StatusSeeOther)
+		return
+	}
+
+	data := hg.loadFeedData(acct)
+	if data == nil {
+		hg.send500(w, r)
+		return
+	}
+	data.FollowHandle = handle
+	data.FollowError = problem
+	hg.sendOneFeed(w, data)
+}
+
+// Returns the URL on the visitor's instance where they can follow the target; or a problem to show them
+func (hg *webHandlerGroup) getSubscribeUrl(handle, target string) (string, string) {
+
+	groups := hg.reHandle.FindStringSubmatch(handle)
+	if groups == nil {
+		return "", "Enter your full handle, like @you@your.instance"
+	}
+	user, host := groups[1], strings.ToLower(groups[2])
+	if net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return "", "Enter your full handle, like @you@your.instance"
+	}
+
+	if !hg.allowLookup(host) {
+		hg.logger.Warnf("Too many remote follow lookups; refusing %s@%s", user, host)
+		return "", "We are getting too many follow requests right now; please try again in a minute"
+	}
+
+	wf, err := hg.userRetriever.RetrieveWebfinger(user, host)
+	if err != nil {
+		hg.logger.Infof("Webfinger lookup of %s@%s failed: %v", user, host, err)
+		return "", fmt.Sprintf("We could not find @%s@%s", user, host)
+	}
+	for _, link := range wf.Links {
+		if link.Rel == subscribeRel && strings.Contains(link.Template, "{uri}") &&
+			strings.HasPrefix(link.Template, "https://") {
+			return strings.Replace(link.Template, "{uri}", url.QueryEscape(target), 1), ""
+		}
+	}
+	return "", fmt.Sprintf("Your instance, %s, does not tell us how to follow from there", host)
+}
+
+// Counts a remote follow lookup to host; false if we have done too many in the last minute,
+// either to this host or overall
+func (hg *webHandlerGroup) allowLookup(host string) bool {
+
+	hg.muLookups.Lock()
+	defer hg.muLookups.Unlock()
+
+	if time.Since(hg.lookupsSince) > time.Minute {
+		hg.lookupsSince = time.Now()
+		hg.lookupCount = 0
+		clear(hg.hostLookups)
+	}
+	if hg.lookupCount >= followLookupsPerMin || hg.hostLookups[host] >= followLookupsPerHostPerMin {
+		return false
+	}
+	hg.lookupCount++
+	hg.hostLookups[host]++
+	return true
+}
+
+// Remote follow and similar flows that end up on our instance. We have no users who could interact,
+// so we show the feed if the target is one of ours.
+func (hg *webHandlerGroup) getInteract(w http.ResponseWriter, r *http.Request) {
+
+	obs := hg.metrics.StartWebRequestIn("/interact")
+	defer obs.Finish()
+
+	uri := r.URL.Query().Get("uri")
+	hg.logger.Infof("Handling interaction GET: %s", uri)
+
+	feedName := ""
+	target := strings.TrimPrefix(strings.TrimPrefix(uri, "acct:"), "@")
+	if user, host, found := strings.Cut(target, "@"); found && !strings.Contains(target, "/") {
+		if strings.EqualFold(host, hg.cfg.Host) {
+			feedName = user
+		}
+	} else if parsed, err := url.Parse(uri); err == nil && strings.EqualFold(parsed.Host, hg.cfg.Host) {
+		if groups := hg.reFeedPath.FindStringSubmatch(parsed.Path); groups != nil {
+			feedName = groups[1]
+		}
+	}
+
+	if feedName == "" {
+		hg.send404(w, r)
+		return
+	}
+	http.Redirect(w, r, hg.idb.UserProfile(strings.ToLower(feedName)), http.StatusSeeOther)
+}
@@ -23,6 +23,10 @@ type Config struct {
 	Host               string         `json:"host"`
 	DbFile             string         `json:"db_file"`
 	BlockedFeedsFile   string         `json:"blocked_feeds_file"`
+	BlockedDomainsFile string         `json:"blocked_domains_file"`
+	AuthorizedFetch    bool           `json:"authorized_fetch"`
+	SigMaxSkewSec      int            `json:"sig_max_skew_sec"`
+	ActorCacheHours    int            `json:"actor_cache_hours"`
 	ProfileDir         string         `json:"profile_dir"`
 	ProfileKeepDays    int            `json:"profile_keep_days"`
 	CachePageTemplates bool           `json:"cache_page_templates"`
@@ -30,7 +34,12 @@ type Config struct {
 	PostsMinCountKept  int            `json:"posts_min_count_kept"`
 	PostsMinDaysKept   int            `json:"posts_min_days_kept"`
 	PurgeWaitSec       int            `json:"purge_wait_sec"`
+	ProfileUpdateMinHr int            `json:"profile_update_min_hr"`
 	FallbackProfilePic string         `json:"fallback_profile_pic"`
+	MaxUrlsPerMention  int            `json:"max_urls_per_mention"`
+	Delivery           Delivery       `json:"delivery"`
+	Inbound            Inbound        `json:"inbound"`
+	Relays             []Relay        `json:"relays"`
 	Birb               *UserInfo      `json:"birb"`
 }
 
@@ -41,6 +50,29 @@ type UpdateSchedule struct {
 	Older  int `json:"older"`
 }
 
+type Delivery struct {
+	MaxParallel   int `json:"max_parallel"`    // Deliveries in flight at the same time, across all hosts
+	MaxPerHost    int `json:"max_per_host"`    // Deliveries in flight at the same time to a single host
+	MaxAttempts   int `json:"max_attempts"`    // Queue item is dropped after this many failed attempts
+	RetryBaseSec  int `json:"retry_base_sec"`  // Wait after first failure; doubles with each further failure
+	RetryMaxSec   int `json:"retry_max_sec"`   // Upper limit of wait between attempts
+	DeadInboxDays int `json:"dead_inbox_days"` // Inboxes failing for this long get no deliveries until they respond
+}
+
+type Inbound struct {
+	Workers      int `json:"workers"`        // Inbound activities processed at the same time
+	MaxAttempts  int `json:"max_attempts"`   // Activity is dropped after this many failed attempts
+	RetryBaseSec int `json:"retry_base_sec"` // Wait after first failure; doubles with each further failure
+}
+
+// ActivityPub relay that the birb subscribes to, and announces new accounts to
+type Relay struct {
+	Inbox string `json:"inbox"` // Where we send the Follow and our announcements
+	// LitePub relays: the relay's actor, which we follow. Also needed for relays whose Accept doesn't include
+	// our Follow's ID. Empty for other Mastodon-style relays.
+	Actor string `json:"actor"`
+}
+
 type UserInfo struct {
 	User                    string    `json:"user"`
 	Published               time.Time `json:"published"`
@@ -0,0 +1,205 @@
+package shared
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Object integrity proofs (FEP-8b32) using the eddsa-jcs-2022 cryptosuite of the W3C Data Integrity spec.
+
+const (
+	DataIntegrityContext = "https://w3id.org/security/data-integrity/v2"
+	MultikeyContext      = "https://w3id.org/security/multikey/v1"
+	eddsaJcs2022         = "eddsa-jcs-2022"
+)
+
+func decodeJsonObject(docJson []byte) (map[string]any, error) {
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(docJson))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, errors.New("document is not a JSON object")
+	}
+	return doc, nil
+}
+
+// The data that is actually signed: hash of the proof options, followed by the hash of the document
+func getProofHashData(proofOptions, unsecuredDoc map[string]any) ([]byte, error) {
+	optionsJcs, err := CanonicalizeValue(proofOptions)
+	if err != nil {
+		return nil, err
+	}
+	docJcs, err := CanonicalizeValue(unsecuredDoc)
+	if err != nil {
+		return nil, err
+	}
+	optionsHash := sha256.Sum256(optionsJcs)
+	docHash := sha256.Sum256(docJcs)
+	return append(optionsHash[:], docHash[:]...), nil
+}
+
+// Adds the data integrity context to the document and attaches an eddsa-jcs-2022 proof.
+func AddEddsaJcsProof(
+	docJson []byte,
+	privKey ed25519.PrivateKey,
+	verificationMethod string,
+	created time.Time,
+) ([]byte, error) {
+
+	doc, err := decodeJsonObject(docJson)
+	if err != nil {
+		return nil, err
+	}
+	delete(doc, "proof")
+
+	// Make sure the context defines the proof's terms
+	switch ctx := doc["@context"].(type) {
+	case string:
+		doc["@context"] = []any{ctx, DataIntegrityContext}
+	case []any:
+		found := false
+		for _, item := range ctx {
+			found = found || item == DataIntegrityContext
+		}
+		if !found {
+			doc["@context"] = append(ctx, DataIntegrityContext)
+		}
+	}
+
+	proof := map[string]any{
+		"type":               "DataIntegrityProof",
+		"cryptosuite":        eddsaJcs2022,
+		"verificationMethod": verificationMethod,
+		"proofPurpose":       "assertionMethod",
+		"created":            created.UTC().Format(time.RFC3339),
+	}
+	if ctx, ok := doc["@context"]; ok {
+		proof["@context"] = ctx
+	}
+
+	hashData, err := getProofHashData(proof, doc)
+	if err != nil {
+		return nil, err
+	}
+	proof["proofValue"] = "z" + Base58Encode(ed25519.Sign(privKey, hashData))
+	doc["proof"] = proof
+
+	return json.Marshal(doc)
+}
+
+func getEddsaJcsProof(doc map[string]any) map[string]any {
+	var candidates []any
+	switch p := doc["proof"].(type) {
+	case map[string]any:
+		candidates = []any{p}
+	case []any:
+		candidates = p
+	}
+	for _, c := range candidates {
+		if proof, ok := c.(map[string]any); ok && proof["cryptosuite"] == eddsaJcs2022 {
+			return proof
+		}
+	}
+	return nil
+}
+
+// Returns the verification method (key ID) of the document's eddsa-jcs-2022 proof; "" if there is none.
+func GetProofVerificationMethod(docJson []byte) string {
+	doc, err := decodeJsonObject(docJson)
+	if err != nil {
+		return ""
+	}
+	proof := getEddsaJcsProof(doc)
+	if proof == nil {
+		return ""
+	}
+	vm, _ := proof["verificationMethod"].(string)
+	return vm
+}
+
+// Verifies the document's eddsa-jcs-2022 proof with the given key.
+func VerifyEddsaJcsProof(docJson []byte, pubKey ed25519.PublicKey) error {
+
+	doc, err := decodeJsonObject(docJson)
+	if err != nil {
+		return err
+	}
+	proof := getEddsaJcsProof(doc)
+	if proof == nil {
+		return errors.New("document has no eddsa-jcs-2022 proof")
+	}
+	if proof["type"] != "DataIntegrityProof" {
+		return fmt.Errorf("unsupported proof type: %v", proof["type"])
+	}
+	if proof["proofPurpose"] != "assertionMethod" {
+		return fmt.Errorf("unsupported proof purpose: %v", proof["proofPurpose"])
+	}
+
+	proofValue, _ := proof["proofValue"].(string)
+	if len(proofValue) < 2 || proofValue[0] != 'z' {
+		return errors.New("missing or invalid proofValue")
+	}
+	sig, err := Base58Decode(proofValue[1:])
+	if err != nil {
+		return err
+	}
+
+	proofOptions := make(map[string]any, len(proof))
+	for key, val := range proof {
+		if key != "proofValue" {
+			proofOptions[key] = val
+		}
+	}
+	unsecuredDoc := make(map[string]any, len(doc))
+	for key, val := range doc {
+		if key != "proof" {
+			unsecuredDoc[key] = val
+		}
+	}
+
+	// Proof's context must be where the document's context starts
+	if proofCtx, ok := proofOptions["@context"]; ok {
+		if !contextStartsWith(unsecuredDoc["@context"], proofCtx) {
+			return errors.New("proof's @context does not match document's")
+		}
+		unsecuredDoc["@context"] = proofCtx
+	}
+
+	hashData, err := getProofHashData(proofOptions, unsecuredDoc)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pubKey, hashData, sig) {
+		return errors.New("proof signature is invalid")
+	}
+	return nil
+}
+
+func contextStartsWith(docCtx, proofCtx any) bool {
+	asList := func(ctx any) []any {
+		if list, ok := ctx.([]any); ok {
+			return list
+		}
+		return []any{ctx}
+	}
+	docList := asList(docCtx)
+	proofList := asList(proofCtx)
+	if len(proofList) > len(docList) {
+		return false
+	}
+	for i := range proofList {
+		if !reflect.DeepEqual(docList[i], proofList[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package shared
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalizeJson(t *testing.T) {
+	// Example from RFC 8785, section 3.2.2
+	input := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+		`"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	res, err := CanonicalizeJson([]byte(input))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(res))
+
+	res, err = CanonicalizeJson([]byte(`[0, -0, 100, 1e20, 1e21, 0.000001, 1e-7, -12.5]`))
+	assert.Nil(t, err)
+	assert.Equal(t, `[0,0,100,100000000000000000000,1e+21,0.000001,1e-7,-12.5]`, string(res))
+
+	// Keys are sorted by UTF-16 code units, not by code points
+	res, err = CanonicalizeJson([]byte(`{"\ufb33": 1, "\ud83d\ude00": 2, "a": 3}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"a\":3,\"\U0001F600\":2,\"\uFB33\":1}", string(res))
+}
+
+func TestMultikey(t *testing.T) {
+	assert.Equal(t, "1112", Base58Encode([]byte{0, 0, 0, 1}))
+	decoded, err := Base58Decode("1112")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 1}, decoded)
+	_, err = Base58Decode("0OIl")
+	assert.NotNil(t, err)
+
+	// Example key from the Data Integrity EdDSA Cryptosuites spec
+	pubKey, err := DecodeEd25519Multikey("z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2")
+	assert.Nil(t, err)
+	assert.Equal(t, ed25519.PublicKeySize, len(pubKey))
+	assert.Equal(t, "z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2", EncodeEd25519Multikey(pubKey))
+
+	_, err = DecodeEd25519Multikey("uAQID")
+	assert.NotNil(t, err)
+}
+
+func TestEddsaJcsProof(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	keyId := "https://example.com/u/feed#ed25519-key"
+	doc := `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/a/1",` +
+		`"type":"Create","actor":"https://example.com/u/feed","object":{"type":"Note","content":"Hello <b>world</b>"}}`
+
+	signed, err := AddEddsaJcsProof([]byte(doc), privKey, keyId, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, keyId, GetProofVerificationMethod(signed))
+	assert.Nil(t, VerifyEddsaJcsProof(signed, pubKey))
+	assert.True(t, strings.Contains(string(signed), DataIntegrityContext))
+
+	// Formatting doesn't matter, only content
+	var indented bytes.Buffer
+	assert.Nil(t, json.Indent(&indented, signed, "", "    "))
+	assert.Nil(t, VerifyEddsaJcsProof(indented.Bytes(), pubKey))
+
+	// Tampered document, or someone else's key
+	tampered := strings.Replace(string(signed), "Hello", "Goodbye", 1)
+	assert.NotNil(t, VerifyEddsaJcsProof([]byte(tampered), pubKey))
+	otherPubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	assert.NotNil(t, VerifyEddsaJcsProof(signed, otherPubKey))
+
+	// No proof at all
+	assert.Equal(t, "", GetProofVerificationMethod([]byte(doc)))
+	assert.NotNil(t, VerifyEddsaJcsProof([]byte(doc), pubKey))
+}
@@ -0,0 +1,502 @@
+package shared
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Helpers for HTTP message signatures. Two schemes are in use in the fediverse: the older
+// draft-cavage-http-signatures (Signature header with keyId, headers, signature params), and RFC 9421
+// (Signature-Input and Signature headers as structured fields).
+
+const (
+	Rfc9421AlgRsaV15Sha256 = "rsa-v1_5-sha256"
+	Rfc9421AlgRsaPssSha512 = "rsa-pss-sha512"
+)
+
+// Parses the parameters of a draft-cavage Signature header: keyId="...",headers="...",signature="..."
+func ParseCavageParams(sigHeader string) map[string]string {
+	res := make(map[string]string)
+	for _, part := range splitOutsideQuotes(sigHeader, ',') {
+		key, val, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+			val = val[1 : len(val)-1]
+		}
+		res[strings.TrimSpace(key)] = val
+	}
+	return res
+}
+
+func splitOutsideQuotes(str string, sep byte) []string {
+	var res []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '\\':
+			if inQuotes {
+				i++
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case sep:
+			if !inQuotes {
+				res = append(res, str[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(res, str[start:])
+}
+
+// Checks a Digest header (RFC 3230) against the body. Only SHA-256 is accepted.
+func VerifyDigest(digestHeader string, body []byte) error {
+	if digestHeader == "" {
+		return errors.New("missing Digest header")
+	}
+	sum := sha256.Sum256(body)
+	expected := base64.StdEncoding.EncodeToString(sum[:])
+	for _, part := range strings.Split(digestHeader, ",") {
+		algo, val, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || !strings.EqualFold(algo, "SHA-256") {
+			continue
+		}
+		if val != expected {
+			return errors.New("SHA-256 digest does not match body")
+		}
+		return nil
+	}
+	return errors.New("no SHA-256 value in Digest header")
+}
+
+// Checks a Content-Digest header (RFC 9530) against the body. Accepts sha-256 and sha-512.
+func VerifyContentDigest(digestHeader string, body []byte) error {
+	if digestHeader == "" {
+		return errors.New("missing Content-Digest header")
+	}
+	members, err := parseSfDictionary(digestHeader)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Digest header: %v", err)
+	}
+	for _, m := range members {
+		var sum []byte
+		if m.key == "sha-256" {
+			s := sha256.Sum256(body)
+			sum = s[:]
+		} else if m.key == "sha-512" {
+			s := sha512.Sum512(body)
+			sum = s[:]
+		} else {
+			continue
+		}
+		if m.value != base64.StdEncoding.EncodeToString(sum) {
+			return fmt.Errorf("%s digest does not match body", m.key)
+		}
+		return nil
+	}
+	return errors.New("no supported algorithm in Content-Digest header")
+}
+
+func MakeContentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// Checks that a signature's creation time is within maxSkew of the current time.
+func CheckSigTime(created time.Time, maxSkew time.Duration) error {
+	diff := time.Since(created)
+	if diff > maxSkew {
+		return fmt.Errorf("signature is too old: created %v", created.UTC().Format(time.RFC3339))
+	}
+	if diff < -maxSkew {
+		return fmt.Errorf("signature is from the future: created %v", created.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// An RFC 9421 signature, parsed from the Signature-Input and Signature headers
+type Rfc9421Sig struct {
+	Label      string
+	Components []string // Covered component identifiers, e.g., @method, content-digest
+	Params     string   // Inner list and parameters, serialized exactly as in Signature-Input
+	KeyId      string
+	Alg        string
+	Created    int64
+	Expires    int64
+	Signature  []byte
+}
+
+// Parses the first signature that is present in both the Signature-Input and Signature headers.
+func ParseRfc9421(sigInputHeader, sigHeader string) (*Rfc9421Sig, error) {
+
+	inputs, err := parseSfDictionary(sigInputHeader)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Signature-Input header: %v", err)
+	}
+	sigs, err := parseSfDictionary(sigHeader)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Signature header: %v", err)
+	}
+
+	for _, input := range inputs {
+		for _, sig := range sigs {
+			if sig.key != input.key {
+				continue
+			}
+			if !input.isInner {
+				return nil, fmt.Errorf("signature input %s is not an inner list", input.key)
+			}
+			res := Rfc9421Sig{
+				Label:      input.key,
+				Components: input.inner,
+				Params:     input.raw,
+			}
+			for _, p := range input.params {
+				switch p.key {
+				case "keyid":
+					res.KeyId = p.value
+				case "alg":
+					res.Alg = p.value
+				case "created":
+					res.Created, _ = strconv.ParseInt(p.value, 10, 64)
+				case "expires":
+					res.Expires, _ = strconv.ParseInt(p.value, 10, 64)
+				}
+			}
+			if res.Signature, err = base64.StdEncoding.DecodeString(sig.value); err != nil {
+				return nil, fmt.Errorf("invalid signature value: %v", err)
+			}
+			return &res, nil
+		}
+	}
+	return nil, errors.New("no matching signature in Signature-Input and Signature headers")
+}
+
+func (sig *Rfc9421Sig) Covers(component string) bool {
+	for _, c := range sig.Components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// Builds the signature base: the string that is actually signed.
+func (sig *Rfc9421Sig) SignatureBase(r *http.Request) (string, error) {
+	var sb strings.Builder
+	for _, comp := range sig.Components {
+		val, err := getComponentValue(r, comp)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(`"` + comp + `": ` + val + "\n")
+	}
+	sb.WriteString(`"@signature-params": ` + sig.Params)
+	return sb.String(), nil
+}
+
+func (sig *Rfc9421Sig) Verify(r *http.Request, pubKey crypto.PublicKey) error {
+
+	sigBase, err := sig.SignatureBase(r)
+	if err != nil {
+		return err
+	}
+	rsaKey, ok := pubKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("unsupported public key type")
+	}
+
+	switch sig.Alg {
+	case "", Rfc9421AlgRsaV15Sha256:
+		hash := sha256.Sum256([]byte(sigBase))
+		return rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, hash[:], sig.Signature)
+	case Rfc9421AlgRsaPssSha512:
+		hash := sha512.Sum512([]byte(sigBase))
+		return rsa.VerifyPSS(rsaKey, crypto.SHA512, hash[:], sig.Signature, &rsa.PSSOptions{SaltLength: 64})
+	default:
+		return fmt.Errorf("unsupported signature algorithm: %s", sig.Alg)
+	}
+}
+
+// Signs the request according to RFC 9421 with rsa-v1_5-sha256. If there is a body, it is covered
+// through the Content-Digest header, which is also set here.
+func SignRfc9421(r *http.Request, privKey *rsa.PrivateKey, keyId string, body []byte) error {
+
+	components := []string{"@method", "@target-uri"}
+	if body != nil {
+		r.Header.Set("Content-Digest", MakeContentDigest(body))
+		components = append(components, "content-digest")
+	}
+
+	var params strings.Builder
+	params.WriteString("(")
+	for i, c := range components {
+		if i > 0 {
+			params.WriteString(" ")
+		}
+		params.WriteString(`"` + c + `"`)
+	}
+	params.WriteString(")")
+	params.WriteString(";created=" + strconv.FormatInt(time.Now().Unix(), 10))
+	params.WriteString(`;keyid="` + keyId + `"`)
+	params.WriteString(`;alg="` + Rfc9421AlgRsaV15Sha256 + `"`)
+
+	sig := Rfc9421Sig{
+		Label:      "sig1",
+		Components: components,
+		Params:     params.String(),
+	}
+	sigBase, err := sig.SignatureBase(r)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(sigBase))
+	sigBytes, err := rsa.SignPKCS1v15(rand.Reader, privKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Signature-Input", sig.Label+"="+sig.Params)
+	r.Header.Set("Signature", sig.Label+"=:"+base64.StdEncoding.EncodeToString(sigBytes)+":")
+	return nil
+}
+
+func getAuthority(r *http.Request) string {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ":443")
+}
+
+func getComponentValue(r *http.Request, comp string) (string, error) {
+	switch comp {
+	case "@method":
+		return strings.ToUpper(r.Method), nil
+	case "@target-uri":
+		// We're always behind HTTPS, even if TLS is terminated by a proxy
+		return "https://" + getAuthority(r) + r.URL.RequestURI(), nil
+	case "@authority":
+		return getAuthority(r), nil
+	case "@scheme":
+		return "https", nil
+	case "@request-target":
+		return r.URL.RequestURI(), nil
+	case "@path":
+		path := r.URL.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		return path, nil
+	case "@query":
+		return "?" + r.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(comp, "@") {
+		return "", fmt.Errorf("unsupported derived component: %s", comp)
+	}
+	if comp == "host" {
+		return getAuthority(r), nil
+	}
+	values := r.Header.Values(comp)
+	if len(values) == 0 {
+		return "", fmt.Errorf("covered header is missing: %s", comp)
+	}
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// Structured field dictionary member (RFC 8941), with just enough detail for signatures and digests
+type sfMember struct {
+	key     string
+	value   string   // Bare item value: string unquoted, byte sequence still base64-encoded
+	isInner bool     // Value is an inner list
+	inner   []string // Items of the inner list, if value is one
+	params  []sfParam
+	raw     string // Value and parameters exactly as they appear in the header
+}
+
+type sfParam struct {
+	key   string
+	value string
+}
+
+type sfParser struct {
+	str string
+	pos int
+}
+
+func parseSfDictionary(str string) ([]*sfMember, error) {
+	p := sfParser{str: str}
+	var res []*sfMember
+	p.skipSpace()
+	for p.pos < len(p.str) {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		m := sfMember{key: key, value: "?1"}
+		start := p.pos
+		if p.peek() == '=' {
+			p.pos++
+			start = p.pos
+			if p.peek() == '(' {
+				if m.inner, err = p.parseInnerList(); err != nil {
+					return nil, err
+				}
+				m.isInner = true
+			} else if m.value, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+		if m.params, err = p.parseParams(); err != nil {
+			return nil, err
+		}
+		m.raw = p.str[start:p.pos]
+		res = append(res, &m)
+		p.skipSpace()
+		if p.pos == len(p.str) {
+			break
+		}
+		if p.peek() != ',' {
+			return nil, fmt.Errorf("expected ',' at position %d", p.pos)
+		}
+		p.pos++
+		p.skipSpace()
+	}
+	return res, nil
+}
+
+func (p *sfParser) peek() byte {
+	if p.pos >= len(p.str) {
+		return 0
+	}
+	return p.str[p.pos]
+}
+
+func (p *sfParser) skipSpace() {
+	for p.pos < len(p.str) && (p.str[p.pos] == ' ' || p.str[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *sfParser) parseKey() (string, error) {
+	start := p.pos
+	for p.pos < len(p.str) {
+		c := p.str[p.pos]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' || c == '*' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("expected key at position %d", p.pos)
+	}
+	return p.str[start:p.pos], nil
+}
+
+func (p *sfParser) parseInnerList() ([]string, error) {
+	var res []string
+	p.pos++ // (
+	for {
+		p.skipSpace()
+		if p.peek() == ')' {
+			p.pos++
+			return res, nil
+		}
+		if p.pos >= len(p.str) {
+			return nil, errors.New("unterminated inner list")
+		}
+		item, err := p.parseBareItem()
+		if err != nil {
+			return nil, err
+		}
+		params, err := p.parseParams()
+		if err != nil {
+			return nil, err
+		}
+		if len(params) != 0 {
+			return nil, fmt.Errorf("unsupported parameters on item: %s", item)
+		}
+		res = append(res, item)
+	}
+}
+
+func (p *sfParser) parseParams() ([]sfParam, error) {
+	var res []sfParam
+	for p.peek() == ';' {
+		p.pos++
+		p.skipSpace()
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		param := sfParam{key: key, value: "?1"}
+		if p.peek() == '=' {
+			p.pos++
+			if param.value, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, param)
+	}
+	return res, nil
+}
+
+func (p *sfParser) parseBareItem() (string, error) {
+	c := p.peek()
+	if c == '"' {
+		var sb strings.Builder
+		p.pos++
+		for p.pos < len(p.str) {
+			c = p.str[p.pos]
+			p.pos++
+			if c == '\\' && p.pos < len(p.str) {
+				sb.WriteByte(p.str[p.pos])
+				p.pos++
+			} else if c == '"' {
+				return sb.String(), nil
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated string")
+	}
+	if c == ':' {
+		end := strings.IndexByte(p.str[p.pos+1:], ':')
+		if end == -1 {
+			return "", errors.New("unterminated byte sequence")
+		}
+		res := p.str[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return res, nil
+	}
+	start := p.pos
+	for p.pos < len(p.str) {
+		c = p.str[p.pos]
+		if c == ' ' || c == ',' || c == ';' || c == ')' || c == '(' || c == '"' || c == '=' || c == '\t' {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("expected item at position %d", p.pos)
+	}
+	return p.str[start:p.pos], nil
+}
@@ -0,0 +1,96 @@
+package shared
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseCavageParams(t *testing.T) {
+	hdr := `keyId="https://example.com/users/a#main-key",algorithm="hs2019",` +
+		`headers="(request-target) host date digest",signature="YWJj,ZA=="`
+	params := ParseCavageParams(hdr)
+	assert.Equal(t, "https://example.com/users/a#main-key", params["keyId"])
+	assert.Equal(t, "hs2019", params["algorithm"])
+	assert.Equal(t, "(request-target) host date digest", params["headers"])
+	assert.Equal(t, "YWJj,ZA==", params["signature"])
+}
+
+func TestVerifyDigest(t *testing.T) {
+	body := []byte(`{"hello": "world"}`)
+	assert.Nil(t, VerifyDigest("SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=", body))
+	assert.NotNil(t, VerifyDigest("SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=", []byte("{}")))
+	assert.NotNil(t, VerifyDigest("MD5=abc", body))
+	assert.NotNil(t, VerifyDigest("", body))
+
+	// Example from RFC 9530
+	assert.Nil(t, VerifyContentDigest("sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:", body))
+	assert.Equal(t, "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:", MakeContentDigest(body))
+	assert.NotNil(t, VerifyContentDigest("sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:", []byte("{}")))
+}
+
+func TestCheckSigTime(t *testing.T) {
+	assert.Nil(t, CheckSigTime(time.Now().Add(-5*time.Minute), time.Hour))
+	assert.NotNil(t, CheckSigTime(time.Now().Add(-2*time.Hour), time.Hour))
+	assert.NotNil(t, CheckSigTime(time.Now().Add(2*time.Hour), time.Hour))
+}
+
+func TestParseRfc9421(t *testing.T) {
+	// Example from RFC 9421, section 2.5 (signature value shortened)
+	sigInput := `sig1=("@method" "@authority" "@path" "content-digest" "content-length" "content-type")` +
+		`;created=1618884473;keyid="test-key-rsa-pss"`
+	sig, err := ParseRfc9421(sigInput, "sig1=:YWJj:")
+	assert.Nil(t, err)
+	assert.Equal(t, "sig1", sig.Label)
+	assert.Equal(t, "test-key-rsa-pss", sig.KeyId)
+	assert.Equal(t, int64(1618884473), sig.Created)
+	assert.Equal(t, []string{"@method", "@authority", "@path", "content-digest", "content-length", "content-type"},
+		sig.Components)
+	assert.Equal(t, []byte("abc"), sig.Signature)
+
+	req, _ := http.NewRequest("POST", "https://example.com/foo?param=Value&Pet=dog", nil)
+	req.Header.Set("Content-Digest", "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+T"+
+		"aPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:")
+	req.Header.Set("Content-Length", "18")
+	req.Header.Set("Content-Type", "application/json")
+	base, err := sig.SignatureBase(req)
+	assert.Nil(t, err)
+	expected := `"@method": POST
+"@authority": example.com
+"@path": /foo
+"content-digest": sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:
+"content-length": 18
+"content-type": application/json
+"@signature-params": ("@method" "@authority" "@path" "content-digest" "content-length" "content-type");created=1618884473;keyid="test-key-rsa-pss"`
+	assert.Equal(t, expected, base)
+
+	// No matching label
+	_, err = ParseRfc9421(sigInput, "sig2=:YWJj:")
+	assert.NotNil(t, err)
+}
+
+func TestSignVerifyRfc9421(t *testing.T) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	body := []byte(`{"type": "Create"}`)
+
+	req, _ := http.NewRequest("POST", "https://example.com/inbox", bytes.NewBuffer(body))
+	assert.Nil(t, SignRfc9421(req, privKey, "https://parrot.example/u/birb#main-key", body))
+
+	sig, err := ParseRfc9421(req.Header.Get("Signature-Input"), req.Header.Get("Signature"))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://parrot.example/u/birb#main-key", sig.KeyId)
+	assert.Equal(t, Rfc9421AlgRsaV15Sha256, sig.Alg)
+	assert.True(t, sig.Covers("content-digest"))
+	assert.Nil(t, VerifyContentDigest(req.Header.Get("Content-Digest"), body))
+	assert.Nil(t, sig.Verify(req, &privKey.PublicKey))
+
+	// Signature doesn't hold for a different target
+	req2, _ := http.NewRequest("POST", "https://example.com/other-inbox", bytes.NewBuffer(body))
+	req2.Header = req.Header.Clone()
+	assert.NotNil(t, sig.Verify(req2, &privKey.PublicKey))
+}
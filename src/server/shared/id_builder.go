@@ -27,6 +27,11 @@ func (idb *IdBuilder) UserProfile(user string) string {
 	return fmt.Sprintf("https://%s/web/feeds/%s", idb.Host, user)
 }
 
+// Where remote users land when they want to interact with something; {uri} is replaced with the target
+func (idb *IdBuilder) InteractTemplate() string {
+	return fmt.Sprintf("https://%s/web/interact?uri={uri}", idb.Host)
+}
+
 func (idb *IdBuilder) UserUrl(user string) string {
 	return fmt.Sprintf("https://%s/u/%s", idb.Host, user)
 }
@@ -35,6 +40,10 @@ func (idb *IdBuilder) UserKeyId(user string) string {
 	return fmt.Sprintf("https://%s/u/%s#main-key", idb.Host, user)
 }
 
+func (idb *IdBuilder) UserEdKeyId(user string) string {
+	return fmt.Sprintf("https://%s/u/%s#ed25519-key", idb.Host, user)
+}
+
 func (idb *IdBuilder) UserInbox(user string) string {
 	return fmt.Sprintf("https://%s/u/%s/inbox", idb.Host, user)
 }
@@ -0,0 +1,180 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// JSON Canonicalization Scheme (RFC 8785): keys sorted by UTF-16 code units, no whitespace,
+// numbers and strings serialized the way ECMAScript's JSON.stringify does.
+
+// Canonicalizes a serialized JSON document
+func CanonicalizeJson(docJson []byte) ([]byte, error) {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(docJson))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return CanonicalizeValue(doc)
+}
+
+// Canonicalizes a value as returned by json.Unmarshal into any. Numbers may be float64 or json.Number.
+func CanonicalizeValue(val any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJcsValue(&buf, val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJcsValue(buf *bytes.Buffer, val any) error {
+	switch v := val.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case string:
+		writeJcsString(buf, v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return writeJcsNumber(buf, f)
+	case float64:
+		return writeJcsNumber(buf, v)
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJcsValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUtf16(keys[i], keys[j]) })
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJcsString(buf, key)
+			buf.WriteByte(':')
+			if err := writeJcsValue(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported type in JSON document: %T", val)
+	}
+	return nil
+}
+
+func lessUtf16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+func writeJcsString(buf *bytes.Buffer, str string) {
+	buf.WriteByte('"')
+	for _, r := range str {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// Serializes a number like ECMAScript's Number.prototype.toString
+func writeJcsNumber(buf *bytes.Buffer, f float64) error {
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("number cannot be represented in JSON: %v", f)
+	}
+	if f == 0 {
+		buf.WriteString("0")
+		return nil
+	}
+	if f < 0 {
+		buf.WriteByte('-')
+		f = -f
+	}
+
+	// Shortest decimal digits that round-trip, and the exponent
+	sci := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, expStr, _ := strings.Cut(sci, "e")
+	exp, _ := strconv.Atoi(expStr)
+	digits := strings.Replace(mantissa, ".", "", 1)
+	k := len(digits)
+	n := exp + 1
+
+	switch {
+	case k <= n && n <= 21:
+		buf.WriteString(digits)
+		buf.WriteString(strings.Repeat("0", n-k))
+	case 0 < n && n <= 21:
+		buf.WriteString(digits[:n])
+		buf.WriteByte('.')
+		buf.WriteString(digits[n:])
+	case -6 < n && n <= 0:
+		buf.WriteString("0.")
+		buf.WriteString(strings.Repeat("0", -n))
+		buf.WriteString(digits)
+	default:
+		buf.WriteString(digits[:1])
+		if k > 1 {
+			buf.WriteByte('.')
+			buf.WriteString(digits[1:])
+		}
+		buf.WriteByte('e')
+		if n-1 >= 0 {
+			buf.WriteByte('+')
+		}
+		buf.WriteString(strconv.Itoa(n - 1))
+	}
+	return nil
+}
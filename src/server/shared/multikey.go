@@ -0,0 +1,77 @@
+package shared
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Multicodec prefix of an Ed25519 public key
+var ed25519PubPrefix = []byte{0xed, 0x01}
+
+func Base58Encode(data []byte) string {
+	num := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var res []byte
+	for num.Sign() > 0 {
+		num.DivMod(num, radix, mod)
+		res = append(res, base58Alphabet[mod.Int64()])
+	}
+	// Every leading zero byte is a '1'
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		res = append(res, base58Alphabet[0])
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return string(res)
+}
+
+func Base58Decode(str string) ([]byte, error) {
+	num := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range str {
+		ix := strings.IndexRune(base58Alphabet, c)
+		if ix == -1 {
+			return nil, fmt.Errorf("invalid base58 character: %c", c)
+		}
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(ix)))
+	}
+	var res []byte
+	for _, c := range str {
+		if c != rune(base58Alphabet[0]) {
+			break
+		}
+		res = append(res, 0)
+	}
+	return append(res, num.Bytes()...), nil
+}
+
+// Encodes an Ed25519 public key as a Multikey publicKeyMultibase value: base58btc with a 'z' prefix
+func EncodeEd25519Multikey(pubKey ed25519.PublicKey) string {
+	return "z" + Base58Encode(append(append([]byte{}, ed25519PubPrefix...), pubKey...))
+}
+
+func DecodeEd25519Multikey(multibase string) (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(multibase, "z") {
+		return nil, errors.New("multibase value is not base58btc")
+	}
+	data, err := Base58Decode(multibase[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != len(ed25519PubPrefix)+ed25519.PublicKeySize ||
+		data[0] != ed25519PubPrefix[0] || data[1] != ed25519PubPrefix[1] {
+		return nil, errors.New("value is not an Ed25519 multikey")
+	}
+	return data[len(ed25519PubPrefix):], nil
+}
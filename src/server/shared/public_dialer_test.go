@@ -0,0 +1,46 @@
+package shared
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	cases := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::6810:84e5", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.public, IsPublicIP(net.ParseIP(c.ip)), c.ip)
+	}
+}
+
+func TestPublicOnlyClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// Works with a normal client, but the test server is on loopback
+	resp, err := http.Get(srv.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	_, err = NewPublicOnlyClient(time.Second).Get(srv.URL)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "non-public address")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"rss_parrot/dto"
+	"strings"
 	"testing"
 )
 
@@ -47,5 +48,204 @@ func Test_Deserialize_Note(t *testing.T) {
 	assert.Equal(t, "Mention", (*note.Tag)[0].Type)
 }
 
+func Test_Deserialize_UserInfo_AssertionMethod(t *testing.T) {
+	var user dto.UserInfo
+
+	// Single object
+	err := json.Unmarshal([]byte(`{"id": "https://a.com/u/x", "assertionMethod": {"id": "https://a.com/u/x#ed",
+		"type": "Multikey", "controller": "https://a.com/u/x", "publicKeyMultibase": "z6Mkabc"}}`), &user)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(user.AssertionMethod))
+	assert.Equal(t, "https://a.com/u/x#ed", user.AssertionMethod[0].Id)
+	assert.Equal(t, "z6Mkabc", user.AssertionMethod[0].PublicKeyMultibase)
+
+	// Array with a link and an unknown key type, which are skipped
+	user = dto.UserInfo{}
+	err = json.Unmarshal([]byte(`{"id": "https://a.com/u/x", "assertionMethod": ["https://a.com/keys/1",
+		{"id": "https://a.com/u/x#other", "type": "JsonWebKey"},
+		{"id": "https://a.com/u/x#ed", "type": "Multikey", "controller": "https://a.com/u/x",
+		"publicKeyMultibase": "z6Mkabc"}]}`), &user)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(user.AssertionMethod))
+	assert.Equal(t, "https://a.com/u/x#ed", user.AssertionMethod[0].Id)
+}
+
+// Returns one of the hand-written edge cases in data/synthetic-activities.json
+func getSyntheticActivity(name string) []byte {
+	bytes, err := fs.ReadFile("data/synthetic-activities.json")
+	if err != nil {
+		panic(err)
+	}
+	var cases map[string]json.RawMessage
+	if err = json.Unmarshal(bytes, &cases); err != nil {
+		panic(err)
+	}
+	return cases[name]
+}
+
+func Test_Deserialize_Activity_Normalized(t *testing.T) {
+	var bytes []byte
+	var err error
+
+	// Embedded actor, embedded object with its own context, 'as:Public', content only in contentMap
+	bytes = getSyntheticActivity("embedded-actor-contentmap")
+	var actBase dto.ActivityInBase
+	err = json.Unmarshal(bytes, &actBase)
+	assert.Nil(t, err)
+	assert.Equal(t, "Create", actBase.Type)
+	assert.Equal(t, "https://remote.example/users/9f1aaa", actBase.Actor)
+	assert.Equal(t, []string{"https://www.w3.org/ns/activitystreams#Public"}, actBase.To)
+	var act dto.ActivityIn[dto.Note]
+	err = json.Unmarshal(bytes, &act)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://remote.example/notes/9k2ab3cd4e", act.Object.Id)
+	assert.Equal(t, "https://remote.example/users/9f1aaa", act.Object.AttributedTo)
+	assert.Contains(t, act.Object.Content, "hello")
+	assert.Equal(t, []string{"https://www.w3.org/ns/activitystreams#Public"}, act.Object.To)
+	// Emoji without href is skipped
+	assert.Equal(t, 1, len(*act.Object.Tag))
+	assert.Equal(t, "Mention", (*act.Object.Tag)[0].Type)
+
+	// Expanded and prefixed keys, type and actor in arrays, embedded audience and inReplyTo
+	bytes = getSyntheticActivity("expanded-keys")
+	act = dto.ActivityIn[dto.Note]{}
+	err = json.Unmarshal(bytes, &act)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://remote.example/activities/6a1f4c5e-2b8d-4e0f-9c3a-71d2b9e0a1aa", act.Id)
+	assert.Equal(t, "Create", act.Type)
+	assert.Equal(t, "https://remote.example/users/lark", act.Actor)
+	assert.Equal(t, []string{"https://www.w3.org/ns/activitystreams#Public"}, act.To)
+	assert.Equal(t, []string{"https://remote.example/users/lark/followers"}, act.Cc)
+	assert.Equal(t, "Note", act.Object.Type)
+	assert.Equal(t, "https://remote.example/users/lark", act.Object.AttributedTo)
+	assert.Equal(t, "https://rss-parrot.zydeo.net/u/birb/status/123", *act.Object.InReplyTo)
+	assert.Equal(t, []string{"https://www.w3.org/ns/activitystreams#Public", "https://rss-parrot.zydeo.net/u/birb"},
+		act.Object.To)
+	assert.Equal(t, 0, len(*act.Object.Tag))
+
+	// Object that is an ID where we expect an object, and an object where we expect an ID
+	var actObj dto.ActivityIn[dto.ActivityInBase]
+	err = json.Unmarshal([]byte(`{"type": "Undo", "actor": "https://a.com/u/x", "object": "https://a.com/f/1"}`), &actObj)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://a.com/f/1", actObj.Object.Id)
+	var actStr dto.ActivityIn[string]
+	err = json.Unmarshal([]byte(`{"type": "Follow", "actor": "https://a.com/u/x", "object": {"id": "https://b.com/u/y"}}`),
+		&actStr)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://b.com/u/y", actStr.Object)
+
+	// Move with embedded actor and object, target in an array
+	bytes = getSyntheticActivity("move-embedded")
+	var move dto.Move
+	err = json.Unmarshal(bytes, &move)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://old.example/users/alice", move.Actor)
+	assert.Equal(t, "https://old.example/users/alice", move.Object)
+	assert.Equal(t, "https://new.example/users/alice", move.Target)
+}
+
+// Real payloads must come through the normalizer with nothing lost: the Mastodon and GoToSocial ones from
+// the inbox tests, and everything in data/captures
+func Test_Normalize_Captured_Activities(t *testing.T) {
+
+	files := []string{
+		"data/atbirb-gts.json",
+		"data/atbirb-direct-nourl.json",
+		"data/atbirb-followers-nourl.json",
+		"data/atbirb-public-nourl.json",
+		"data/atbirb-unlisted-nourl.json",
+		"data/atbirb-unlisted-oneurl.json",
+		"data/atbirb-unlisted-reply.json",
+	}
+	captures, err := fs.ReadDir("data/captures")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range captures {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, "data/captures/"+entry.Name())
+		}
+	}
+
+	for _, fn := range files {
+		bytes, err := fs.ReadFile(fn)
+		if err != nil {
+			panic(err)
+		}
+		var raw map[string]any
+		assert.Nil(t, json.Unmarshal(bytes, &raw), fn)
+
+		normalized, err := dto.NormalizeActivity(bytes)
+		assert.Nil(t, err, fn)
+		again, err := dto.NormalizeActivity(normalized)
+		assert.Nil(t, err, fn)
+		assert.JSONEq(t, string(normalized), string(again), fn)
+
+		var actBase dto.ActivityInBase
+		assert.Nil(t, json.Unmarshal(normalized, &actBase), fn)
+		assert.Equal(t, raw["id"], actBase.Id, fn)
+		assert.Equal(t, raw["type"], actBase.Type, fn)
+		assert.Equal(t, dto.GetId(raw["actor"]), actBase.Actor, fn)
+		assert.ElementsMatch(t, dto.GetIds(raw["to"]), actBase.To, fn)
+		assert.ElementsMatch(t, dto.GetIds(raw["cc"]), actBase.Cc, fn)
+
+		rawObj, ok := raw["object"].(map[string]any)
+		if !ok || raw["type"] != "Create" || rawObj["type"] != "Note" {
+			continue
+		}
+		var act dto.ActivityIn[dto.Note]
+		assert.Nil(t, json.Unmarshal(normalized, &act), fn)
+		assert.Equal(t, rawObj["id"], act.Object.Id, fn)
+		assert.Equal(t, "Note", act.Object.Type, fn)
+		assert.Equal(t, dto.GetId(rawObj["attributedTo"]), act.Object.AttributedTo, fn)
+		if content, ok := rawObj["content"].(string); ok {
+			assert.Equal(t, content, act.Object.Content, fn)
+		}
+		assert.ElementsMatch(t, dto.GetIds(rawObj["to"]), act.Object.To, fn)
+		assert.ElementsMatch(t, dto.GetIds(rawObj["cc"]), act.Object.Cc, fn)
+		if inReplyTo := dto.GetId(rawObj["inReplyTo"]); inReplyTo != "" {
+			assert.Equal(t, inReplyTo, *act.Object.InReplyTo, fn)
+		}
+		// Every mention survives
+		var mentions []string
+		if act.Object.Tag != nil {
+			for _, tag := range *act.Object.Tag {
+				if tag.Type == "Mention" {
+					mentions = append(mentions, tag.Href)
+				}
+			}
+		}
+		var rawMentions []string
+		rawTags, isList := rawObj["tag"].([]any)
+		if !isList && rawObj["tag"] != nil {
+			rawTags = []any{rawObj["tag"]}
+		}
+		for _, rawTag := range rawTags {
+			if tag, ok := rawTag.(map[string]any); ok && tag["type"] == "Mention" {
+				rawMentions = append(rawMentions, tag["href"].(string))
+			}
+		}
+		assert.ElementsMatch(t, rawMentions, mentions, fn)
+	}
+
+	// Bare Notes, as we get them when fetching a status
+	for _, fn := range []string{"data/note-01.json", "data/note-02.json"} {
+		bytes, err := fs.ReadFile(fn)
+		if err != nil {
+			panic(err)
+		}
+		var raw map[string]any
+		assert.Nil(t, json.Unmarshal(bytes, &raw), fn)
+		normalized, err := dto.NormalizeActivity(bytes)
+		assert.Nil(t, err, fn)
+		var note dto.Note
+		assert.Nil(t, json.Unmarshal(normalized, &note), fn)
+		assert.Equal(t, raw["id"], note.Id, fn)
+		assert.Equal(t, raw["content"], note.Content, fn)
+		assert.ElementsMatch(t, dto.GetIds(raw["to"]), note.To, fn)
+		assert.ElementsMatch(t, dto.GetIds(raw["cc"]), note.Cc, fn)
+	}
+}
+
 //func Test_Foo(t *testing.T) {
 //}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"os"
+	"path/filepath"
+	"rss_parrot/dal"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"testing"
+)
+
+func setupBlockedFeedsTest(t *testing.T, cfg *shared.Config) (*gomock.Controller, *mocks.MockIRepo, logic.IBlockedFeeds) {
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks.NewMockILogger(ctrl)
+	setupDummyLogger(mockLogger)
+	mockRepo := mocks.NewMockIRepo(ctrl)
+	return ctrl, mockRepo, logic.NewBlockedFeeds(cfg, mockLogger, mockRepo)
+}
+
+func Test_Blocked_Feeds_Rules(t *testing.T) {
+
+	ctrl, mockRepo, bf := setupBlockedFeedsTest(t, &shared.Config{})
+	defer ctrl.Finish()
+
+	mockRepo.EXPECT().GetFeedBlocks().Return([]*dal.FeedBlock{
+		{Id: 1, Kind: dal.FeedBlockUrl, Pattern: "blog.example.com/feed.xml"},
+		{Id: 2, Kind: dal.FeedBlockDomain, Pattern: "spam.example"},
+		{Id: 3, Kind: dal.FeedBlockGlob, Pattern: "*.substack.com/feed?crypto*"},
+		{Id: 4, Kind: dal.FeedBlockRegex, Pattern: `^news\.example\.org/(ads|promo)/`},
+		{Id: 5, Kind: dal.FeedBlockRegex, Pattern: `(unclosed`},
+	}, nil).Times(1)
+
+	cases := []struct {
+		feedUrl string
+		blocked bool
+	}{
+		{"https://blog.example.com/feed.xml", true},
+		{"HTTP://Blog.Example.com/feed.xml", true},
+		{"https://blog.example.com/feed.xml?x=1", false},
+		{"https://other.example.com/feed.xml", false},
+		{"https://spam.example/rss", true},
+		{"https://www.spam.example:8080/rss", true},
+		{"https://notspam.example/rss", false},
+		{"https://someone.substack.com/feed?crypto=1", true},
+		{"https://someone.substack.com/feed", false},
+		{"https://news.example.org/promo/rss", true},
+		{"https://news.example.org/sports/rss", false},
+	}
+	for _, c := range cases {
+		blocked, err := bf.IsBlocked(c.feedUrl)
+		assert.Nil(t, err)
+		assert.Equal(t, c.blocked, blocked, c.feedUrl)
+	}
+}
+
+func Test_Blocked_Feeds_Add_Remove(t *testing.T) {
+
+	ctrl, mockRepo, bf := setupBlockedFeedsTest(t, &shared.Config{})
+	defer ctrl.Finish()
+
+	// Invalid rules are rejected before they reach the DB
+	for _, rule := range [][2]string{
+		{"host", "example.com"},
+		{dal.FeedBlockUrl, "  "},
+		{dal.FeedBlockDomain, "https://example.com/"},
+		{dal.FeedBlockGlob, "https://**"},
+		{dal.FeedBlockRegex, "[a-"},
+	} {
+		_, err := bf.AddBlock(rule[0], rule[1], "")
+		assert.NotNil(t, err, rule[1])
+	}
+
+	mockRepo.EXPECT().GetFeedBlocks().Return(nil, nil).Times(1)
+	blocked, err := bf.IsBlocked("https://Blog.Example.com/feed")
+	assert.Nil(t, err)
+	assert.False(t, blocked)
+
+	// Adding a rule stores it in normalized form and reloads the index
+	mockRepo.EXPECT().SaveFeedBlock(gomock.Any()).DoAndReturn(func(b *dal.FeedBlock) (int, error) {
+		assert.Equal(t, dal.FeedBlockUrl, b.Kind)
+		assert.Equal(t, "blog.example.com/feed", b.Pattern)
+		assert.Equal(t, "Spam", b.Reason)
+		return 12, nil
+	})
+	block, err := bf.AddBlock(dal.FeedBlockUrl, " HTTPS://Blog.Example.com/feed ", "Spam")
+	assert.Nil(t, err)
+	assert.Equal(t, 12, block.Id)
+
+	mockRepo.EXPECT().GetFeedBlocks().Return([]*dal.FeedBlock{block}, nil).Times(1)
+	blocked, err = bf.IsBlocked("https://Blog.Example.com/feed")
+	assert.Nil(t, err)
+	assert.True(t, blocked)
+
+	mockRepo.EXPECT().DeleteFeedBlock(12).Return(true, nil)
+	found, err := bf.RemoveBlock(12)
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	mockRepo.EXPECT().GetFeedBlocks().Return(nil, nil).Times(1)
+	blocked, err = bf.IsBlocked("https://Blog.Example.com/feed")
+	assert.Nil(t, err)
+	assert.False(t, blocked)
+}
+
+func Test_Blocked_Feeds_Matching_Accounts(t *testing.T) {
+
+	ctrl, mockRepo, bf := setupBlockedFeedsTest(t, &shared.Config{})
+	defer ctrl.Finish()
+
+	mockRepo.EXPECT().GetFeedAccounts().Return([]*dal.Account{
+		{Handle: "one", FeedUrl: "https://spam.example/feed"},
+		{Handle: "two", FeedUrl: "https://blog.spam.example/rss"},
+		{Handle: "three", FeedUrl: "https://example.com/spam.example"},
+	}, nil).AnyTimes()
+
+	accounts, err := bf.GetMatchingAccounts(&dal.FeedBlock{Kind: dal.FeedBlockDomain, Pattern: "spam.example"})
+	assert.Nil(t, err)
+	var handles []string
+	for _, acct := range accounts {
+		handles = append(handles, acct.Handle)
+	}
+	assert.Equal(t, []string{"one", "two"}, handles)
+
+	accounts, err = bf.GetMatchingAccounts(&dal.FeedBlock{Kind: dal.FeedBlockGlob, Pattern: "*/spam.*"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, "three", accounts[0].Handle)
+}
+
+func Test_Blocked_Feeds_Import_File(t *testing.T) {
+
+	fileName := filepath.Join(t.TempDir(), "blocked-feeds.txt")
+	content := "blog.example.com/feed\n\n# Comment line\nHTTPS://Other.Example/RSS\n"
+	assert.Nil(t, os.WriteFile(fileName, []byte(content), 0644))
+
+	ctrl, mockRepo, bf := setupBlockedFeedsTest(t, &shared.Config{BlockedFeedsFile: fileName})
+	defer ctrl.Finish()
+
+	var saved []string
+	mockRepo.EXPECT().GetSysParam("blocked_feeds_imported").Return("", nil).Times(1)
+	mockRepo.EXPECT().SaveFeedBlock(gomock.Any()).DoAndReturn(func(b *dal.FeedBlock) (int, error) {
+		assert.Equal(t, dal.FeedBlockUrl, b.Kind)
+		saved = append(saved, b.Pattern)
+		return len(saved), nil
+	}).Times(2)
+	mockRepo.EXPECT().SetSysParam("blocked_feeds_imported", fileName).Return(nil).Times(1)
+	mockRepo.EXPECT().GetFeedBlocks().Return(nil, nil).Times(1)
+
+	_, err := bf.IsBlocked("https://blog.example.com/feed")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"blog.example.com/feed", "other.example/rss"}, saved)
+
+	// Once imported, the file is not read again
+	ctrl, mockRepo, bf = setupBlockedFeedsTest(t, &shared.Config{BlockedFeedsFile: fileName})
+	defer ctrl.Finish()
+	mockRepo.EXPECT().GetSysParam("blocked_feeds_imported").Return(fileName, nil).Times(1)
+	mockRepo.EXPECT().GetFeedBlocks().Return(nil, nil).Times(1)
+	_, err = bf.IsBlocked("https://blog.example.com/feed")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"testing"
+)
+
+type bundlesHarness struct {
+	cfg           *shared.Config
+	mockLogger    *mocks.MockILogger
+	mockRepo      *mocks.MockIRepo
+	mockKeyStore  *mocks.MockIKeyStore
+	mockMessenger *mocks.MockIMessenger
+	mockRelays    *mocks.MockIRelays
+}
+
+func setupBundlesTest(t *testing.T) (*gomock.Controller, *bundlesHarness, logic.IBundles) {
+
+	ctrl := gomock.NewController(t)
+
+	h := &bundlesHarness{
+		cfg:           &shared.Config{Host: "parrot.net", Birb: &shared.UserInfo{User: "birb"}},
+		mockLogger:    mocks.NewMockILogger(ctrl),
+		mockRepo:      mocks.NewMockIRepo(ctrl),
+		mockKeyStore:  mocks.NewMockIKeyStore(ctrl),
+		mockMessenger: mocks.NewMockIMessenger(ctrl),
+		mockRelays:    mocks.NewMockIRelays(ctrl),
+	}
+	setupDummyLogger(h.mockLogger)
+	h.mockRepo.EXPECT().GetNextId().DoAndReturn(getNextId).AnyTimes()
+
+	bn := logic.NewBundles(h.cfg, h.mockLogger, h.mockRepo, h.mockKeyStore, h.mockMessenger, h.mockRelays)
+	return ctrl, h, bn
+}
+
+func Test_Bundles_Announce_Toot(t *testing.T) {
+
+	ctrl, h, bn := setupBundlesTest(t)
+	defer ctrl.Finish()
+
+	const memberId = 42
+	const statusId = "https://parrot.net/u/some.blog.com/status/1234"
+	h.mockRepo.EXPECT().GetBundlesOfMember(gomock.Eq(memberId)).Return([]*dal.Account{
+		{Id: 7, Handle: "go-blogs", Kind: dal.AccountKindBundle},
+		{Id: 8, Handle: "quiet-bundle", Kind: dal.AccountKindBundle},
+	}, nil)
+	h.mockRepo.EXPECT().GetFollowersByUser(gomock.Eq("go-blogs"), gomock.Eq(true)).Return([]*dal.FollowerInfo{
+		{UserUrl: "https://one.social/users/a", UserInbox: "https://one.social/users/a/inbox", SharedInbox: "https://one.social/inbox"},
+		{UserUrl: "https://one.social/users/b", UserInbox: "https://one.social/users/b/inbox", SharedInbox: "https://one.social/inbox"},
+		{UserUrl: "https://two.social/users/c", UserInbox: "https://two.social/users/c/inbox"},
+	}, nil)
+	h.mockRepo.EXPECT().GetFollowersByUser(gomock.Eq("quiet-bundle"), gomock.Eq(true)).Return(nil, nil)
+
+	// One Announce to the distinct inboxes, from the bundle, boosting the member's status
+	var inboxes []string
+	h.mockMessenger.EXPECT().EnqueueActivityToMany(gomock.Eq("go-blogs"), gomock.Any(), gomock.Any(), gomock.Eq(logic.PriorityBulk)).
+		DoAndReturn(func(byUser string, toInboxes []string, act *dto.ActivityOut, priority int) error {
+			inboxes = toInboxes
+			assert.Equal(t, "Announce", act.Type)
+			assert.Equal(t, "https://parrot.net/u/go-blogs", act.Actor)
+			assert.Equal(t, statusId, act.Object)
+			assert.Equal(t, []string{shared.ActivityPublic}, *act.To)
+			assert.Equal(t, []string{"https://parrot.net/u/go-blogs/followers"}, *act.Cc)
+			return nil
+		}).Times(1)
+
+	bn.AnnounceToot(memberId, statusId)
+	assert.ElementsMatch(t, []string{"https://one.social/inbox", "https://two.social/users/c/inbox"}, inboxes)
+}
+
+func Test_Bundles_Create_Handle_Taken_By_Feed(t *testing.T) {
+
+	ctrl, h, bn := setupBundlesTest(t)
+	defer ctrl.Finish()
+
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq("some.blog.com")).Return(&dal.Account{Id: 3, Handle: "some.blog.com"}, nil)
+
+	acct, isNew, err := bn.CreateBundle("some.blog.com", "Some bundle", "")
+	assert.NotNil(t, err)
+	assert.Nil(t, acct)
+	assert.False(t, isNew)
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"os"
+	"path/filepath"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"strings"
+	"testing"
+)
+
+func setupDomainBlocksTest(t *testing.T, cfg *shared.Config) (*gomock.Controller, *mocks.MockIRepo, logic.IDomainBlocks) {
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks.NewMockILogger(ctrl)
+	setupDummyLogger(mockLogger)
+	mockRepo := mocks.NewMockIRepo(ctrl)
+	return ctrl, mockRepo, logic.NewDomainBlocks(cfg, mockLogger, mockRepo)
+}
+
+func Test_Domain_Blocks(t *testing.T) {
+
+	fileName := filepath.Join(t.TempDir(), "blocked-domains.txt")
+	content := "# Comment line\nbad.example\n\n  Spam.Social  \n"
+	assert.Nil(t, os.WriteFile(fileName, []byte(content), 0644))
+
+	cfg := &shared.Config{BlockedDomainsFile: fileName}
+	ctrl, mockRepo, dblocks := setupDomainBlocksTest(t, cfg)
+	defer ctrl.Finish()
+	mockRepo.EXPECT().GetDomainBlocks().Return(nil, nil).AnyTimes()
+
+	cases := []struct {
+		host    string
+		blocked bool
+	}{
+		{"bad.example", true},
+		{"sub.bad.example", true},
+		{"notbad.example", false},
+		{"spam.social", true},
+		{"SPAM.social", true},
+		{"example", false},
+		{"mastodon.social", false},
+	}
+	for _, c := range cases {
+		blocked, err := dblocks.IsBlocked(c.host)
+		assert.Nil(t, err)
+		assert.Equal(t, c.blocked, blocked, c.host)
+	}
+
+	// No file configured: nothing is blocked
+	ctrl, mockRepo, dblocks = setupDomainBlocksTest(t, &shared.Config{})
+	defer ctrl.Finish()
+	mockRepo.EXPECT().GetDomainBlocks().Return(nil, nil).AnyTimes()
+	blocked, err := dblocks.IsBlocked("bad.example")
+	assert.Nil(t, err)
+	assert.False(t, blocked)
+}
+
+func Test_Domain_Blocks_Levels(t *testing.T) {
+
+	ctrl, mockRepo, dblocks := setupDomainBlocksTest(t, &shared.Config{})
+	defer ctrl.Finish()
+
+	mockRepo.EXPECT().GetDomainBlocks().Return([]*dal.DomainBlock{
+		{Domain: "quiet.social", Level: dal.DomainSilence},
+		{Domain: "spam.quiet.social", Level: dal.DomainSuspend},
+		{Domain: "pushy.example", Level: dal.DomainRejectFollows},
+	}, nil).Times(1)
+
+	cases := []struct {
+		host  string
+		level int
+	}{
+		{"quiet.social", dal.DomainSilence},
+		{"sub.quiet.social", dal.DomainSilence},
+		{"spam.quiet.social", dal.DomainSuspend},
+		{"a.spam.quiet.social", dal.DomainSuspend},
+		{"Pushy.Example", dal.DomainRejectFollows},
+		{"mastodon.social", 0},
+	}
+	for _, c := range cases {
+		level, err := dblocks.GetLevel(c.host)
+		assert.Nil(t, err)
+		assert.Equal(t, c.level, level, c.host)
+	}
+	blocked, _ := dblocks.IsBlocked("pushy.example")
+	assert.False(t, blocked)
+
+	// Changing a block reloads the list from the DB
+	mockRepo.EXPECT().SaveDomainBlock(gomock.Any()).Return(nil)
+	assert.Nil(t, dblocks.SetBlock("pushy.example", dal.DomainSuspend, ""))
+	mockRepo.EXPECT().GetDomainBlocks().Return([]*dal.DomainBlock{
+		{Domain: "pushy.example", Level: dal.DomainSuspend},
+	}, nil).Times(1)
+	blocked, _ = dblocks.IsBlocked("pushy.example")
+	assert.True(t, blocked)
+
+	assert.NotNil(t, dblocks.SetBlock("https://bad.url/", dal.DomainSuspend, ""))
+	assert.NotNil(t, dblocks.SetBlock("fine.example", 17, ""))
+}
+
+func Test_Domain_Blocks_Import_Csv(t *testing.T) {
+
+	saved := map[string]*dal.DomainBlock{}
+	saveBlock := func(block *dal.DomainBlock) error {
+		saved[block.Domain] = block
+		return nil
+	}
+
+	// Mastodon export
+	ctrl, mockRepo, dblocks := setupDomainBlocksTest(t, &shared.Config{})
+	defer ctrl.Finish()
+	mockRepo.EXPECT().SaveDomainBlock(gomock.Any()).DoAndReturn(saveBlock).AnyTimes()
+	csv := "#domain,#severity,#reject_media,#reject_reports,#public_comment,#obfuscate\n" +
+		"spam.example,suspend,true,true,Spam,false\n" +
+		"Loud.Social,silence,false,false,\"Too loud, really\",false\n" +
+		"harmless.example,noop,true,false,,false\n" +
+		"hid*en.example,suspend,false,false,,true\n"
+	count, err := dblocks.ImportCsv(strings.NewReader(csv))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, dal.DomainSuspend, saved["spam.example"].Level)
+	assert.Equal(t, "Spam", saved["spam.example"].Reason)
+	assert.Equal(t, dal.DomainSilence, saved["loud.social"].Level)
+	assert.Equal(t, "Too loud, really", saved["loud.social"].Reason)
+
+	// Headerless list of domains, some with a severity
+	saved = map[string]*dal.DomainBlock{}
+	count, err = dblocks.ImportCsv(strings.NewReader("one.example\ntwo.example,reject_follows\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, dal.DomainSuspend, saved["one.example"].Level)
+	assert.Equal(t, dal.DomainRejectFollows, saved["two.example"].Level)
+
+	_, err = dblocks.ImportCsv(strings.NewReader("three.example,obliterate\n"))
+	assert.NotNil(t, err)
+}
+
+func Test_Domain_Blocks_Follow_Rejected(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	followId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Follow",
+		"actor": "%s", "object": "https://%s/u/%s"}`, followId, h.sender.Id, birbHost, feedHandle)
+
+	h.mockRepo.EXPECT().GetNextId().DoAndReturn(getNextId).AnyTimes()
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq(feedHandle)).Return(&dal.Account{Id: 5, Handle: feedHandle}, nil)
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(followId), gomock.Any()).Return(false, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq(callerHost)).Return(dal.DomainRejectFollows, nil)
+
+	// Follower is not stored; a Reject goes back instead
+	h.mockRepo.EXPECT().AddFollower(gomock.Any(), gomock.Any()).Times(0)
+	h.mockMessenger.EXPECT().EnqueueActivity(gomock.Eq(feedHandle), gomock.Eq(h.sender.Inbox), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(byUser, toInbox string, act *dto.ActivityOut, priority int) error {
+			assert.Equal(t, "Reject", act.Type)
+			follow, ok := act.Object.(dto.ActivityOut)
+			assert.True(t, ok)
+			assert.Equal(t, followId, follow.Id)
+			return nil
+		}).Times(1)
+
+	reqProblem, err := inbox.HandleFollow(feedHandle, h.sender, []byte(body))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
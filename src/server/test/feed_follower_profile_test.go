@@ -0,0 +1,182 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"rss_parrot/dal"
+	"testing"
+	"time"
+)
+
+const profileFeedXml = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+  <title>New name</title>
+  <link>https://blog.example.com/</link>
+  <description>New summary</description>
+  <image><url>https://blog.example.com/new.png</url><title>New name</title><link>https://blog.example.com/</link></image>
+</channel>
+</rss>`
+
+// Serves a feed with a new name, summary and image to the account; the test sets what is expected of the profile
+func serveProfileFeed(h *feedFollowerHarness, acct *dal.Account) *httptest.Server {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(profileFeedXml))
+	}))
+	acct.FeedUrl = srv.URL + "/feed"
+
+	h.mockUserAgent.EXPECT().AddUserAgent(gomock.Any()).AnyTimes()
+	h.mockMetrics.EXPECT().FeedUpdated().AnyTimes()
+	h.mockRepo.EXPECT().GetFeedLastUpdated(acct.Id).Return(time.Time{}, nil)
+	h.mockRepo.EXPECT().UpdateAccountFeedTimes(acct.Id, gomock.Any(), gomock.Any()).Return(nil)
+	h.mockRepo.EXPECT().RecordFeedCheck(acct.Id, gomock.Any(), "").Return(nil)
+	return srv
+}
+
+func makeProfileAccount() *dal.Account {
+	return &dal.Account{
+		Id:               7,
+		Handle:           "blog.example.com",
+		FeedName:         "Old name",
+		FeedSummary:      "Old summary",
+		ProfileImageUrl:  "https://blog.example.com/old.png",
+		FeedMetaHash:     1,
+		ProfileUpdatedAt: time.Now().Add(-48 * time.Hour),
+	}
+}
+
+func Test_Feed_Follower_Profile_Locked_Fields(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	acct := makeProfileAccount()
+	acct.LockedFields = dal.LockFeedName | dal.LockProfileImage
+	srv := serveProfileFeed(h, acct)
+	defer srv.Close()
+
+	// Only the unlocked summary follows the feed
+	h.mockRepo.EXPECT().UpdateAccountProfile(acct.Id, "Old name", "New summary", "https://blog.example.com/old.png",
+		gomock.Not(int64(1)), gomock.Any()).Return(nil)
+	h.mockUDir.EXPECT().BroadcastUpdate(acct.Handle).Return(nil)
+
+	diag := ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+}
+
+func Test_Feed_Follower_Profile_All_Locked(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	acct := makeProfileAccount()
+	acct.LockedFields = dal.LockFeedName | dal.LockFeedSummary | dal.LockProfileImage
+	srv := serveProfileFeed(h, acct)
+	defer srv.Close()
+
+	// Nothing to tell followers; the new hash is remembered so the change isn't looked at again
+	h.mockRepo.EXPECT().UpdateAccountFeedMeta(acct.Id, gomock.Not(int64(1))).Return(nil)
+	h.mockRepo.EXPECT().UpdateAccountProfile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Times(0)
+	h.mockUDir.EXPECT().BroadcastUpdate(gomock.Any()).Times(0)
+
+	diag := ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+}
+
+func Test_Feed_Follower_Profile_Changed(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	acct := makeProfileAccount()
+	srv := serveProfileFeed(h, acct)
+	defer srv.Close()
+
+	h.mockRepo.EXPECT().UpdateAccountProfile(acct.Id, "New name", "New summary", "https://blog.example.com/new.png",
+		gomock.Not(int64(1)), gomock.Any()).Return(nil)
+	h.mockUDir.EXPECT().BroadcastUpdate(acct.Handle).Return(nil)
+
+	diag := ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+}
+
+// Same metadata as last time: nothing is written, nobody is told
+func Test_Feed_Follower_Profile_Unchanged(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	// First check learns the hash of the feed's metadata
+	acct := makeProfileAccount()
+	acct.FeedMetaHash = 0
+	srv := serveProfileFeed(h, acct)
+	defer srv.Close()
+	var metaHash int64
+	h.mockRepo.EXPECT().UpdateAccountFeedMeta(acct.Id, gomock.Any()).
+		Do(func(_ int, hash int64) { metaHash = hash }).Return(nil).Times(1)
+	diag := ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+	assert.NotEqual(t, int64(0), metaHash)
+
+	acct.FeedMetaHash = metaHash
+	srv2 := serveProfileFeed(h, acct)
+	defer srv2.Close()
+	h.mockRepo.EXPECT().UpdateAccountProfile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Times(0)
+	h.mockUDir.EXPECT().BroadcastUpdate(gomock.Any()).Times(0)
+	diag = ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+}
+
+// Accounts from before metadata was tracked only get the hash stored; their profile is left alone
+func Test_Feed_Follower_Profile_First_Hash(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	acct := makeProfileAccount()
+	acct.FeedMetaHash = 0
+	srv := serveProfileFeed(h, acct)
+	defer srv.Close()
+
+	h.mockRepo.EXPECT().UpdateAccountFeedMeta(acct.Id, gomock.Not(int64(0))).Return(nil).Times(1)
+	h.mockRepo.EXPECT().UpdateAccountProfile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Times(0)
+	h.mockUDir.EXPECT().BroadcastUpdate(gomock.Any()).Times(0)
+
+	diag := ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+}
+
+func Test_Feed_Follower_Profile_Throttled(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	// Updated an hour ago; default is to wait a day. Not even the hash is stored, so the change comes up again.
+	acct := makeProfileAccount()
+	acct.ProfileUpdatedAt = time.Now().Add(-time.Hour)
+	srv := serveProfileFeed(h, acct)
+	defer srv.Close()
+	h.mockRepo.EXPECT().UpdateAccountFeedMeta(gomock.Any(), gomock.Any()).Times(0)
+	h.mockRepo.EXPECT().UpdateAccountProfile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Times(0)
+	h.mockUDir.EXPECT().BroadcastUpdate(gomock.Any()).Times(0)
+	diag := ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+
+	// With a shorter interval configured, the same change goes through
+	h.cfg.ProfileUpdateMinHr = 1
+	acct.ProfileUpdatedAt = time.Now().Add(-2 * time.Hour)
+	srv2 := serveProfileFeed(h, acct)
+	defer srv2.Close()
+	h.mockRepo.EXPECT().UpdateAccountProfile(acct.Id, "New name", "New summary", "https://blog.example.com/new.png",
+		gomock.Not(int64(1)), gomock.Any()).Return(nil)
+	h.mockUDir.EXPECT().BroadcastUpdate(acct.Handle).Return(nil)
+	diag = ff.RefreshFeed(acct)
+	assert.Equal(t, "", diag.Error)
+}
@@ -23,9 +23,12 @@ type feedFollowerHarness struct {
 	mockRepo         *mocks.MockIRepo
 	mockBlockedFeeds *mocks.MockIBlockedFeeds
 	mockMessenger    *mocks.MockIMessenger
+	mockUDir         *mocks.MockIUserDirectory
 	mockTexts        *mocks.MockITexts
 	mockKeyStore     *mocks.MockIKeyStore
 	mockMetrics      *mocks.MockIMetrics
+	mockRelays       *mocks.MockIRelays
+	mockBundles      *mocks.MockIBundles
 }
 
 func setupFeedFollowerTest(t *testing.T) (*gomock.Controller, *feedFollowerHarness, logic.IFeedFollower) {
@@ -33,23 +36,29 @@ func setupFeedFollowerTest(t *testing.T) (*gomock.Controller, *feedFollowerHarne
 	ctrl := gomock.NewController(t)
 
 	h := &feedFollowerHarness{
-		cfg:              &shared.Config{},
+		cfg:              &shared.Config{Birb: &shared.UserInfo{User: "birb"}},
 		mockLogger:       mocks.NewMockILogger(ctrl),
 		mockUserAgent:    mocks.NewMockIUserAgent(ctrl),
 		mockRepo:         mocks.NewMockIRepo(ctrl),
 		mockBlockedFeeds: mocks.NewMockIBlockedFeeds(ctrl),
 		mockMessenger:    mocks.NewMockIMessenger(ctrl),
+		mockUDir:         mocks.NewMockIUserDirectory(ctrl),
 		mockTexts:        mocks.NewMockITexts(ctrl),
 		mockKeyStore:     mocks.NewMockIKeyStore(ctrl),
 		mockMetrics:      mocks.NewMockIMetrics(ctrl),
+		mockRelays:       mocks.NewMockIRelays(ctrl),
+		mockBundles:      mocks.NewMockIBundles(ctrl),
 	}
 	setupDummyLogger(h.mockLogger)
 	setupDummyMetrics(h.mockMetrics)
 
 	h.mockRepo.EXPECT().GetTotalPostCount().Return(uint(0), nil).AnyTimes()
+	// The feed check loop runs in the background; it finds nothing to do
+	h.mockRepo.EXPECT().GetAccountToCheck(gomock.Any()).Return(nil, 0, nil).AnyTimes()
 
 	ff := logic.NewFeedFollower(h.cfg, h.mockLogger, h.mockUserAgent, h.mockRepo,
-		h.mockBlockedFeeds, h.mockMessenger, h.mockTexts, h.mockKeyStore, h.mockMetrics)
+		h.mockBlockedFeeds, h.mockMessenger, h.mockUDir, h.mockTexts, h.mockKeyStore, h.mockMetrics,
+		h.mockRelays, h.mockBundles)
 
 	return ctrl, h, ff
 }
@@ -107,3 +116,28 @@ func Test_Feed_Follower_Purge_Old_Posts_Scenarios(t *testing.T) {
 	test_Feed_Follower_Purge_Old_Posts(t, tootExtracts, &tootExtracts[3].postTime, 3)
 	test_Feed_Follower_Purge_Old_Posts(t, tootExtracts, &tootExtracts[3].postTime, 2)
 }
+
+func Test_Feed_Follower_Purge_Account(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+	h.mockRepo.EXPECT().GetAccountToCheck(gomock.Any()).Return(nil, 0, nil).AnyTimes()
+
+	acct := dal.Account{
+		Id:     17,
+		Handle: "some.site.com.feed",
+	}
+
+	// Followers must hear about it while the account still exists; the tombstone comes before deletion
+	gomock.InOrder(
+		h.mockUDir.EXPECT().BroadcastDelete(gomock.Eq(acct.Handle)).Return(nil).Times(1),
+		h.mockRepo.EXPECT().TombstoneAccount(gomock.Eq(acct.Id), gomock.Any()).Return(nil).Times(1),
+		h.mockRepo.EXPECT().BruteDeleteAccount(gomock.Eq(acct.Id)).Return(nil).Times(1),
+	)
+	err := ff.PurgeAccount(&acct)
+	assert.Nil(t, err)
+
+	// The built-in account is never purged
+	err = ff.PurgeAccount(&dal.Account{Id: 1, Handle: "birb"})
+	assert.NotNil(t, err)
+}
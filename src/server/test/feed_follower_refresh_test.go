@@ -0,0 +1,214 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"rss_parrot/dal"
+	"rss_parrot/logic"
+	"strings"
+	"testing"
+	"time"
+)
+
+const refreshFeedXml = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+  <title>Refreshed blog</title>
+  <link>https://blog.example.com/</link>
+  <item><title>Old post</title><link>https://blog.example.com/old</link><pubDate>Mon, 01 Jan 2024 10:00:00 +0000</pubDate></item>
+  <item><title>New post</title><link>https://blog.example.com/new</link><pubDate>Mon, 01 Jul 2024 10:00:00 +0000</pubDate></item>
+  <item><title>Future post</title><link>https://blog.example.com/future</link><pubDate>Sat, 01 Jan 2150 10:00:00 +0000</pubDate></item>
+  <item><title>Undated post</title><link>https://blog.example.com/undated</link></item>
+</channel>
+</rss>`
+
+func Test_Feed_Follower_Refresh_Diagnostics(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/feed", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(refreshFeedXml))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	acct := &dal.Account{Id: 7, Handle: "blog", FeedUrl: srv.URL + "/moved"}
+	lastKnown := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	h.mockUserAgent.EXPECT().AddUserAgent(gomock.Any()).AnyTimes()
+	h.mockMetrics.EXPECT().FeedUpdated().AnyTimes()
+	h.mockRepo.EXPECT().GetFeedLastUpdated(acct.Id).Return(lastKnown, nil)
+	h.mockRepo.EXPECT().AddFeedPostIfNew(acct.Id, gomock.Any()).DoAndReturn(
+		func(_ int, post *dal.FeedPost) (bool, error) {
+			assert.Equal(t, "https://blog.example.com/new", post.Link)
+			return false, nil
+		})
+	var nextCheckDue time.Time
+	h.mockRepo.EXPECT().UpdateAccountFeedTimes(acct.Id, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ int, lastUpdated, nextCheck time.Time) error {
+			assert.True(t, lastUpdated.Equal(time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)))
+			nextCheckDue = nextCheck
+			return nil
+		})
+	h.mockRepo.EXPECT().UpdateAccountFeedMeta(acct.Id, gomock.Any()).Return(nil)
+	h.mockRepo.EXPECT().RecordFeedCheck(acct.Id, gomock.Any(), "").Return(nil)
+
+	diag := ff.RefreshFeed(acct)
+
+	assert.Equal(t, "", diag.Error)
+	assert.Equal(t, http.StatusOK, diag.HttpStatus)
+	assert.Equal(t, []string{srv.URL + "/feed"}, diag.Redirects)
+	assert.Equal(t, len(refreshFeedXml), diag.ResponseBytes)
+	assert.Equal(t, 4, diag.ItemCount)
+	assert.True(t, diag.LastSeenBefore.Equal(lastKnown))
+	assert.True(t, diag.NextCheckDue.Equal(nextCheckDue))
+	verdicts := map[string]string{}
+	for _, itm := range diag.Items {
+		verdicts[itm.Title] = itm.Verdict
+	}
+	assert.Equal(t, map[string]string{
+		"Old post":     logic.ItemNotNewer,
+		"New post":     logic.ItemNew,
+		"Future post":  logic.ItemFuture,
+		"Undated post": logic.ItemNoDate,
+	}, verdicts)
+}
+
+func Test_Feed_Follower_Refresh_Failed(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	lastKnown := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	acct := &dal.Account{Id: 7, Handle: "blog", FeedUrl: srv.URL + "/feed",
+		FeedLastUpdated: lastKnown, CheckIntervalMin: 60}
+
+	h.mockUserAgent.EXPECT().AddUserAgent(gomock.Any()).AnyTimes()
+	h.mockMetrics.EXPECT().FeedUpdated().AnyTimes()
+	h.mockRepo.EXPECT().UpdateAccountFeedTimes(acct.Id, lastKnown, gomock.Any()).Return(nil)
+	h.mockRepo.EXPECT().RecordFeedCheck(acct.Id, gomock.Any(), gomock.Not("")).Return(nil)
+
+	diag := ff.RefreshFeed(acct)
+
+	assert.Equal(t, http.StatusNotFound, diag.HttpStatus)
+	assert.NotEqual(t, "", diag.Error)
+	assert.Empty(t, diag.Items)
+	assert.True(t, diag.NextCheckDue.After(time.Now()))
+}
+
+// A bundle's handle can look like a feed's; the feed must not be parrotted as the bundle
+func Test_Feed_Follower_Handle_Taken_By_Bundle(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	feedXml := strings.Replace(refreshFeedXml, "https://blog.example.com/", "https://go.dev/", 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(feedXml))
+	}))
+	defer srv.Close()
+
+	h.mockUserAgent.EXPECT().AddUserAgent(gomock.Any()).AnyTimes()
+	h.mockMetrics.EXPECT().FeedRequested(gomock.Any()).AnyTimes()
+	h.mockBlockedFeeds.EXPECT().IsBlocked(gomock.Any()).Return(false, nil)
+	h.mockRepo.EXPECT().GetAccountTombstone("go.dev").Return(nil, nil)
+	h.mockKeyStore.EXPECT().MakeKeyPair().Return("pub", "priv", nil)
+	h.mockRepo.EXPECT().AddAccountIfNotExist(gomock.Any(), "priv").Return(false, nil)
+	h.mockRepo.EXPECT().GetAccount("go.dev").Return(&dal.Account{Id: 3, Handle: "go.dev", Kind: dal.AccountKindBundle}, nil)
+
+	acct, status, err := ff.GetAccountForFeed(srv.URL + "/feed")
+	assert.NotNil(t, err)
+	assert.Nil(t, acct)
+	assert.Equal(t, logic.FeedStatus(logic.FsError), status)
+}
+
+// An admin-set interval is used as is, without the random band of the automatic schedule
+func Test_Feed_Follower_Refresh_Fixed_Interval(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(refreshFeedXml))
+	}))
+	defer srv.Close()
+
+	acct := &dal.Account{Id: 7, Handle: "blog", FeedUrl: srv.URL + "/feed", CheckIntervalMin: 90}
+
+	h.mockUserAgent.EXPECT().AddUserAgent(gomock.Any()).AnyTimes()
+	h.mockMetrics.EXPECT().FeedUpdated().AnyTimes()
+	h.mockRepo.EXPECT().GetFeedLastUpdated(acct.Id).Return(time.Now(), nil)
+	h.mockRepo.EXPECT().UpdateAccountFeedTimes(acct.Id, gomock.Any(), gomock.Any()).Return(nil)
+	h.mockRepo.EXPECT().UpdateAccountFeedMeta(acct.Id, gomock.Any()).Return(nil)
+	h.mockRepo.EXPECT().RecordFeedCheck(acct.Id, gomock.Any(), "").Return(nil)
+
+	diag := ff.RefreshFeed(acct)
+
+	assert.Equal(t, "", diag.Error)
+	assert.WithinDuration(t, time.Now().Add(90*time.Minute), diag.NextCheckDue, 5*time.Second)
+}
+
+// Valid feed, but with more than we are willing to read for diagnostics
+func serveHugeFeed() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>Huge</title>`))
+		padding := []byte(strings.Repeat(" ", 1024*1024))
+		for i := 0; i < 11; i++ {
+			_, _ = w.Write(padding)
+		}
+		_, _ = w.Write([]byte(`</channel></rss>`))
+	}))
+}
+
+func Test_Feed_Follower_Refresh_Too_Large(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	srv := serveHugeFeed()
+	defer srv.Close()
+
+	lastKnown := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	acct := &dal.Account{Id: 7, Handle: "blog", FeedUrl: srv.URL + "/feed", FeedLastUpdated: lastKnown}
+
+	h.mockUserAgent.EXPECT().AddUserAgent(gomock.Any()).AnyTimes()
+	h.mockMetrics.EXPECT().FeedUpdated().AnyTimes()
+	h.mockRepo.EXPECT().UpdateAccountFeedTimes(acct.Id, lastKnown, gomock.Any()).Return(nil)
+	h.mockRepo.EXPECT().RecordFeedCheck(acct.Id, gomock.Any(), gomock.Not("")).Return(nil)
+
+	diag := ff.RefreshFeed(acct)
+
+	assert.Equal(t, http.StatusOK, diag.HttpStatus)
+	assert.True(t, diag.Truncated)
+	assert.Equal(t, 10*1024*1024, diag.ResponseBytes)
+	assert.Contains(t, diag.Error, "larger than")
+	assert.Empty(t, diag.Items)
+}
+
+func Test_Feed_Follower_Large_Feed_Without_Diagnostics(t *testing.T) {
+
+	ctrl, h, ff := setupFeedFollowerTest(t)
+	defer ctrl.Finish()
+
+	srv := serveHugeFeed()
+	defer srv.Close()
+
+	h.mockUserAgent.EXPECT().AddUserAgent(gomock.Any()).AnyTimes()
+	h.mockBlockedFeeds.EXPECT().IsBlocked(gomock.Any()).Return(false, nil)
+
+	// Only diagnostics are capped; regular fetches read the whole feed
+	status, err := ff.ValidateFeedUrl(srv.URL + "/feed")
+
+	assert.Nil(t, err)
+	assert.Equal(t, logic.FeedStatus(logic.FsError), status)
+}
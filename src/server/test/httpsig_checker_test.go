@@ -0,0 +1,205 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/go-fed/httpsig"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"testing"
+	"time"
+)
+
+const gtsActor = "https://gts.example/users/alice"
+const gtsKeyId = "https://gts.example/users/alice/main-key"
+
+type sigCheckerHarness struct {
+	mockUserRetriever *mocks.MockIUserRetriever
+	sigSchemes        logic.ISigSchemes
+	chk               logic.IHttpSigChecker
+}
+
+func setupSigCheckerTest(t *testing.T) (*gomock.Controller, *sigCheckerHarness) {
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks.NewMockILogger(ctrl)
+	setupDummyLogger(mockLogger)
+	h := sigCheckerHarness{
+		mockUserRetriever: mocks.NewMockIUserRetriever(ctrl),
+		sigSchemes:        logic.NewSigSchemes(),
+	}
+	cfg := &shared.Config{Host: birbHost, Birb: &shared.UserInfo{User: "birb"}}
+	h.chk = logic.NewHttpSigChecker(cfg, mockLogger, h.mockUserRetriever, h.sigSchemes)
+	return ctrl, &h
+}
+
+func makeSigKey(t *testing.T) (*rsa.PrivateKey, string) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
+	assert.Nil(t, err)
+	return privKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+}
+
+// Signs a GET with a draft-cavage signature, dated as given
+func makeSignedFetch(t *testing.T, privKey *rsa.PrivateKey, keyId string, date time.Time) *http.Request {
+	req, _ := http.NewRequest("GET", "https://"+birbHost+"/u/some.blog.com", nil)
+	req.Header.Set("Host", birbHost)
+	req.Header.Set("Date", date.UTC().Format(http.TimeFormat))
+	signer, _, err := httpsig.NewSigner([]httpsig.Algorithm{httpsig.RSA_SHA256}, httpsig.DigestSha256,
+		[]string{httpsig.RequestTarget, "host", "date"}, httpsig.Signature, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, signer.SignRequest(privKey, keyId, req, nil))
+	return req
+}
+
+func makeGtsActor(pubKeyPem string) *dto.UserInfo {
+	actor := makeCallerUserInfo("gts.example", "alice", pubKeyPem)
+	actor.PublicKey.Id = gtsKeyId
+	return actor
+}
+
+// GoToSocial's key IDs are a path of their own; the key document names the actor as its owner
+func Test_Sig_Checker_Fetch_Path_Key_Id(t *testing.T) {
+
+	ctrl, h := setupSigCheckerTest(t)
+	defer ctrl.Finish()
+
+	privKey, pubKeyPem := makeSigKey(t)
+	keyDoc := &dto.UserInfo{Id: gtsActor, PublicKey: dto.PublicKey{Id: gtsKeyId, Owner: gtsActor, PublicKeyPem: pubKeyPem}}
+	h.mockUserRetriever.EXPECT().RetrieveCached(gtsKeyId).Return(keyDoc, false, nil)
+	h.mockUserRetriever.EXPECT().RetrieveCached(gtsActor).Return(makeGtsActor(pubKeyPem), false, nil)
+
+	userInfo, problem, err := h.chk.CheckFetch(makeSignedFetch(t, privKey, gtsKeyId, time.Now()))
+	assert.Nil(t, err)
+	assert.Equal(t, "", problem)
+	assert.Equal(t, gtsActor, userInfo.Id)
+}
+
+// The key document's say-so is not enough: the owner's actor must name the key too
+func Test_Sig_Checker_Fetch_Key_Owner_Disowns(t *testing.T) {
+
+	ctrl, h := setupSigCheckerTest(t)
+	defer ctrl.Finish()
+
+	privKey, pubKeyPem := makeSigKey(t)
+	keyId := "https://evil.example/keys/1"
+	keyDoc := &dto.UserInfo{Id: keyId, PublicKey: dto.PublicKey{Id: keyId, Owner: gtsActor, PublicKeyPem: pubKeyPem}}
+	h.mockUserRetriever.EXPECT().RetrieveCached(keyId).Return(keyDoc, false, nil)
+	h.mockUserRetriever.EXPECT().RetrieveCached(gtsActor).Return(makeGtsActor(pubKeyPem), false, nil)
+
+	userInfo, problem, err := h.chk.CheckFetch(makeSignedFetch(t, privKey, keyId, time.Now()))
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", problem)
+	assert.Nil(t, userInfo)
+}
+
+func Test_Sig_Checker_Skew_Window(t *testing.T) {
+
+	ctrl, h := setupSigCheckerTest(t)
+	defer ctrl.Finish()
+
+	privKey, pubKeyPem := makeSigKey(t)
+	caller := makeCallerUserInfo(callerHost, callerName, pubKeyPem)
+	h.mockUserRetriever.EXPECT().RetrieveCached(caller.Id).Return(caller, true, nil).AnyTimes()
+
+	// Default window is an hour either way
+	cases := []struct {
+		age time.Duration
+		ok  bool
+	}{
+		{0, true},
+		{50 * time.Minute, true},
+		{-50 * time.Minute, true},
+		{70 * time.Minute, false},
+		{-70 * time.Minute, false},
+	}
+	for _, c := range cases {
+		req := makeSignedFetch(t, privKey, caller.PublicKey.Id, time.Now().Add(-c.age))
+		_, problem, err := h.chk.CheckFetch(req)
+		assert.Nil(t, err)
+		assert.Equal(t, c.ok, problem == "", c.age.String())
+	}
+}
+
+// Actor may have rotated their key since we cached it
+func Test_Sig_Checker_Refetch_After_Cached_Key_Fails(t *testing.T) {
+
+	ctrl, h := setupSigCheckerTest(t)
+	defer ctrl.Finish()
+
+	_, oldPubKeyPem := makeSigKey(t)
+	privKey, pubKeyPem := makeSigKey(t)
+	cached := makeCallerUserInfo(callerHost, callerName, oldPubKeyPem)
+	fresh := makeCallerUserInfo(callerHost, callerName, pubKeyPem)
+	h.mockUserRetriever.EXPECT().RetrieveCached(cached.Id).Return(cached, true, nil).Times(1)
+	h.mockUserRetriever.EXPECT().Retrieve(cached.Id).Return(fresh, nil).Times(1)
+
+	userInfo, problem, err := h.chk.CheckFetch(makeSignedFetch(t, privKey, fresh.PublicKey.Id, time.Now()))
+	assert.Nil(t, err)
+	assert.Equal(t, "", problem)
+	assert.Equal(t, pubKeyPem, userInfo.PublicKey.PublicKeyPem)
+}
+
+// Key was just fetched and still doesn't fit: no point in fetching it again
+func Test_Sig_Checker_No_Refetch_Of_Fresh_Key(t *testing.T) {
+
+	ctrl, h := setupSigCheckerTest(t)
+	defer ctrl.Finish()
+
+	_, otherPubKeyPem := makeSigKey(t)
+	privKey, _ := makeSigKey(t)
+	fresh := makeCallerUserInfo(callerHost, callerName, otherPubKeyPem)
+	h.mockUserRetriever.EXPECT().RetrieveCached(fresh.Id).Return(fresh, false, nil).Times(1)
+	h.mockUserRetriever.EXPECT().Retrieve(gomock.Any()).Times(0)
+
+	userInfo, problem, err := h.chk.CheckFetch(makeSignedFetch(t, privKey, fresh.PublicKey.Id, time.Now()))
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", problem)
+	assert.Nil(t, userInfo)
+}
+
+// Whoever signs with RFC 9421 gets deliveries signed that way; draft-cavage signers keep getting what they sent
+func Test_Sig_Checker_Scheme_Choice(t *testing.T) {
+
+	ctrl, h := setupSigCheckerTest(t)
+	defer ctrl.Finish()
+
+	privKey, pubKeyPem := makeSigKey(t)
+	caller := makeCallerUserInfo(callerHost, callerName, pubKeyPem)
+	h.mockUserRetriever.EXPECT().RetrieveCached(caller.Id).Return(caller, true, nil).AnyTimes()
+	body := []byte(`{"type": "Follow"}`)
+	inboxUrl := "https://" + birbHost + "/u/some.blog.com/inbox"
+
+	// Draft-cavage, with the body covered by Digest
+	req, _ := http.NewRequest("POST", inboxUrl, nil)
+	req.Header.Set("Host", birbHost)
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	signer, _, err := httpsig.NewSigner([]httpsig.Algorithm{httpsig.RSA_SHA256}, httpsig.DigestSha256,
+		[]string{httpsig.RequestTarget, "host", "date", "digest"}, httpsig.Signature, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, signer.SignRequest(privKey, caller.PublicKey.Id, req, body))
+	_, problem, err := h.chk.Check(caller.Id, body, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "", problem)
+	assert.False(t, h.sigSchemes.PrefersRfc9421(callerHost))
+
+	// Body that doesn't match the digest
+	_, problem, _ = h.chk.Check(caller.Id, []byte(`{"type": "Undo"}`), req)
+	assert.NotEqual(t, "", problem)
+	assert.False(t, h.sigSchemes.PrefersRfc9421(callerHost))
+
+	// RFC 9421, with the body covered by Content-Digest
+	req, _ = http.NewRequest("POST", inboxUrl, nil)
+	assert.Nil(t, shared.SignRfc9421(req, privKey, caller.PublicKey.Id, body))
+	_, problem, err = h.chk.Check(caller.Id, body, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "", problem)
+	assert.True(t, h.sigSchemes.PrefersRfc9421(callerHost))
+}
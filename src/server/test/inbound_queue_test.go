@@ -0,0 +1,142 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"sync"
+	"testing"
+	"time"
+)
+
+type inboundQueueHarness struct {
+	cfg         *shared.Config
+	mockLogger  *mocks.MockILogger
+	mockRepo    *mocks.MockIRepo
+	mockMetrics *mocks.MockIMetrics
+	mockInbox   *mocks.MockIInbox
+}
+
+// Sets up a queue whose repo hands out the item that was added, once
+func setupInboundQueueTest(t *testing.T) (*gomock.Controller, *inboundQueueHarness, logic.IInboundQueue) {
+
+	ctrl := gomock.NewController(t)
+
+	h := &inboundQueueHarness{
+		cfg:         &shared.Config{Host: birbHost},
+		mockLogger:  mocks.NewMockILogger(ctrl),
+		mockRepo:    mocks.NewMockIRepo(ctrl),
+		mockMetrics: mocks.NewMockIMetrics(ctrl),
+		mockInbox:   mocks.NewMockIInbox(ctrl),
+	}
+	setupDummyLogger(h.mockLogger)
+	setupDummyMetrics(h.mockMetrics)
+
+	var mu sync.Mutex
+	var queued []*dal.InboundItem
+	h.mockRepo.EXPECT().AddInboundItem(gomock.Any()).DoAndReturn(func(item *dal.InboundItem) error {
+		mu.Lock()
+		defer mu.Unlock()
+		item.Id = len(queued) + 1
+		// Items are ordered per sender by this ID
+		var senderInfo dto.UserInfo
+		assert.Nil(t, json.Unmarshal([]byte(item.Sender), &senderInfo))
+		assert.Equal(t, senderInfo.Id, item.SenderId)
+		queued = append(queued, item)
+		return nil
+	}).AnyTimes()
+	h.mockRepo.EXPECT().GetInboundItems(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(due time.Time, skipIds []int, maxCount int) ([]*dal.InboundItem, int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			res := queued
+			queued = nil
+			return res, len(res), nil
+		}).AnyTimes()
+
+	q := logic.NewInboundQueue(h.cfg, h.mockLogger, h.mockRepo, h.mockMetrics, h.mockInbox)
+	return ctrl, h, q
+}
+
+func Test_InboundQueue_Process(t *testing.T) {
+
+	ctrl, h, q := setupInboundQueueTest(t)
+	defer ctrl.Finish()
+
+	sender := makeCallerUserInfo(callerHost, callerName, callerPubKey1)
+	body := fmt.Sprintf(`{"id": "https://%s/1", "type": "Follow", "actor": "%s", "object": "https://%s/u/some.blog.com"}`,
+		callerHost, sender.Id, birbHost)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	h.mockInbox.EXPECT().HandleFollow(gomock.Eq("some.blog.com"), gomock.Any(), gomock.Eq([]byte(body))).
+		DoAndReturn(func(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error) {
+			defer wg.Done()
+			assert.Equal(t, sender.Id, senderInfo.Id)
+			return "", nil
+		}).Times(1)
+	h.mockRepo.EXPECT().DeleteInboundItem(gomock.Eq(1)).DoAndReturn(func(id int) error {
+		defer wg.Done()
+		return nil
+	}).Times(1)
+
+	assert.Nil(t, q.Enqueue("some.blog.com", sender, []byte(body)))
+	waitOnWG(t, &wg, time.Millisecond*500)
+}
+
+func Test_InboundQueue_Retry(t *testing.T) {
+
+	ctrl, h, q := setupInboundQueueTest(t)
+	defer ctrl.Finish()
+
+	sender := makeCallerUserInfo(callerHost, callerName, callerPubKey1)
+	body := fmt.Sprintf(`{"id": "https://%s/2", "type": "Undo", "actor": "%s", "object": {"type": "Follow"}}`,
+		callerHost, sender.Id)
+
+	// Failing item is kept for later, with a wait before the next attempt
+	var wg sync.WaitGroup
+	wg.Add(1)
+	h.mockInbox.EXPECT().HandleUndo(gomock.Eq(""), gomock.Any(), gomock.Any()).Return("", errors.New("db is locked"))
+	h.mockRepo.EXPECT().DeleteInboundItem(gomock.Any()).Times(0)
+	h.mockRepo.EXPECT().RescheduleInboundItem(gomock.Eq(1), gomock.Eq(1), gomock.Any(), gomock.Eq("db is locked")).
+		DoAndReturn(func(id, attempts int, nextAttemptAt time.Time, lastError string) error {
+			defer wg.Done()
+			assert.True(t, nextAttemptAt.After(time.Now()))
+			return nil
+		}).Times(1)
+
+	assert.Nil(t, q.Enqueue("", sender, []byte(body)))
+	waitOnWG(t, &wg, time.Millisecond*500)
+}
+
+func Test_InboundQueue_Handled_Types(t *testing.T) {
+
+	cases := []struct {
+		body    string
+		handled bool
+	}{
+		{`{"type": "Follow", "object": "https://x/u/a"}`, true},
+		{`{"type": "Undo", "object": {"type": "Follow"}}`, true},
+		{`{"type": "Create", "object": {"type": "Note"}}`, true},
+		{`{"type": "as:Create", "object": {"@type": "as:Note"}}`, true},
+		{`{"type": "Create", "object": {"type": "Question"}}`, false},
+		{`{"type": "Create", "object": "https://x/notes/1"}`, false},
+		{`{"type": "Delete", "object": "https://x/users/a"}`, false},
+		{`{"type": "Like", "object": "https://x/u/a/s/1"}`, false},
+		{`{"type": "Announce", "object": "https://x/u/a/s/1"}`, false},
+		{`{"type": "Update", "object": {"type": "Person"}}`, false},
+		{`{"type": "Flag", "object": ["https://x/u/a"]}`, true},
+	}
+	for _, c := range cases {
+		var act dto.ActivityInBase
+		assert.Nil(t, json.Unmarshal([]byte(c.body), &act))
+		assert.Equal(t, c.handled, logic.IsInboundActivityHandled(act), c.body)
+	}
+}
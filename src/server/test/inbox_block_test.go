@@ -0,0 +1,125 @@
+package test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"testing"
+)
+
+func Test_Inbox_Block(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	blockId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Block",
+		"actor": "%s", "object": "https://%s/u/%s"}`, blockId, h.sender.Id, birbHost, feedHandle)
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(blockId), gomock.Any()).Return(false, nil)
+	h.mockRepo.EXPECT().DoesAccountExist(gomock.Eq(feedHandle)).Return(true, nil)
+	h.mockRepo.EXPECT().RemoveFollower(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(nil).Times(1)
+	h.mockRepo.EXPECT().AddRemoteBlock(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id), gomock.Any()).Return(nil).Times(1)
+
+	reqProblem, err := inbox.HandleBlock(feedHandle, h.sender, []byte(body))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+func Test_Inbox_Follow_After_Block(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	followId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Follow",
+		"actor": "%s", "object": "https://%s/u/%s"}`, followId, h.sender.Id, birbHost, feedHandle)
+
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq(feedHandle)).Return(&dal.Account{Id: 5, Handle: feedHandle}, nil)
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(followId), gomock.Any()).Return(false, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq(callerHost)).Return(0, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(true, nil)
+	h.mockRepo.EXPECT().GetNextId().DoAndReturn(getNextId).AnyTimes()
+
+	// No follower gets stored; the Follow is rejected instead
+	h.mockRepo.EXPECT().AddFollower(gomock.Any(), gomock.Any()).Times(0)
+	h.mockMessenger.EXPECT().EnqueueActivity(gomock.Eq(feedHandle), gomock.Eq(h.sender.Inbox), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(byUser, toInbox string, act *dto.ActivityOut, priority int) error {
+			assert.Equal(t, "Reject", act.Type)
+			return nil
+		}).Times(1)
+
+	reqProblem, err := inbox.HandleFollow(feedHandle, h.sender, []byte(body))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+// A follower rejected through the API doesn't get back in by sending the Follow again
+func Test_Inbox_Follow_After_Reject(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	followId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Follow",
+		"actor": "%s", "object": "https://%s/u/%s"}`, followId, h.sender.Id, birbHost, feedHandle)
+
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq(feedHandle)).Return(&dal.Account{Id: 5, Handle: feedHandle}, nil)
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(followId), gomock.Any()).Return(false, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq(callerHost)).Return(0, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(false, nil)
+	h.mockRepo.EXPECT().GetFollower(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).
+		Return(&dal.FollowerInfo{UserUrl: h.sender.Id, ApproveStatus: -1}, nil)
+	h.mockRepo.EXPECT().GetNextId().DoAndReturn(getNextId).AnyTimes()
+
+	h.mockRepo.EXPECT().AddFollower(gomock.Any(), gomock.Any()).Times(0)
+	h.mockMessenger.EXPECT().EnqueueActivity(gomock.Eq(feedHandle), gomock.Eq(h.sender.Inbox), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(byUser, toInbox string, act *dto.ActivityOut, priority int) error {
+			assert.Equal(t, "Reject", act.Type)
+			return nil
+		}).Times(1)
+
+	reqProblem, err := inbox.HandleFollow(feedHandle, h.sender, []byte(body))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+func Test_Inbox_Reject_Follow(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	rejectId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	actBase := dto.ActivityInBase{
+		Id:    rejectId,
+		Type:  "Reject",
+		Actor: h.sender.Id,
+		Object: map[string]any{
+			"id":     fmt.Sprintf("https://%s/%d", callerHost, getNextId()),
+			"type":   "Follow",
+			"actor":  h.sender.Id,
+			"object": fmt.Sprintf("https://%s/u/%s", birbHost, feedHandle),
+		},
+	}
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(rejectId), gomock.Any()).Return(false, nil)
+	h.mockRelays.EXPECT().HandleResponse(gomock.Eq(h.sender), gomock.Any()).Return(false, nil)
+	h.mockRepo.EXPECT().DoesAccountExist(gomock.Eq(feedHandle)).Return(true, nil)
+	h.mockRepo.EXPECT().RemoveFollower(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(nil).Times(1)
+
+	reqProblem, err := inbox.HandleAcceptReject("", actBase, h.sender)
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+
+	// Same Reject again is cheap: nothing else is looked at
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(rejectId), gomock.Any()).Return(true, nil)
+	reqProblem, err = inbox.HandleAcceptReject("", actBase, h.sender)
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
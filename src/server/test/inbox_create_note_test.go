@@ -3,6 +3,7 @@ package test
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"rss_parrot/dal"
 	"rss_parrot/dto"
@@ -62,6 +63,10 @@ type inboxHarness struct {
 	mockSender    *mocks.MockIActivitySender
 	mockMessenger *mocks.MockIMessenger
 	mockFF        *mocks.MockIFeedFollower
+	mockRelays    *mocks.MockIRelays
+	mockDBlocks   *mocks.MockIDomainBlocks
+	mockReports   *mocks.MockIReports
+	mockUserRetr  *mocks.MockIUserRetriever
 	sender        *dto.UserInfo
 	birbUrl       string
 	birbMoniker   string
@@ -90,6 +95,10 @@ func setupInboxTest(t *testing.T) (*gomock.Controller, *inboxHarness, logic.IInb
 		mockSender:    mocks.NewMockIActivitySender(ctrl),
 		mockMessenger: mocks.NewMockIMessenger(ctrl),
 		mockFF:        mocks.NewMockIFeedFollower(ctrl),
+		mockRelays:    mocks.NewMockIRelays(ctrl),
+		mockDBlocks:   mocks.NewMockIDomainBlocks(ctrl),
+		mockReports:   mocks.NewMockIReports(ctrl),
+		mockUserRetr:  mocks.NewMockIUserRetriever(ctrl),
 		sender:        makeCallerUserInfo(callerHost, callerName, callerPubKey1),
 	}
 	h.birbUrl = fmt.Sprintf("https://%s/u/%s", h.cfg.Host, h.cfg.Birb.User)
@@ -101,7 +110,8 @@ func setupInboxTest(t *testing.T) (*gomock.Controller, *inboxHarness, logic.IInb
 	h.mockRepo.EXPECT().DeleteHandledActivities(gomock.Any()).AnyTimes()
 
 	inbox := logic.NewInbox(h.cfg, h.mockLogger, h.mockRepo, h.mockTexts, h.mockMetrics, h.mockUDir,
-		h.mockKeyStore, h.mockSender, h.mockMessenger, h.mockFF)
+		h.mockKeyStore, h.mockSender, h.mockMessenger, h.mockFF, h.mockRelays, h.mockDBlocks, h.mockReports,
+		h.mockUserRetr)
 
 	return ctrl, h, inbox
 }
@@ -422,3 +432,72 @@ func Test_BirbMentioned_OneUrl_BlockedFeed_Public(t *testing.T) {
 	content := strings.ReplaceAll(contentBirbOneUrl, "{{requested-url}}", requestedHost+"/"+requestedPath)
 	testInbox_CreateNoteActivity(t, vizPublic, content, rkNotAReply, abmkOneUrlBlockedFeed)
 }
+
+// Message to birb with several URLs, a hashtag and a second mention
+// -------------------------------------------
+const contentBirbMultiUrl = `<p><span class=\"h-card\" translate=\"no\"><a href=\"https://rss-parrot.zydeo.net/u/birb\" class=\"u-url mention\">@<span>birb</span></a></span> please parrot <a href=\"https://cute-animals.xyz/blog\" target=\"_blank\" rel=\"nofollow noopener noreferrer\" translate=\"no\"><span class=\"invisible\">https://</span><span class=\"\">cute-animals.xyz/blog</span><span class=\"invisible\"></span></a> and https://wild-animals.xyz/news, thanks <span class=\"h-card\" translate=\"no\"><a href=\"https://stardust.community/@ziggy\" class=\"u-url mention\">@<span>ziggy</span></a></span></p><p><a href=\"https://stardust.community/tags/rss\" class=\"mention hashtag\" rel=\"tag\">#<span>rss</span></a> <a href=\"https://cute-animals.xyz/blog\">again</a></p>`
+
+func Test_BirbMentioned_MultiUrl(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	tags := `[{"type":"Mention","href": "` + h.birbUrl + `","name": "` + h.birbMoniker + `"},
+		{"type":"Mention","href": "https://stardust.community/users/ziggy","name": "@ziggy@stardust.community"},
+		{"type":"Hashtag","href": "https://stardust.community/tags/rss","name": "#rss"}]`
+	bodyBytes := makeCreateNote(callerHost, callerName, contentBirbMultiUrl, []string{publicStream},
+		[]string{h.birbUrl, h.sender.Followers}, nil, tags)
+	var act dto.ActivityInBase
+	if err := json.Unmarshal(bodyBytes, &act); err != nil {
+		panic(err)
+	}
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(act.Id), gomock.Any()).Return(false, nil)
+	// Each distinct site is requested once; the hashtag and the mentions are not sites
+	h.mockFF.EXPECT().GetAccountForFeed(gomock.Eq("https://cute-animals.xyz/blog")).
+		Return(makeRequestedAccount(), logic.FeedStatus(logic.FsAlreadyFollowed), nil)
+	h.mockFF.EXPECT().GetAccountForFeed(gomock.Eq("https://wild-animals.xyz/news")).
+		Return(nil, logic.FeedStatus(logic.FsOptOut), fmt.Errorf("feed opted out"))
+
+	// One reply listing each result, in the order of the URLs in the message
+	var templates []string
+	h.mockTexts.EXPECT().WithVals(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, vals map[string]string) string {
+			templates = append(templates, id)
+			return fakeTextWithVals(id, vals)
+		}).Times(3)
+	h.mockMessenger.EXPECT().SendMessageAsync(
+		gomock.Eq(birbName),
+		gomock.Eq(h.sender.Inbox),
+		gomock.Any(),
+		gomock.Cond(checkSenderMention(h.sender, callerHost, true)),
+		gomock.Any(),
+		gomock.Any(),
+		gomock.Eq(act.Id)).Times(1)
+
+	inbox.HandleCreateNote(act, h.sender, bodyBytes)
+
+	assert.Equal(t, []string{"reply_multi_header.html", "reply_multi_existing.html", "reply_multi_optout.html"},
+		templates)
+}
+
+func Test_BirbMentioned_TooManyUrls(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+	h.cfg.MaxUrlsPerMention = 1
+
+	tags := `[{"type":"Mention","href": "` + h.birbUrl + `","name": "` + h.birbMoniker + `"}]`
+	bodyBytes := makeCreateNote(callerHost, callerName, contentBirbMultiUrl, []string{h.birbUrl}, nil, nil, tags)
+	var act dto.ActivityInBase
+	if err := json.Unmarshal(bodyBytes, &act); err != nil {
+		panic(err)
+	}
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(act.Id), gomock.Any()).Return(false, nil)
+	h.mockTexts.EXPECT().WithVals(gomock.Eq("reply_too_many_urls.html"), gomock.Any()).Return("")
+	h.mockMessenger.EXPECT().SendMessageAsync(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+	inbox.HandleCreateNote(act, h.sender, bodyBytes)
+}
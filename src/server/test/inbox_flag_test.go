@@ -0,0 +1,54 @@
+package test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"testing"
+)
+
+func Test_Inbox_Flag_Status(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	flagId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	actorUrl := fmt.Sprintf("https://%s/u/%s", birbHost, feedHandle)
+	statusUrl := fmt.Sprintf("https://%s/u/%s/status/1234", birbHost, feedHandle)
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Flag",
+		"actor": "%s", "content": "Spam", "object": ["%s", "%s", "https://elsewhere.social/notes/1"]}`,
+		flagId, h.sender.Id, actorUrl, statusUrl)
+	actBase := dto.ActivityInBase{Id: flagId, Type: "Flag", Actor: h.sender.Id}
+
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq(feedHandle)).Return(&dal.Account{Id: 5, Handle: feedHandle}, nil)
+	h.mockReports.EXPECT().AddReport(gomock.Any()).DoAndReturn(func(report *dal.Report) error {
+		assert.Equal(t, flagId, report.ActivityId)
+		assert.Equal(t, h.sender.Id, report.Reporter)
+		assert.Equal(t, feedHandle, report.AccountHandle)
+		assert.Equal(t, []string{statusUrl}, report.StatusIds)
+		assert.Equal(t, "Spam", report.Comment)
+		return nil
+	}).Times(1)
+
+	reqProblem, err := inbox.HandleFlag(actBase, h.sender, []byte(body))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+func Test_Inbox_Flag_Not_Ours(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	flagId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Flag",
+		"actor": "%s", "object": "https://elsewhere.social/users/someone"}`, flagId, h.sender.Id)
+	actBase := dto.ActivityInBase{Id: flagId, Type: "Flag", Actor: h.sender.Id}
+
+	reqProblem, err := inbox.HandleFlag(actBase, h.sender, []byte(body))
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", reqProblem)
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"testing"
+)
+
+func test_Inbox_Follow(t *testing.T, manuallyApproves bool) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	followId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Follow",
+		"actor": "%s", "object": "https://%s/u/%s"}`, followId, h.sender.Id, birbHost, feedHandle)
+
+	acct := &dal.Account{Id: 5, Handle: feedHandle, ManuallyApproves: manuallyApproves}
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq(feedHandle)).Return(acct, nil)
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(followId), gomock.Any()).Return(false, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq(callerHost)).Return(0, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(false, nil)
+	h.mockRepo.EXPECT().GetFollower(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(nil, nil)
+
+	// Follower is stored as unapproved either way; only auto-accepting accounts send the Accept right away
+	h.mockRepo.EXPECT().AddFollower(gomock.Eq(feedHandle), gomock.Any()).
+		DoAndReturn(func(user string, flwr *dal.FollowerInfo) error {
+			assert.Equal(t, 0, flwr.ApproveStatus)
+			assert.Equal(t, followId, flwr.RequestId)
+			return nil
+		}).Times(1)
+	acceptTimes := 1
+	if manuallyApproves {
+		acceptTimes = 0
+	}
+	h.mockUDir.EXPECT().AcceptFollower(gomock.Eq(followId), gomock.Eq(h.sender.Id), gomock.Eq(h.sender.Inbox),
+		gomock.Eq(feedHandle)).Return(nil).Times(acceptTimes)
+
+	reqProblem, err := inbox.HandleFollow(feedHandle, h.sender, []byte(body))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+func Test_Inbox_Follow_Auto_Accept(t *testing.T) {
+	test_Inbox_Follow(t, false)
+}
+
+func Test_Inbox_Follow_Manually_Approves(t *testing.T) {
+	test_Inbox_Follow(t, true)
+}
+
+// A failed write must not leave the activity marked as handled, or the inbound queue's retry would skip it
+func Test_Inbox_Follow_Failed_Unmarks(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	feedHandle := "some.blog.com"
+	followId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	body := fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Follow",
+		"actor": "%s", "object": "https://%s/u/%s"}`, followId, h.sender.Id, birbHost, feedHandle)
+
+	acct := &dal.Account{Id: 5, Handle: feedHandle}
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq(feedHandle)).Return(acct, nil)
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(followId), gomock.Any()).Return(false, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq(callerHost)).Return(0, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(false, nil)
+	h.mockRepo.EXPECT().GetFollower(gomock.Eq(feedHandle), gomock.Eq(h.sender.Id)).Return(nil, nil)
+	h.mockRepo.EXPECT().AddFollower(gomock.Eq(feedHandle), gomock.Any()).Return(errors.New("database is locked"))
+	h.mockRepo.EXPECT().UnmarkActivityHandled(gomock.Eq(followId)).Return(nil).Times(1)
+
+	_, err := inbox.HandleFollow(feedHandle, h.sender, []byte(body))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"testing"
+	"time"
+)
+
+func makeMoveBody(id, from, to string) []byte {
+	return []byte(fmt.Sprintf(`{"@context": "https://www.w3.org/ns/activitystreams", "id": "%s", "type": "Move",
+		"actor": "%s", "object": "%s", "target": "%s"}`, id, from, from, to))
+}
+
+func Test_Inbox_Move(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	moveId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	target := makeCallerUserInfo("newhome.social", "pixie", callerPubKey1)
+	// Aliases come as a single string or a list; parse the target as it would arrive
+	targetJson := fmt.Sprintf(`{"id": "%s", "type": "Person", "preferredUsername": "pixie", "inbox": "%s",
+		"endpoints": {"sharedInbox": "%s"}, "alsoKnownAs": "%s"}`,
+		target.Id, target.Inbox, target.Endpoints.SharedInbox, h.sender.Id)
+	var parsedTarget dto.UserInfo
+	assert.Nil(t, json.Unmarshal([]byte(targetJson), &parsedTarget))
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(moveId), gomock.Any()).Return(false, nil)
+	h.mockUserRetr.EXPECT().Retrieve(gomock.Eq(target.Id)).Return(&parsedTarget, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq("newhome.social")).Return(0, nil)
+	h.mockRepo.EXPECT().GetFollowedAccounts(gomock.Eq(h.sender.Id)).Return([]*dal.Account{
+		{Id: 1, Handle: "feed-a"},
+		{Id: 2, Handle: "feed-b"},
+	}, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Any(), gomock.Eq(target.Id)).Return(false, nil).Times(2)
+	for _, user := range []string{"feed-a", "feed-b"} {
+		h.mockRepo.EXPECT().MoveFollower(gomock.Eq(user), gomock.Eq(h.sender.Id), gomock.Any()).
+			DoAndReturn(func(_, _ string, flwr *dal.FollowerInfo) error {
+				assert.Equal(t, target.Id, flwr.UserUrl)
+				assert.Equal(t, "pixie", flwr.Handle)
+				assert.Equal(t, "newhome.social", flwr.Host)
+				assert.Equal(t, target.Inbox, flwr.UserInbox)
+				assert.Equal(t, target.Endpoints.SharedInbox, flwr.SharedInbox)
+				return nil
+			}).Times(1)
+	}
+
+	reqProblem, err := inbox.HandleMove(h.sender, makeMoveBody(moveId, h.sender.Id, target.Id))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+func Test_Inbox_Move_Without_Alias(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	moveId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	target := makeCallerUserInfo("newhome.social", "pixie", callerPubKey1)
+	target.AlsoKnownAs = []string{"https://elsewhere.social/users/pixie"}
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(moveId), gomock.Any()).Return(false, nil)
+	h.mockUserRetr.EXPECT().Retrieve(gomock.Eq(target.Id)).Return(target, nil)
+	h.mockRepo.EXPECT().MoveFollower(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	reqProblem, err := inbox.HandleMove(h.sender, makeMoveBody(moveId, h.sender.Id, target.Id))
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", reqProblem)
+}
+
+func Test_Inbox_Move_Checks_Target(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	moveId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	target := makeCallerUserInfo("newhome.social", "pixie", callerPubKey1)
+	target.AlsoKnownAs = []string{h.sender.Id}
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(moveId), gomock.Any()).Return(false, nil)
+	h.mockUserRetr.EXPECT().Retrieve(gomock.Eq(target.Id)).Return(target, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq("newhome.social")).Return(0, nil)
+	h.mockRepo.EXPECT().GetFollowedAccounts(gomock.Eq(h.sender.Id)).Return([]*dal.Account{
+		{Id: 1, Handle: "blocked-by-target"},
+		{Id: 2, Handle: "approves-followers", ManuallyApproves: true},
+		{Id: 3, Handle: "open"},
+	}, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Eq("blocked-by-target"), gomock.Eq(target.Id)).Return(true, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Any(), gomock.Eq(target.Id)).Return(false, nil).Times(2)
+	h.mockRepo.EXPECT().GetFollower(gomock.Any(), gomock.Eq(h.sender.Id)).Return(&dal.FollowerInfo{ApproveStatus: 1}, nil).Times(2)
+	h.mockRepo.EXPECT().RemoveFollower(gomock.Eq("blocked-by-target"), gomock.Eq(h.sender.Id)).Return(nil)
+	h.mockRepo.EXPECT().RemoveFollower(gomock.Eq("approves-followers"), gomock.Eq(h.sender.Id)).Return(nil)
+	h.mockRepo.EXPECT().MoveFollower(gomock.Eq("open"), gomock.Eq(h.sender.Id), gomock.Any()).Return(nil)
+
+	reqProblem, err := inbox.HandleMove(h.sender, makeMoveBody(moveId, h.sender.Id, target.Id))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+func Test_Inbox_Move_To_Blocked_Domain(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	moveId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	target := makeCallerUserInfo("newhome.social", "pixie", callerPubKey1)
+	target.AlsoKnownAs = []string{h.sender.Id}
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(moveId), gomock.Any()).Return(false, nil)
+	h.mockUserRetr.EXPECT().Retrieve(gomock.Eq(target.Id)).Return(target, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq("newhome.social")).Return(dal.DomainRejectFollows, nil)
+	h.mockRepo.EXPECT().GetFollowedAccounts(gomock.Eq(h.sender.Id)).Return([]*dal.Account{{Id: 1, Handle: "feed-a"}}, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Eq("feed-a"), gomock.Eq(target.Id)).Return(false, nil)
+	h.mockRepo.EXPECT().GetFollower(gomock.Eq("feed-a"), gomock.Eq(h.sender.Id)).Return(&dal.FollowerInfo{ApproveStatus: 1}, nil)
+	h.mockRepo.EXPECT().RemoveFollower(gomock.Eq("feed-a"), gomock.Eq(h.sender.Id)).Return(nil)
+	h.mockRepo.EXPECT().MoveFollower(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	reqProblem, err := inbox.HandleMove(h.sender, makeMoveBody(moveId, h.sender.Id, target.Id))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+func Test_Inbox_Move_Keeps_Ban(t *testing.T) {
+
+	ctrl, h, inbox := setupInboxTest(t)
+	defer ctrl.Finish()
+
+	moveId := fmt.Sprintf("https://%s/%d", callerHost, getNextId())
+	target := makeCallerUserInfo("newhome.social", "pixie", callerPubKey1)
+	target.AlsoKnownAs = []string{h.sender.Id}
+
+	h.mockRepo.EXPECT().MarkActivityHandled(gomock.Eq(moveId), gomock.Any()).Return(false, nil)
+	h.mockUserRetr.EXPECT().Retrieve(gomock.Eq(target.Id)).Return(target, nil)
+	h.mockDBlocks.EXPECT().GetLevel(gomock.Eq("newhome.social")).Return(0, nil)
+	h.mockRepo.EXPECT().GetFollowedAccounts(gomock.Eq(h.sender.Id)).Return([]*dal.Account{
+		{Id: 1, Handle: "approves-followers", ManuallyApproves: true},
+	}, nil)
+	h.mockRepo.EXPECT().IsRemoteBlocked(gomock.Any(), gomock.Eq(target.Id)).Return(false, nil)
+	h.mockRepo.EXPECT().GetFollower(gomock.Eq("approves-followers"), gomock.Eq(h.sender.Id)).
+		Return(&dal.FollowerInfo{ApproveStatus: -1, UserUrl: h.sender.Id}, nil)
+	h.mockRepo.EXPECT().RemoveFollower(gomock.Any(), gomock.Any()).Times(0)
+	h.mockRepo.EXPECT().MoveFollower(gomock.Eq("approves-followers"), gomock.Eq(h.sender.Id), gomock.Any()).Return(nil)
+
+	reqProblem, err := inbox.HandleMove(h.sender, makeMoveBody(moveId, h.sender.Id, target.Id))
+	assert.Nil(t, err)
+	assert.Equal(t, "", reqProblem)
+}
+
+// Merging with the new account's existing follow is all SQL, so this runs against a real database
+func Test_Move_Follower_Carries_Ban(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockLogger := mocks.NewMockILogger(ctrl)
+	setupDummyLogger(mockLogger)
+	cfg := &shared.Config{Host: birbHost, Birb: &shared.UserInfo{User: "birb"}, DbFile: t.TempDir() + "/parrot.db"}
+	repo := dal.NewRepo(cfg, mockLogger)
+	repo.InitUpdateDb()
+
+	_, err := repo.AddAccountIfNotExist(&dal.Account{CreatedAt: time.Now().UTC(), Handle: "feed-a"}, "")
+	assert.Nil(t, err)
+	oldUrl := "https://stardust.community/users/pixie"
+	newFlwr := dal.FollowerInfo{ApproveStatus: 1, UserUrl: "https://newhome.social/users/pixie",
+		Handle: "pixie", Host: "newhome.social"}
+	assert.Nil(t, repo.AddFollower("feed-a", &dal.FollowerInfo{ApproveStatus: -1, UserUrl: oldUrl,
+		Handle: "pixie", Host: callerHost}))
+	assert.Nil(t, repo.AddFollower("feed-a", &newFlwr))
+
+	assert.Nil(t, repo.MoveFollower("feed-a", oldUrl, &newFlwr))
+
+	oldRow, err := repo.GetFollower("feed-a", oldUrl)
+	assert.Nil(t, err)
+	assert.Nil(t, oldRow)
+	newRow, err := repo.GetFollower("feed-a", newFlwr.UserUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, newRow.ApproveStatus)
+}
@@ -0,0 +1,300 @@
+package test
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type messengerHarness struct {
+	cfg         *shared.Config
+	mockLogger  *mocks.MockILogger
+	mockRepo    *mocks.MockIRepo
+	mockKeys    *mocks.MockIKeyStore
+	mockSender  *mocks.MockIActivitySender
+	mockMetrics *mocks.MockIMetrics
+	mockDBlocks *mocks.MockIDomainBlocks
+	muQueue     sync.Mutex
+	onPick      func(skipIds []int, skipHosts []string, maxPerHost, maxCount int) // Sees what serveQueue is asked
+}
+
+// The messenger's queue loop starts right away, so each test must say what GetTootQueueItems returns
+func setupMessengerTest(t *testing.T) (*gomock.Controller, *messengerHarness) {
+
+	ctrl := gomock.NewController(t)
+	h := &messengerHarness{
+		cfg:         &shared.Config{Host: "parrot.net", Birb: &shared.UserInfo{User: "birb"}},
+		mockLogger:  mocks.NewMockILogger(ctrl),
+		mockRepo:    mocks.NewMockIRepo(ctrl),
+		mockKeys:    mocks.NewMockIKeyStore(ctrl),
+		mockSender:  mocks.NewMockIActivitySender(ctrl),
+		mockMetrics: mocks.NewMockIMetrics(ctrl),
+		mockDBlocks: mocks.NewMockIDomainBlocks(ctrl),
+	}
+	setupDummyLogger(h.mockLogger)
+	setupDummyMetrics(h.mockMetrics)
+	h.mockMetrics.EXPECT().TootQueueLength(gomock.Any()).AnyTimes()
+	return ctrl, h
+}
+
+func (h *messengerHarness) newMessenger() logic.IMessenger {
+	return logic.NewMessenger(h.cfg, h.mockLogger, h.mockRepo, h.mockKeys, h.mockSender, h.mockMetrics, h.mockDBlocks)
+}
+
+func Test_Messenger_Enqueue_To_Many(t *testing.T) {
+
+	ctrl, h := setupMessengerTest(t)
+	defer ctrl.Finish()
+
+	h.mockRepo.EXPECT().GetTootQueueItems(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, 0, nil).AnyTimes()
+	m := h.newMessenger()
+
+	_, edKey, _ := ed25519.GenerateKey(nil)
+	// Dead inboxes are looked up, and the activity signed, once for the whole batch
+	h.mockRepo.EXPECT().GetDeadInboxes(gomock.Any()).Return([]string{"https://dead.social/inbox"}, nil).Times(1)
+	h.mockKeys.EXPECT().GetEdPrivKey(gomock.Eq("some.blog.com")).Return(edKey, nil).Times(1)
+	h.mockDBlocks.EXPECT().IsBlocked(gomock.Eq("suspended.social")).Return(true, nil)
+	h.mockDBlocks.EXPECT().IsBlocked(gomock.Any()).Return(false, nil).Times(2)
+	h.mockMetrics.EXPECT().DeliveryResult(gomock.Eq("skipped")).Times(2)
+	var items []*dal.TootQueueItem
+	h.mockRepo.EXPECT().AddTootQueueItem(gomock.Any()).
+		Do(func(item *dal.TootQueueItem) { items = append(items, item) }).Return(nil).Times(2)
+
+	act := &dto.ActivityOut{
+		Context: "https://www.w3.org/ns/activitystreams",
+		Id:      "https://parrot.net/activity/1",
+		Type:    "Update",
+		Actor:   "https://parrot.net/u/some.blog.com",
+		Object:  "https://parrot.net/u/some.blog.com",
+	}
+	err := m.EnqueueActivityToMany("some.blog.com", []string{
+		"https://one.social/inbox",
+		"https://dead.social/inbox",
+		"https://suspended.social/inbox",
+		"https://two.social/users/c/inbox",
+	}, act, logic.PriorityBulk)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, "https://one.social/inbox", items[0].ToInbox)
+	assert.Equal(t, "one.social", items[0].ToHost)
+	assert.Equal(t, "https://two.social/users/c/inbox", items[1].ToInbox)
+	assert.Equal(t, "two.social", items[1].ToHost)
+	assert.Equal(t, items[0].Activity, items[1].Activity)
+	assert.Equal(t, logic.PriorityBulk, items[0].Priority)
+	var sent map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(items[0].Activity), &sent))
+	assert.Equal(t, "Update", sent["type"])
+	assert.NotNil(t, sent["proof"])
+}
+
+// Has the repo hand out the given items, once; the queue loop looks at them after the next kick.
+// Returns a function that wakes the loop by queuing an activity that is never handed out itself.
+func (h *messengerHarness) serveQueue(t *testing.T, m logic.IMessenger, deadInboxes []string,
+	items ...*dal.TootQueueItem) func() {
+
+	queued := items
+	h.mockRepo.EXPECT().GetTootQueueItems(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(due time.Time, skipIds []int, skipHosts []string, maxPerHost, maxCount int) (
+			[]*dal.TootQueueItem, int, error) {
+			h.muQueue.Lock()
+			defer h.muQueue.Unlock()
+			if h.onPick != nil {
+				h.onPick(skipIds, skipHosts, maxPerHost, maxCount)
+			}
+			res := queued
+			queued = nil
+			return res, len(res), nil
+		}).AnyTimes()
+	h.mockRepo.EXPECT().GetDeadInboxes(gomock.Any()).Return(deadInboxes, nil).AnyTimes()
+	h.mockKeys.EXPECT().GetEdPrivKey(gomock.Any()).Return(nil, errors.New("no key")).AnyTimes()
+	h.mockKeys.EXPECT().GetPrivKey(gomock.Any()).Return(nil, nil).AnyTimes()
+	h.mockDBlocks.EXPECT().IsBlocked(gomock.Any()).Return(false, nil).AnyTimes()
+	h.mockRepo.EXPECT().AddTootQueueItem(gomock.Any()).Return(nil).AnyTimes()
+	h.mockRepo.EXPECT().RecordInboxSuccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	h.mockRepo.EXPECT().RecordInboxFailure(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).AnyTimes()
+	h.mockMetrics.EXPECT().DeliveryResult(gomock.Any()).AnyTimes()
+
+	return func() {
+		act := &dto.ActivityOut{Id: "https://parrot.net/activity/kick", Type: "Update"}
+		assert.Nil(t, m.EnqueueActivity("birb", "https://kick.social/inbox", act, logic.PriorityBulk))
+	}
+}
+
+func Test_Messenger_Retry_Backoff(t *testing.T) {
+
+	ctrl, h := setupMessengerTest(t)
+	defer ctrl.Finish()
+
+	m := h.newMessenger()
+	kick := h.serveQueue(t, m, nil,
+		&dal.TootQueueItem{Id: 1, ToInbox: "https://a.social/inbox", ToHost: "a.social", Activity: "{}"},
+		&dal.TootQueueItem{Id: 2, ToInbox: "https://b.social/inbox", ToHost: "b.social", Activity: "{}", Attempts: 2},
+		&dal.TootQueueItem{Id: 3, ToInbox: "https://c.social/inbox", ToHost: "c.social", Activity: "{}", Attempts: 10},
+	)
+	h.mockSender.EXPECT().SendJson(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("503 Service Unavailable")).Times(3)
+
+	// Wait doubles from one minute with each failure, up to six hours
+	var wg sync.WaitGroup
+	wg.Add(3)
+	expectRetry := func(id, attempts int, delay time.Duration) {
+		h.mockRepo.EXPECT().RescheduleTootQueueItem(id, attempts, gomock.Any(), "503 Service Unavailable").
+			DoAndReturn(func(id, attempts int, nextAttemptAt time.Time, lastError string) error {
+				defer wg.Done()
+				assert.WithinDuration(t, time.Now().Add(delay), nextAttemptAt, 5*time.Second)
+				return nil
+			}).Times(1)
+	}
+	expectRetry(1, 1, time.Minute)
+	expectRetry(2, 3, 4*time.Minute)
+	expectRetry(3, 11, 6*time.Hour)
+
+	kick()
+	waitOnWG(t, &wg, time.Second)
+}
+
+func Test_Messenger_Gives_Up(t *testing.T) {
+
+	ctrl, h := setupMessengerTest(t)
+	defer ctrl.Finish()
+
+	m := h.newMessenger()
+	kick := h.serveQueue(t, m, nil,
+		&dal.TootQueueItem{Id: 1, ToInbox: "https://a.social/inbox", ToHost: "a.social", Activity: "{}", Attempts: 11})
+	h.mockSender.EXPECT().SendJson(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("connection refused")).Times(1)
+
+	// Last of the twelve attempts has failed: the item is removed, not rescheduled
+	var wg sync.WaitGroup
+	wg.Add(1)
+	h.mockRepo.EXPECT().RescheduleTootQueueItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	h.mockRepo.EXPECT().DeleteTootQueueItem(1).DoAndReturn(func(id int) error {
+		defer wg.Done()
+		return nil
+	}).Times(1)
+
+	kick()
+	waitOnWG(t, &wg, time.Second)
+}
+
+func Test_Messenger_Drops_Dead_Inbox(t *testing.T) {
+
+	ctrl, h := setupMessengerTest(t)
+	defer ctrl.Finish()
+
+	m := h.newMessenger()
+	// Item was queued before the inbox was given up on
+	kick := h.serveQueue(t, m, []string{"https://dead.social/inbox"},
+		&dal.TootQueueItem{Id: 1, ToInbox: "https://dead.social/inbox", ToHost: "dead.social", Activity: "{}"})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	h.mockSender.EXPECT().SendJson(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	h.mockRepo.EXPECT().DeleteTootQueueItem(1).DoAndReturn(func(id int) error {
+		defer wg.Done()
+		return nil
+	}).Times(1)
+
+	kick()
+	waitOnWG(t, &wg, time.Second)
+}
+
+func Test_Messenger_Limit_Per_Host(t *testing.T) {
+
+	ctrl, h := setupMessengerTest(t)
+	defer ctrl.Finish()
+
+	m := h.newMessenger()
+	kick := h.serveQueue(t, m, nil,
+		&dal.TootQueueItem{Id: 1, ToInbox: "https://a.social/inbox", ToHost: "a.social", Activity: "{}"},
+		&dal.TootQueueItem{Id: 2, ToInbox: "https://a.social/users/x/inbox", ToHost: "a.social", Activity: "{}"},
+		&dal.TootQueueItem{Id: 3, ToInbox: "https://a.social/users/y/inbox", ToHost: "a.social", Activity: "{}"},
+		&dal.TootQueueItem{Id: 4, ToInbox: "https://b.social/inbox", ToHost: "b.social", Activity: "{}"},
+	)
+
+	// Deliveries hang until released, so we can see what the next pick leaves out
+	release := make(chan struct{})
+	var started, finished sync.WaitGroup
+	started.Add(3)
+	finished.Add(3)
+	var mu sync.Mutex
+	var sentTo []string
+	h.mockSender.EXPECT().SendJson(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, _, inboxUrl string, _ []byte) error {
+			mu.Lock()
+			sentTo = append(sentTo, inboxUrl)
+			mu.Unlock()
+			started.Done()
+			<-release
+			return nil
+		}).Times(3)
+	h.mockRepo.EXPECT().DeleteTootQueueItem(gomock.Any()).DoAndReturn(func(id int) error {
+		defer finished.Done()
+		return nil
+	}).Times(3)
+
+	kick()
+	waitOnWG(t, &started, time.Second)
+	mu.Lock()
+	sort.Strings(sentTo)
+	assert.Equal(t, []string{"https://a.social/inbox", "https://a.social/users/x/inbox", "https://b.social/inbox"}, sentTo)
+	mu.Unlock()
+
+	// Items in flight are skipped, and so is the host that has no room for more
+	picked := make(chan struct{})
+	h.muQueue.Lock()
+	h.onPick = func(skipIds []int, skipHosts []string, maxPerHost, maxCount int) {
+		sort.Ints(skipIds)
+		assert.Equal(t, []int{1, 2, 4}, skipIds)
+		assert.Equal(t, []string{"a.social"}, skipHosts)
+		assert.Equal(t, 2, maxPerHost)
+		assert.Equal(t, (20-3)*2, maxCount)
+		close(picked)
+		h.onPick = nil
+	}
+	h.muQueue.Unlock()
+	kick()
+	select {
+	case <-picked:
+	case <-time.After(time.Second):
+		t.Errorf("Queue was not looked at again")
+	}
+
+	close(release)
+	waitOnWG(t, &finished, time.Second)
+}
+
+// Every inbox POST resumes its host, so only hosts known to be failing cost a DB write
+func Test_Messenger_Resume_Host(t *testing.T) {
+
+	ctrl, h := setupMessengerTest(t)
+	defer ctrl.Finish()
+
+	h.mockRepo.EXPECT().GetTootQueueItems(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, 0, nil).AnyTimes()
+	m := h.newMessenger()
+
+	h.mockRepo.EXPECT().GetFailingInboxes().Return([]*dal.InboxHealth{
+		{Inbox: "https://down.social/inbox", Host: "down.social", FailureStreak: 3},
+	}, nil).Times(1)
+	h.mockRepo.EXPECT().ResetInboxFailuresForHost("down.social").Return(1, nil).Times(1)
+
+	m.ResumeHost("fine.social")
+	m.ResumeHost("down.social")
+	m.ResumeHost("down.social")
+	m.ResumeHost("fine.social")
+}
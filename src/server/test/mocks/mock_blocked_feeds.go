@@ -11,6 +11,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	dal "rss_parrot/dal"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -19,6 +20,7 @@ import (
 type MockIBlockedFeeds struct {
 	ctrl     *gomock.Controller
 	recorder *MockIBlockedFeedsMockRecorder
+	isgomock struct{}
 }
 
 // MockIBlockedFeedsMockRecorder is the mock recorder for MockIBlockedFeeds.
@@ -38,17 +40,77 @@ func (m *MockIBlockedFeeds) EXPECT() *MockIBlockedFeedsMockRecorder {
 	return m.recorder
 }
 
+// AddBlock mocks base method.
+func (m *MockIBlockedFeeds) AddBlock(kind, pattern, reason string) (*dal.FeedBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBlock", kind, pattern, reason)
+	ret0, _ := ret[0].(*dal.FeedBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddBlock indicates an expected call of AddBlock.
+func (mr *MockIBlockedFeedsMockRecorder) AddBlock(kind, pattern, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBlock", reflect.TypeOf((*MockIBlockedFeeds)(nil).AddBlock), kind, pattern, reason)
+}
+
+// GetBlocks mocks base method.
+func (m *MockIBlockedFeeds) GetBlocks() ([]*dal.FeedBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocks")
+	ret0, _ := ret[0].([]*dal.FeedBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocks indicates an expected call of GetBlocks.
+func (mr *MockIBlockedFeedsMockRecorder) GetBlocks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocks", reflect.TypeOf((*MockIBlockedFeeds)(nil).GetBlocks))
+}
+
+// GetMatchingAccounts mocks base method.
+func (m *MockIBlockedFeeds) GetMatchingAccounts(block *dal.FeedBlock) ([]*dal.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMatchingAccounts", block)
+	ret0, _ := ret[0].([]*dal.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMatchingAccounts indicates an expected call of GetMatchingAccounts.
+func (mr *MockIBlockedFeedsMockRecorder) GetMatchingAccounts(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatchingAccounts", reflect.TypeOf((*MockIBlockedFeeds)(nil).GetMatchingAccounts), block)
+}
+
 // IsBlocked mocks base method.
-func (m *MockIBlockedFeeds) IsBlocked(arg0 string) (bool, error) {
+func (m *MockIBlockedFeeds) IsBlocked(feedUrl string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsBlocked", arg0)
+	ret := m.ctrl.Call(m, "IsBlocked", feedUrl)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // IsBlocked indicates an expected call of IsBlocked.
-func (mr *MockIBlockedFeedsMockRecorder) IsBlocked(arg0 any) *gomock.Call {
+func (mr *MockIBlockedFeedsMockRecorder) IsBlocked(feedUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockIBlockedFeeds)(nil).IsBlocked), feedUrl)
+}
+
+// RemoveBlock mocks base method.
+func (m *MockIBlockedFeeds) RemoveBlock(id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveBlock", id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveBlock indicates an expected call of RemoveBlock.
+func (mr *MockIBlockedFeedsMockRecorder) RemoveBlock(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockIBlockedFeeds)(nil).IsBlocked), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveBlock", reflect.TypeOf((*MockIBlockedFeeds)(nil).RemoveBlock), id)
 }
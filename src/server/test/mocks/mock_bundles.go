@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rss_parrot/logic (interfaces: IBundles)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_bundles.go -package mocks rss_parrot/logic IBundles
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	dal "rss_parrot/dal"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIBundles is a mock of IBundles interface.
+type MockIBundles struct {
+	ctrl     *gomock.Controller
+	recorder *MockIBundlesMockRecorder
+	isgomock struct{}
+}
+
+// MockIBundlesMockRecorder is the mock recorder for MockIBundles.
+type MockIBundlesMockRecorder struct {
+	mock *MockIBundles
+}
+
+// NewMockIBundles creates a new mock instance.
+func NewMockIBundles(ctrl *gomock.Controller) *MockIBundles {
+	mock := &MockIBundles{ctrl: ctrl}
+	mock.recorder = &MockIBundlesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIBundles) EXPECT() *MockIBundlesMockRecorder {
+	return m.recorder
+}
+
+// AnnounceToot mocks base method.
+func (m *MockIBundles) AnnounceToot(memberId int, statusId string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AnnounceToot", memberId, statusId)
+}
+
+// AnnounceToot indicates an expected call of AnnounceToot.
+func (mr *MockIBundlesMockRecorder) AnnounceToot(memberId, statusId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnounceToot", reflect.TypeOf((*MockIBundles)(nil).AnnounceToot), memberId, statusId)
+}
+
+// CreateBundle mocks base method.
+func (m *MockIBundles) CreateBundle(handle, name, summary string) (*dal.Account, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBundle", handle, name, summary)
+	ret0, _ := ret[0].(*dal.Account)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateBundle indicates an expected call of CreateBundle.
+func (mr *MockIBundlesMockRecorder) CreateBundle(handle, name, summary any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBundle", reflect.TypeOf((*MockIBundles)(nil).CreateBundle), handle, name, summary)
+}
@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rss_parrot/logic (interfaces: IDomainBlocks)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_domain_blocks.go -package mocks rss_parrot/logic IDomainBlocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	io "io"
+	reflect "reflect"
+	dal "rss_parrot/dal"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIDomainBlocks is a mock of IDomainBlocks interface.
+type MockIDomainBlocks struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDomainBlocksMockRecorder
+	isgomock struct{}
+}
+
+// MockIDomainBlocksMockRecorder is the mock recorder for MockIDomainBlocks.
+type MockIDomainBlocksMockRecorder struct {
+	mock *MockIDomainBlocks
+}
+
+// NewMockIDomainBlocks creates a new mock instance.
+func NewMockIDomainBlocks(ctrl *gomock.Controller) *MockIDomainBlocks {
+	mock := &MockIDomainBlocks{ctrl: ctrl}
+	mock.recorder = &MockIDomainBlocksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDomainBlocks) EXPECT() *MockIDomainBlocksMockRecorder {
+	return m.recorder
+}
+
+// GetBlocks mocks base method.
+func (m *MockIDomainBlocks) GetBlocks() ([]*dal.DomainBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocks")
+	ret0, _ := ret[0].([]*dal.DomainBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocks indicates an expected call of GetBlocks.
+func (mr *MockIDomainBlocksMockRecorder) GetBlocks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocks", reflect.TypeOf((*MockIDomainBlocks)(nil).GetBlocks))
+}
+
+// GetLevel mocks base method.
+func (m *MockIDomainBlocks) GetLevel(host string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLevel", host)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLevel indicates an expected call of GetLevel.
+func (mr *MockIDomainBlocksMockRecorder) GetLevel(host any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLevel", reflect.TypeOf((*MockIDomainBlocks)(nil).GetLevel), host)
+}
+
+// ImportCsv mocks base method.
+func (m *MockIDomainBlocks) ImportCsv(r io.Reader) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportCsv", r)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportCsv indicates an expected call of ImportCsv.
+func (mr *MockIDomainBlocksMockRecorder) ImportCsv(r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportCsv", reflect.TypeOf((*MockIDomainBlocks)(nil).ImportCsv), r)
+}
+
+// IsBlocked mocks base method.
+func (m *MockIDomainBlocks) IsBlocked(host string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlocked", host)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsBlocked indicates an expected call of IsBlocked.
+func (mr *MockIDomainBlocksMockRecorder) IsBlocked(host any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockIDomainBlocks)(nil).IsBlocked), host)
+}
+
+// RemoveBlock mocks base method.
+func (m *MockIDomainBlocks) RemoveBlock(domain string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveBlock", domain)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveBlock indicates an expected call of RemoveBlock.
+func (mr *MockIDomainBlocksMockRecorder) RemoveBlock(domain any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveBlock", reflect.TypeOf((*MockIDomainBlocks)(nil).RemoveBlock), domain)
+}
+
+// SetBlock mocks base method.
+func (m *MockIDomainBlocks) SetBlock(domain string, level int, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBlock", domain, level, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBlock indicates an expected call of SetBlock.
+func (mr *MockIDomainBlocksMockRecorder) SetBlock(domain, level, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlock", reflect.TypeOf((*MockIDomainBlocks)(nil).SetBlock), domain, level, reason)
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	reflect "reflect"
 	dal "rss_parrot/dal"
+	dto "rss_parrot/dto"
 	logic "rss_parrot/logic"
 
 	gomock "go.uber.org/mock/gomock"
@@ -21,6 +22,7 @@ import (
 type MockIFeedFollower struct {
 	ctrl     *gomock.Controller
 	recorder *MockIFeedFollowerMockRecorder
+	isgomock struct{}
 }
 
 // MockIFeedFollowerMockRecorder is the mock recorder for MockIFeedFollower.
@@ -41,9 +43,9 @@ func (m *MockIFeedFollower) EXPECT() *MockIFeedFollowerMockRecorder {
 }
 
 // GetAccountForFeed mocks base method.
-func (m *MockIFeedFollower) GetAccountForFeed(arg0 string) (*dal.Account, logic.FeedStatus, error) {
+func (m *MockIFeedFollower) GetAccountForFeed(urlStr string) (*dal.Account, logic.FeedStatus, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountForFeed", arg0)
+	ret := m.ctrl.Call(m, "GetAccountForFeed", urlStr)
 	ret0, _ := ret[0].(*dal.Account)
 	ret1, _ := ret[1].(logic.FeedStatus)
 	ret2, _ := ret[2].(error)
@@ -51,21 +53,64 @@ func (m *MockIFeedFollower) GetAccountForFeed(arg0 string) (*dal.Account, logic.
 }
 
 // GetAccountForFeed indicates an expected call of GetAccountForFeed.
-func (mr *MockIFeedFollowerMockRecorder) GetAccountForFeed(arg0 any) *gomock.Call {
+func (mr *MockIFeedFollowerMockRecorder) GetAccountForFeed(urlStr any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForFeed", reflect.TypeOf((*MockIFeedFollower)(nil).GetAccountForFeed), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForFeed", reflect.TypeOf((*MockIFeedFollower)(nil).GetAccountForFeed), urlStr)
+}
+
+// PurgeAccount mocks base method.
+func (m *MockIFeedFollower) PurgeAccount(acct *dal.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeAccount", acct)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeAccount indicates an expected call of PurgeAccount.
+func (mr *MockIFeedFollowerMockRecorder) PurgeAccount(acct any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeAccount", reflect.TypeOf((*MockIFeedFollower)(nil).PurgeAccount), acct)
 }
 
 // PurgeOldPosts mocks base method.
-func (m *MockIFeedFollower) PurgeOldPosts(arg0 *dal.Account, arg1, arg2 int) error {
+func (m *MockIFeedFollower) PurgeOldPosts(acct *dal.Account, minCount, minAgeDays int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PurgeOldPosts", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "PurgeOldPosts", acct, minCount, minAgeDays)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PurgeOldPosts indicates an expected call of PurgeOldPosts.
-func (mr *MockIFeedFollowerMockRecorder) PurgeOldPosts(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockIFeedFollowerMockRecorder) PurgeOldPosts(acct, minCount, minAgeDays any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeOldPosts", reflect.TypeOf((*MockIFeedFollower)(nil).PurgeOldPosts), acct, minCount, minAgeDays)
+}
+
+// RefreshFeed mocks base method.
+func (m *MockIFeedFollower) RefreshFeed(acct *dal.Account) *dto.FeedDiagnostics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshFeed", acct)
+	ret0, _ := ret[0].(*dto.FeedDiagnostics)
+	return ret0
+}
+
+// RefreshFeed indicates an expected call of RefreshFeed.
+func (mr *MockIFeedFollowerMockRecorder) RefreshFeed(acct any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshFeed", reflect.TypeOf((*MockIFeedFollower)(nil).RefreshFeed), acct)
+}
+
+// ValidateFeedUrl mocks base method.
+func (m *MockIFeedFollower) ValidateFeedUrl(feedUrl string) (logic.FeedStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateFeedUrl", feedUrl)
+	ret0, _ := ret[0].(logic.FeedStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateFeedUrl indicates an expected call of ValidateFeedUrl.
+func (mr *MockIFeedFollowerMockRecorder) ValidateFeedUrl(feedUrl any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeOldPosts", reflect.TypeOf((*MockIFeedFollower)(nil).PurgeOldPosts), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateFeedUrl", reflect.TypeOf((*MockIFeedFollower)(nil).ValidateFeedUrl), feedUrl)
 }
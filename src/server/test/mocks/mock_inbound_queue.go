@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rss_parrot/logic (interfaces: IInboundQueue)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_inbound_queue.go -package mocks rss_parrot/logic IInboundQueue
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	dto "rss_parrot/dto"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIInboundQueue is a mock of IInboundQueue interface.
+type MockIInboundQueue struct {
+	ctrl     *gomock.Controller
+	recorder *MockIInboundQueueMockRecorder
+	isgomock struct{}
+}
+
+// MockIInboundQueueMockRecorder is the mock recorder for MockIInboundQueue.
+type MockIInboundQueueMockRecorder struct {
+	mock *MockIInboundQueue
+}
+
+// NewMockIInboundQueue creates a new mock instance.
+func NewMockIInboundQueue(ctrl *gomock.Controller) *MockIInboundQueue {
+	mock := &MockIInboundQueue{ctrl: ctrl}
+	mock.recorder = &MockIInboundQueueMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIInboundQueue) EXPECT() *MockIInboundQueueMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method.
+func (m *MockIInboundQueue) Enqueue(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", receivingUser, senderInfo, bodyBytes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockIInboundQueueMockRecorder) Enqueue(receivingUser, senderInfo, bodyBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockIInboundQueue)(nil).Enqueue), receivingUser, senderInfo, bodyBytes)
+}
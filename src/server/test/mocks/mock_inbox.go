@@ -0,0 +1,146 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rss_parrot/logic (interfaces: IInbox)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_inbox.go -package mocks rss_parrot/logic IInbox
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	dto "rss_parrot/dto"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIInbox is a mock of IInbox interface.
+type MockIInbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockIInboxMockRecorder
+	isgomock struct{}
+}
+
+// MockIInboxMockRecorder is the mock recorder for MockIInbox.
+type MockIInboxMockRecorder struct {
+	mock *MockIInbox
+}
+
+// NewMockIInbox creates a new mock instance.
+func NewMockIInbox(ctrl *gomock.Controller) *MockIInbox {
+	mock := &MockIInbox{ctrl: ctrl}
+	mock.recorder = &MockIInboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIInbox) EXPECT() *MockIInboxMockRecorder {
+	return m.recorder
+}
+
+// HandleAcceptReject mocks base method.
+func (m *MockIInbox) HandleAcceptReject(receivingUser string, actBase dto.ActivityInBase, senderInfo *dto.UserInfo) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleAcceptReject", receivingUser, actBase, senderInfo)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleAcceptReject indicates an expected call of HandleAcceptReject.
+func (mr *MockIInboxMockRecorder) HandleAcceptReject(receivingUser, actBase, senderInfo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleAcceptReject", reflect.TypeOf((*MockIInbox)(nil).HandleAcceptReject), receivingUser, actBase, senderInfo)
+}
+
+// HandleBlock mocks base method.
+func (m *MockIInbox) HandleBlock(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleBlock", receivingUser, senderInfo, bodyBytes)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleBlock indicates an expected call of HandleBlock.
+func (mr *MockIInboxMockRecorder) HandleBlock(receivingUser, senderInfo, bodyBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleBlock", reflect.TypeOf((*MockIInbox)(nil).HandleBlock), receivingUser, senderInfo, bodyBytes)
+}
+
+// HandleCreateNote mocks base method.
+func (m *MockIInbox) HandleCreateNote(actBase dto.ActivityInBase, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleCreateNote", actBase, senderInfo, bodyBytes)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleCreateNote indicates an expected call of HandleCreateNote.
+func (mr *MockIInboxMockRecorder) HandleCreateNote(actBase, senderInfo, bodyBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleCreateNote", reflect.TypeOf((*MockIInbox)(nil).HandleCreateNote), actBase, senderInfo, bodyBytes)
+}
+
+// HandleFlag mocks base method.
+func (m *MockIInbox) HandleFlag(actBase dto.ActivityInBase, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleFlag", actBase, senderInfo, bodyBytes)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleFlag indicates an expected call of HandleFlag.
+func (mr *MockIInboxMockRecorder) HandleFlag(actBase, senderInfo, bodyBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleFlag", reflect.TypeOf((*MockIInbox)(nil).HandleFlag), actBase, senderInfo, bodyBytes)
+}
+
+// HandleFollow mocks base method.
+func (m *MockIInbox) HandleFollow(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleFollow", receivingUser, senderInfo, bodyBytes)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleFollow indicates an expected call of HandleFollow.
+func (mr *MockIInboxMockRecorder) HandleFollow(receivingUser, senderInfo, bodyBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleFollow", reflect.TypeOf((*MockIInbox)(nil).HandleFollow), receivingUser, senderInfo, bodyBytes)
+}
+
+// HandleMove mocks base method.
+func (m *MockIInbox) HandleMove(senderInfo *dto.UserInfo, bodyBytes []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleMove", senderInfo, bodyBytes)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleMove indicates an expected call of HandleMove.
+func (mr *MockIInboxMockRecorder) HandleMove(senderInfo, bodyBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleMove", reflect.TypeOf((*MockIInbox)(nil).HandleMove), senderInfo, bodyBytes)
+}
+
+// HandleUndo mocks base method.
+func (m *MockIInbox) HandleUndo(receivingUser string, senderInfo *dto.UserInfo, bodyBytes []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleUndo", receivingUser, senderInfo, bodyBytes)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleUndo indicates an expected call of HandleUndo.
+func (mr *MockIInboxMockRecorder) HandleUndo(receivingUser, senderInfo, bodyBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleUndo", reflect.TypeOf((*MockIInbox)(nil).HandleUndo), receivingUser, senderInfo, bodyBytes)
+}
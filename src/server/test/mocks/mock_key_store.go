@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	ed25519 "crypto/ed25519"
 	rsa "crypto/rsa"
 	reflect "reflect"
 
@@ -20,6 +21,7 @@ import (
 type MockIKeyStore struct {
 	ctrl     *gomock.Controller
 	recorder *MockIKeyStoreMockRecorder
+	isgomock struct{}
 }
 
 // MockIKeyStoreMockRecorder is the mock recorder for MockIKeyStore.
@@ -39,19 +41,63 @@ func (m *MockIKeyStore) EXPECT() *MockIKeyStoreMockRecorder {
 	return m.recorder
 }
 
+// CreateEdKeys mocks base method.
+func (m *MockIKeyStore) CreateEdKeys(user string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEdKeys", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEdKeys indicates an expected call of CreateEdKeys.
+func (mr *MockIKeyStoreMockRecorder) CreateEdKeys(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEdKeys", reflect.TypeOf((*MockIKeyStore)(nil).CreateEdKeys), user)
+}
+
+// GetEdPrivKey mocks base method.
+func (m *MockIKeyStore) GetEdPrivKey(user string) (ed25519.PrivateKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEdPrivKey", user)
+	ret0, _ := ret[0].(ed25519.PrivateKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEdPrivKey indicates an expected call of GetEdPrivKey.
+func (mr *MockIKeyStoreMockRecorder) GetEdPrivKey(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEdPrivKey", reflect.TypeOf((*MockIKeyStore)(nil).GetEdPrivKey), user)
+}
+
+// GetEdPubKey mocks base method.
+func (m *MockIKeyStore) GetEdPubKey(user string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEdPubKey", user)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEdPubKey indicates an expected call of GetEdPubKey.
+func (mr *MockIKeyStoreMockRecorder) GetEdPubKey(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEdPubKey", reflect.TypeOf((*MockIKeyStore)(nil).GetEdPubKey), user)
+}
+
 // GetPrivKey mocks base method.
-func (m *MockIKeyStore) GetPrivKey(arg0 string) (*rsa.PrivateKey, error) {
+func (m *MockIKeyStore) GetPrivKey(user string) (*rsa.PrivateKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPrivKey", arg0)
+	ret := m.ctrl.Call(m, "GetPrivKey", user)
 	ret0, _ := ret[0].(*rsa.PrivateKey)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPrivKey indicates an expected call of GetPrivKey.
-func (mr *MockIKeyStoreMockRecorder) GetPrivKey(arg0 any) *gomock.Call {
+func (mr *MockIKeyStoreMockRecorder) GetPrivKey(user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivKey", reflect.TypeOf((*MockIKeyStore)(nil).GetPrivKey), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivKey", reflect.TypeOf((*MockIKeyStore)(nil).GetPrivKey), user)
 }
 
 // MakeKeyPair mocks base method.
@@ -11,6 +11,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	dto "rss_parrot/dto"
 	logic "rss_parrot/logic"
 	time "time"
 
@@ -21,6 +22,7 @@ import (
 type MockIMessenger struct {
 	ctrl     *gomock.Controller
 	recorder *MockIMessengerMockRecorder
+	isgomock struct{}
 }
 
 // MockIMessengerMockRecorder is the mock recorder for MockIMessenger.
@@ -40,28 +42,98 @@ func (m *MockIMessenger) EXPECT() *MockIMessengerMockRecorder {
 	return m.recorder
 }
 
+// EnqueueActivity mocks base method.
+func (m *MockIMessenger) EnqueueActivity(byUser, toInbox string, act *dto.ActivityOut, priority int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueActivity", byUser, toInbox, act, priority)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueueActivity indicates an expected call of EnqueueActivity.
+func (mr *MockIMessengerMockRecorder) EnqueueActivity(byUser, toInbox, act, priority any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueActivity", reflect.TypeOf((*MockIMessenger)(nil).EnqueueActivity), byUser, toInbox, act, priority)
+}
+
+// EnqueueActivityToMany mocks base method.
+func (m *MockIMessenger) EnqueueActivityToMany(byUser string, toInboxes []string, act *dto.ActivityOut, priority int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueActivityToMany", byUser, toInboxes, act, priority)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueueActivityToMany indicates an expected call of EnqueueActivityToMany.
+func (mr *MockIMessengerMockRecorder) EnqueueActivityToMany(byUser, toInboxes, act, priority any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueActivityToMany", reflect.TypeOf((*MockIMessenger)(nil).EnqueueActivityToMany), byUser, toInboxes, act, priority)
+}
+
 // EnqueueBroadcast mocks base method.
-func (m *MockIMessenger) EnqueueBroadcast(arg0, arg1 string, arg2 time.Time, arg3 string) error {
+func (m *MockIMessenger) EnqueueBroadcast(user, statusId string, tootedAt time.Time, msg string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "EnqueueBroadcast", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "EnqueueBroadcast", user, statusId, tootedAt, msg)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // EnqueueBroadcast indicates an expected call of EnqueueBroadcast.
-func (mr *MockIMessengerMockRecorder) EnqueueBroadcast(arg0, arg1, arg2, arg3 any) *gomock.Call {
+func (mr *MockIMessengerMockRecorder) EnqueueBroadcast(user, statusId, tootedAt, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueBroadcast", reflect.TypeOf((*MockIMessenger)(nil).EnqueueBroadcast), user, statusId, tootedAt, msg)
+}
+
+// GetFailingInboxes mocks base method.
+func (m *MockIMessenger) GetFailingInboxes() ([]*dto.InboxHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailingInboxes")
+	ret0, _ := ret[0].([]*dto.InboxHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailingInboxes indicates an expected call of GetFailingInboxes.
+func (mr *MockIMessengerMockRecorder) GetFailingInboxes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailingInboxes", reflect.TypeOf((*MockIMessenger)(nil).GetFailingInboxes))
+}
+
+// GetQueueSummary mocks base method.
+func (m *MockIMessenger) GetQueueSummary() (*dto.DeliveryQueue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueueSummary")
+	ret0, _ := ret[0].(*dto.DeliveryQueue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueueSummary indicates an expected call of GetQueueSummary.
+func (mr *MockIMessengerMockRecorder) GetQueueSummary() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueueSummary", reflect.TypeOf((*MockIMessenger)(nil).GetQueueSummary))
+}
+
+// ResumeHost mocks base method.
+func (m *MockIMessenger) ResumeHost(host string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResumeHost", host)
+}
+
+// ResumeHost indicates an expected call of ResumeHost.
+func (mr *MockIMessengerMockRecorder) ResumeHost(host any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueBroadcast", reflect.TypeOf((*MockIMessenger)(nil).EnqueueBroadcast), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeHost", reflect.TypeOf((*MockIMessenger)(nil).ResumeHost), host)
 }
 
 // SendMessageAsync mocks base method.
-func (m *MockIMessenger) SendMessageAsync(arg0, arg1, arg2 string, arg3 []*logic.MsgMention, arg4, arg5 []string, arg6 string) {
+func (m *MockIMessenger) SendMessageAsync(byUser, toInbox, msg string, mentions []*logic.MsgMention, to, cc []string, inReplyTo string) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SendMessageAsync", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	m.ctrl.Call(m, "SendMessageAsync", byUser, toInbox, msg, mentions, to, cc, inReplyTo)
 }
 
 // SendMessageAsync indicates an expected call of SendMessageAsync.
-func (mr *MockIMessengerMockRecorder) SendMessageAsync(arg0, arg1, arg2, arg3, arg4, arg5, arg6 any) *gomock.Call {
+func (mr *MockIMessengerMockRecorder) SendMessageAsync(byUser, toInbox, msg, mentions, to, cc, inReplyTo any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessageAsync", reflect.TypeOf((*MockIMessenger)(nil).SendMessageAsync), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessageAsync", reflect.TypeOf((*MockIMessenger)(nil).SendMessageAsync), byUser, toInbox, msg, mentions, to, cc, inReplyTo)
 }
@@ -0,0 +1,92 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rss_parrot/logic (interfaces: IRelays)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_relays.go -package mocks rss_parrot/logic IRelays
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	dto "rss_parrot/dto"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIRelays is a mock of IRelays interface.
+type MockIRelays struct {
+	ctrl     *gomock.Controller
+	recorder *MockIRelaysMockRecorder
+	isgomock struct{}
+}
+
+// MockIRelaysMockRecorder is the mock recorder for MockIRelays.
+type MockIRelaysMockRecorder struct {
+	mock *MockIRelays
+}
+
+// NewMockIRelays creates a new mock instance.
+func NewMockIRelays(ctrl *gomock.Controller) *MockIRelays {
+	mock := &MockIRelays{ctrl: ctrl}
+	mock.recorder = &MockIRelaysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIRelays) EXPECT() *MockIRelaysMockRecorder {
+	return m.recorder
+}
+
+// AnnounceAccount mocks base method.
+func (m *MockIRelays) AnnounceAccount(user string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AnnounceAccount", user)
+}
+
+// AnnounceAccount indicates an expected call of AnnounceAccount.
+func (mr *MockIRelaysMockRecorder) AnnounceAccount(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnounceAccount", reflect.TypeOf((*MockIRelays)(nil).AnnounceAccount), user)
+}
+
+// AnnounceToot mocks base method.
+func (m *MockIRelays) AnnounceToot(user, statusId string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AnnounceToot", user, statusId)
+}
+
+// AnnounceToot indicates an expected call of AnnounceToot.
+func (mr *MockIRelaysMockRecorder) AnnounceToot(user, statusId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnounceToot", reflect.TypeOf((*MockIRelays)(nil).AnnounceToot), user, statusId)
+}
+
+// HandleResponse mocks base method.
+func (m *MockIRelays) HandleResponse(senderInfo *dto.UserInfo, act *dto.ActivityInBase) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleResponse", senderInfo, act)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HandleResponse indicates an expected call of HandleResponse.
+func (mr *MockIRelaysMockRecorder) HandleResponse(senderInfo, act any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleResponse", reflect.TypeOf((*MockIRelays)(nil).HandleResponse), senderInfo, act)
+}
+
+// SyncSubscriptions mocks base method.
+func (m *MockIRelays) SyncSubscriptions() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SyncSubscriptions")
+}
+
+// SyncSubscriptions indicates an expected call of SyncSubscriptions.
+func (mr *MockIRelaysMockRecorder) SyncSubscriptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncSubscriptions", reflect.TypeOf((*MockIRelays)(nil).SyncSubscriptions))
+}
@@ -21,6 +21,7 @@ import (
 type MockIRepo struct {
 	ctrl     *gomock.Controller
 	recorder *MockIRepoMockRecorder
+	isgomock struct{}
 }
 
 // MockIRepoMockRecorder is the mock recorder for MockIRepo.
@@ -41,153 +42,324 @@ func (m *MockIRepo) EXPECT() *MockIRepoMockRecorder {
 }
 
 // AddAccountIfNotExist mocks base method.
-func (m *MockIRepo) AddAccountIfNotExist(arg0 *dal.Account, arg1 string) (bool, error) {
+func (m *MockIRepo) AddAccountIfNotExist(account *dal.Account, privKey string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddAccountIfNotExist", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddAccountIfNotExist", account, privKey)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddAccountIfNotExist indicates an expected call of AddAccountIfNotExist.
-func (mr *MockIRepoMockRecorder) AddAccountIfNotExist(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) AddAccountIfNotExist(account, privKey any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountIfNotExist", reflect.TypeOf((*MockIRepo)(nil).AddAccountIfNotExist), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountIfNotExist", reflect.TypeOf((*MockIRepo)(nil).AddAccountIfNotExist), account, privKey)
+}
+
+// AddBundleMember mocks base method.
+func (m *MockIRepo) AddBundleMember(bundleId, memberId int, addedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBundleMember", bundleId, memberId, addedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddBundleMember indicates an expected call of AddBundleMember.
+func (mr *MockIRepoMockRecorder) AddBundleMember(bundleId, memberId, addedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBundleMember", reflect.TypeOf((*MockIRepo)(nil).AddBundleMember), bundleId, memberId, addedAt)
 }
 
 // AddFeedPostIfNew mocks base method.
-func (m *MockIRepo) AddFeedPostIfNew(arg0 int, arg1 *dal.FeedPost) (bool, error) {
+func (m *MockIRepo) AddFeedPostIfNew(accountId int, post *dal.FeedPost) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddFeedPostIfNew", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddFeedPostIfNew", accountId, post)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddFeedPostIfNew indicates an expected call of AddFeedPostIfNew.
-func (mr *MockIRepoMockRecorder) AddFeedPostIfNew(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) AddFeedPostIfNew(accountId, post any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFeedPostIfNew", reflect.TypeOf((*MockIRepo)(nil).AddFeedPostIfNew), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFeedPostIfNew", reflect.TypeOf((*MockIRepo)(nil).AddFeedPostIfNew), accountId, post)
 }
 
 // AddFollower mocks base method.
-func (m *MockIRepo) AddFollower(arg0 string, arg1 *dal.FollowerInfo) error {
+func (m *MockIRepo) AddFollower(user string, follower *dal.FollowerInfo) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddFollower", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddFollower", user, follower)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddFollower indicates an expected call of AddFollower.
-func (mr *MockIRepoMockRecorder) AddFollower(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) AddFollower(user, follower any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFollower", reflect.TypeOf((*MockIRepo)(nil).AddFollower), user, follower)
+}
+
+// AddInboundItem mocks base method.
+func (m *MockIRepo) AddInboundItem(item *dal.InboundItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddInboundItem", item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddInboundItem indicates an expected call of AddInboundItem.
+func (mr *MockIRepoMockRecorder) AddInboundItem(item any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFollower", reflect.TypeOf((*MockIRepo)(nil).AddFollower), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInboundItem", reflect.TypeOf((*MockIRepo)(nil).AddInboundItem), item)
+}
+
+// AddRemoteBlock mocks base method.
+func (m *MockIRepo) AddRemoteBlock(user, actorUrl string, blockedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRemoteBlock", user, actorUrl, blockedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRemoteBlock indicates an expected call of AddRemoteBlock.
+func (mr *MockIRepoMockRecorder) AddRemoteBlock(user, actorUrl, blockedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRemoteBlock", reflect.TypeOf((*MockIRepo)(nil).AddRemoteBlock), user, actorUrl, blockedAt)
+}
+
+// AddReport mocks base method.
+func (m *MockIRepo) AddReport(report *dal.Report) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReport", report)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReport indicates an expected call of AddReport.
+func (mr *MockIRepoMockRecorder) AddReport(report any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReport", reflect.TypeOf((*MockIRepo)(nil).AddReport), report)
 }
 
 // AddToot mocks base method.
-func (m *MockIRepo) AddToot(arg0 int, arg1 *dal.Toot) error {
+func (m *MockIRepo) AddToot(accountId int, toot *dal.Toot) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddToot", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddToot", accountId, toot)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddToot indicates an expected call of AddToot.
-func (mr *MockIRepoMockRecorder) AddToot(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) AddToot(accountId, toot any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToot", reflect.TypeOf((*MockIRepo)(nil).AddToot), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToot", reflect.TypeOf((*MockIRepo)(nil).AddToot), accountId, toot)
 }
 
 // AddTootQueueItem mocks base method.
-func (m *MockIRepo) AddTootQueueItem(arg0 *dal.TootQueueItem) error {
+func (m *MockIRepo) AddTootQueueItem(tqi *dal.TootQueueItem) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddTootQueueItem", arg0)
+	ret := m.ctrl.Call(m, "AddTootQueueItem", tqi)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddTootQueueItem indicates an expected call of AddTootQueueItem.
-func (mr *MockIRepoMockRecorder) AddTootQueueItem(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) AddTootQueueItem(tqi any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTootQueueItem", reflect.TypeOf((*MockIRepo)(nil).AddTootQueueItem), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTootQueueItem", reflect.TypeOf((*MockIRepo)(nil).AddTootQueueItem), tqi)
 }
 
 // BruteDeleteAccount mocks base method.
-func (m *MockIRepo) BruteDeleteAccount(arg0 int) error {
+func (m *MockIRepo) BruteDeleteAccount(accountId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BruteDeleteAccount", arg0)
+	ret := m.ctrl.Call(m, "BruteDeleteAccount", accountId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BruteDeleteAccount indicates an expected call of BruteDeleteAccount.
-func (mr *MockIRepoMockRecorder) BruteDeleteAccount(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) BruteDeleteAccount(accountId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BruteDeleteAccount", reflect.TypeOf((*MockIRepo)(nil).BruteDeleteAccount), accountId)
+}
+
+// DeleteAccountTombstone mocks base method.
+func (m *MockIRepo) DeleteAccountTombstone(user string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccountTombstone", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccountTombstone indicates an expected call of DeleteAccountTombstone.
+func (mr *MockIRepoMockRecorder) DeleteAccountTombstone(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountTombstone", reflect.TypeOf((*MockIRepo)(nil).DeleteAccountTombstone), user)
+}
+
+// DeleteCachedActors mocks base method.
+func (m *MockIRepo) DeleteCachedActors(fetchedBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCachedActors", fetchedBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCachedActors indicates an expected call of DeleteCachedActors.
+func (mr *MockIRepoMockRecorder) DeleteCachedActors(fetchedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCachedActors", reflect.TypeOf((*MockIRepo)(nil).DeleteCachedActors), fetchedBefore)
+}
+
+// DeleteDomainBlock mocks base method.
+func (m *MockIRepo) DeleteDomainBlock(domain string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDomainBlock", domain)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDomainBlock indicates an expected call of DeleteDomainBlock.
+func (mr *MockIRepoMockRecorder) DeleteDomainBlock(domain any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDomainBlock", reflect.TypeOf((*MockIRepo)(nil).DeleteDomainBlock), domain)
+}
+
+// DeleteFeedBlock mocks base method.
+func (m *MockIRepo) DeleteFeedBlock(id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFeedBlock", id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFeedBlock indicates an expected call of DeleteFeedBlock.
+func (mr *MockIRepoMockRecorder) DeleteFeedBlock(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BruteDeleteAccount", reflect.TypeOf((*MockIRepo)(nil).BruteDeleteAccount), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeedBlock", reflect.TypeOf((*MockIRepo)(nil).DeleteFeedBlock), id)
 }
 
 // DeleteHandledActivities mocks base method.
-func (m *MockIRepo) DeleteHandledActivities(arg0 time.Time) error {
+func (m *MockIRepo) DeleteHandledActivities(before time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteHandledActivities", arg0)
+	ret := m.ctrl.Call(m, "DeleteHandledActivities", before)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteHandledActivities indicates an expected call of DeleteHandledActivities.
-func (mr *MockIRepoMockRecorder) DeleteHandledActivities(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) DeleteHandledActivities(before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHandledActivities", reflect.TypeOf((*MockIRepo)(nil).DeleteHandledActivities), before)
+}
+
+// DeleteInboundItem mocks base method.
+func (m *MockIRepo) DeleteInboundItem(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInboundItem", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInboundItem indicates an expected call of DeleteInboundItem.
+func (mr *MockIRepoMockRecorder) DeleteInboundItem(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHandledActivities", reflect.TypeOf((*MockIRepo)(nil).DeleteHandledActivities), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInboundItem", reflect.TypeOf((*MockIRepo)(nil).DeleteInboundItem), id)
+}
+
+// DeleteRelay mocks base method.
+func (m *MockIRepo) DeleteRelay(inbox string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRelay", inbox)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRelay indicates an expected call of DeleteRelay.
+func (mr *MockIRepoMockRecorder) DeleteRelay(inbox any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRelay", reflect.TypeOf((*MockIRepo)(nil).DeleteRelay), inbox)
+}
+
+// DeleteToot mocks base method.
+func (m *MockIRepo) DeleteToot(statusId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteToot", statusId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteToot indicates an expected call of DeleteToot.
+func (mr *MockIRepoMockRecorder) DeleteToot(statusId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteToot", reflect.TypeOf((*MockIRepo)(nil).DeleteToot), statusId)
 }
 
 // DeleteTootQueueItem mocks base method.
-func (m *MockIRepo) DeleteTootQueueItem(arg0 int) error {
+func (m *MockIRepo) DeleteTootQueueItem(id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTootQueueItem", arg0)
+	ret := m.ctrl.Call(m, "DeleteTootQueueItem", id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteTootQueueItem indicates an expected call of DeleteTootQueueItem.
-func (mr *MockIRepoMockRecorder) DeleteTootQueueItem(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) DeleteTootQueueItem(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTootQueueItem", reflect.TypeOf((*MockIRepo)(nil).DeleteTootQueueItem), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTootQueueItem", reflect.TypeOf((*MockIRepo)(nil).DeleteTootQueueItem), id)
 }
 
 // DoesAccountExist mocks base method.
-func (m *MockIRepo) DoesAccountExist(arg0 string) (bool, error) {
+func (m *MockIRepo) DoesAccountExist(user string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DoesAccountExist", arg0)
+	ret := m.ctrl.Call(m, "DoesAccountExist", user)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DoesAccountExist indicates an expected call of DoesAccountExist.
-func (mr *MockIRepoMockRecorder) DoesAccountExist(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) DoesAccountExist(user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoesAccountExist", reflect.TypeOf((*MockIRepo)(nil).DoesAccountExist), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoesAccountExist", reflect.TypeOf((*MockIRepo)(nil).DoesAccountExist), user)
 }
 
 // GetAccount mocks base method.
-func (m *MockIRepo) GetAccount(arg0 string) (*dal.Account, error) {
+func (m *MockIRepo) GetAccount(user string) (*dal.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", arg0)
+	ret := m.ctrl.Call(m, "GetAccount", user)
 	ret0, _ := ret[0].(*dal.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAccount indicates an expected call of GetAccount.
-func (mr *MockIRepoMockRecorder) GetAccount(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetAccount(user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockIRepo)(nil).GetAccount), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockIRepo)(nil).GetAccount), user)
+}
+
+// GetAccountSummaries mocks base method.
+func (m *MockIRepo) GetAccountSummaries(filter *dal.AccountFilter) ([]*dal.AccountSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountSummaries", filter)
+	ret0, _ := ret[0].([]*dal.AccountSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountSummaries indicates an expected call of GetAccountSummaries.
+func (mr *MockIRepoMockRecorder) GetAccountSummaries(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountSummaries", reflect.TypeOf((*MockIRepo)(nil).GetAccountSummaries), filter)
 }
 
 // GetAccountToCheck mocks base method.
-func (m *MockIRepo) GetAccountToCheck(arg0 time.Time) (*dal.Account, int, error) {
+func (m *MockIRepo) GetAccountToCheck(checkDue time.Time) (*dal.Account, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountToCheck", arg0)
+	ret := m.ctrl.Call(m, "GetAccountToCheck", checkDue)
 	ret0, _ := ret[0].(*dal.Account)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
@@ -195,15 +367,30 @@ func (m *MockIRepo) GetAccountToCheck(arg0 time.Time) (*dal.Account, int, error)
 }
 
 // GetAccountToCheck indicates an expected call of GetAccountToCheck.
-func (mr *MockIRepoMockRecorder) GetAccountToCheck(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetAccountToCheck(checkDue any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountToCheck", reflect.TypeOf((*MockIRepo)(nil).GetAccountToCheck), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountToCheck", reflect.TypeOf((*MockIRepo)(nil).GetAccountToCheck), checkDue)
+}
+
+// GetAccountTombstone mocks base method.
+func (m *MockIRepo) GetAccountTombstone(user string) (*dal.AccountTombstone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountTombstone", user)
+	ret0, _ := ret[0].(*dal.AccountTombstone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountTombstone indicates an expected call of GetAccountTombstone.
+func (mr *MockIRepoMockRecorder) GetAccountTombstone(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountTombstone", reflect.TypeOf((*MockIRepo)(nil).GetAccountTombstone), user)
 }
 
 // GetAccountsPage mocks base method.
-func (m *MockIRepo) GetAccountsPage(arg0, arg1 int) ([]*dal.Account, int, error) {
+func (m *MockIRepo) GetAccountsPage(offset, limit int) ([]*dal.Account, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountsPage", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetAccountsPage", offset, limit)
 	ret0, _ := ret[0].([]*dal.Account)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
@@ -211,9 +398,160 @@ func (m *MockIRepo) GetAccountsPage(arg0, arg1 int) ([]*dal.Account, int, error)
 }
 
 // GetAccountsPage indicates an expected call of GetAccountsPage.
-func (mr *MockIRepoMockRecorder) GetAccountsPage(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetAccountsPage(offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsPage", reflect.TypeOf((*MockIRepo)(nil).GetAccountsPage), offset, limit)
+}
+
+// GetBundleMembers mocks base method.
+func (m *MockIRepo) GetBundleMembers(bundleId int) ([]*dal.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBundleMembers", bundleId)
+	ret0, _ := ret[0].([]*dal.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBundleMembers indicates an expected call of GetBundleMembers.
+func (mr *MockIRepoMockRecorder) GetBundleMembers(bundleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBundleMembers", reflect.TypeOf((*MockIRepo)(nil).GetBundleMembers), bundleId)
+}
+
+// GetBundlesOfMember mocks base method.
+func (m *MockIRepo) GetBundlesOfMember(memberId int) ([]*dal.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBundlesOfMember", memberId)
+	ret0, _ := ret[0].([]*dal.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBundlesOfMember indicates an expected call of GetBundlesOfMember.
+func (mr *MockIRepoMockRecorder) GetBundlesOfMember(memberId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBundlesOfMember", reflect.TypeOf((*MockIRepo)(nil).GetBundlesOfMember), memberId)
+}
+
+// GetCachedActor mocks base method.
+func (m *MockIRepo) GetCachedActor(userUrl string) (*dal.CachedActor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCachedActor", userUrl)
+	ret0, _ := ret[0].(*dal.CachedActor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCachedActor indicates an expected call of GetCachedActor.
+func (mr *MockIRepoMockRecorder) GetCachedActor(userUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCachedActor", reflect.TypeOf((*MockIRepo)(nil).GetCachedActor), userUrl)
+}
+
+// GetDeadInboxes mocks base method.
+func (m *MockIRepo) GetDeadInboxes(failingSince time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadInboxes", failingSince)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadInboxes indicates an expected call of GetDeadInboxes.
+func (mr *MockIRepoMockRecorder) GetDeadInboxes(failingSince any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadInboxes", reflect.TypeOf((*MockIRepo)(nil).GetDeadInboxes), failingSince)
+}
+
+// GetDomainBlocks mocks base method.
+func (m *MockIRepo) GetDomainBlocks() ([]*dal.DomainBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainBlocks")
+	ret0, _ := ret[0].([]*dal.DomainBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainBlocks indicates an expected call of GetDomainBlocks.
+func (mr *MockIRepoMockRecorder) GetDomainBlocks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainBlocks", reflect.TypeOf((*MockIRepo)(nil).GetDomainBlocks))
+}
+
+// GetEdKeys mocks base method.
+func (m *MockIRepo) GetEdKeys(user string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEdKeys", user)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetEdKeys indicates an expected call of GetEdKeys.
+func (mr *MockIRepoMockRecorder) GetEdKeys(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEdKeys", reflect.TypeOf((*MockIRepo)(nil).GetEdKeys), user)
+}
+
+// GetFailingInboxes mocks base method.
+func (m *MockIRepo) GetFailingInboxes() ([]*dal.InboxHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailingInboxes")
+	ret0, _ := ret[0].([]*dal.InboxHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailingInboxes indicates an expected call of GetFailingInboxes.
+func (mr *MockIRepoMockRecorder) GetFailingInboxes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailingInboxes", reflect.TypeOf((*MockIRepo)(nil).GetFailingInboxes))
+}
+
+// GetFeedAccounts mocks base method.
+func (m *MockIRepo) GetFeedAccounts() ([]*dal.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedAccounts")
+	ret0, _ := ret[0].([]*dal.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedAccounts indicates an expected call of GetFeedAccounts.
+func (mr *MockIRepoMockRecorder) GetFeedAccounts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedAccounts", reflect.TypeOf((*MockIRepo)(nil).GetFeedAccounts))
+}
+
+// GetFeedBlocks mocks base method.
+func (m *MockIRepo) GetFeedBlocks() ([]*dal.FeedBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedBlocks")
+	ret0, _ := ret[0].([]*dal.FeedBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedBlocks indicates an expected call of GetFeedBlocks.
+func (mr *MockIRepoMockRecorder) GetFeedBlocks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedBlocks", reflect.TypeOf((*MockIRepo)(nil).GetFeedBlocks))
+}
+
+// GetFeedErrors mocks base method.
+func (m *MockIRepo) GetFeedErrors(accountId int) ([]*dal.FeedError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedErrors", accountId)
+	ret0, _ := ret[0].([]*dal.FeedError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedErrors indicates an expected call of GetFeedErrors.
+func (mr *MockIRepoMockRecorder) GetFeedErrors(accountId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsPage", reflect.TypeOf((*MockIRepo)(nil).GetAccountsPage), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedErrors", reflect.TypeOf((*MockIRepo)(nil).GetFeedErrors), accountId)
 }
 
 // GetFeedFollowerCount mocks base method.
@@ -232,63 +570,124 @@ func (mr *MockIRepoMockRecorder) GetFeedFollowerCount() *gomock.Call {
 }
 
 // GetFeedLastUpdated mocks base method.
-func (m *MockIRepo) GetFeedLastUpdated(arg0 int) (time.Time, error) {
+func (m *MockIRepo) GetFeedLastUpdated(accountId int) (time.Time, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFeedLastUpdated", arg0)
+	ret := m.ctrl.Call(m, "GetFeedLastUpdated", accountId)
 	ret0, _ := ret[0].(time.Time)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFeedLastUpdated indicates an expected call of GetFeedLastUpdated.
-func (mr *MockIRepoMockRecorder) GetFeedLastUpdated(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetFeedLastUpdated(accountId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedLastUpdated", reflect.TypeOf((*MockIRepo)(nil).GetFeedLastUpdated), accountId)
+}
+
+// GetFollowedAccounts mocks base method.
+func (m *MockIRepo) GetFollowedAccounts(followerUserUrl string) ([]*dal.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFollowedAccounts", followerUserUrl)
+	ret0, _ := ret[0].([]*dal.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFollowedAccounts indicates an expected call of GetFollowedAccounts.
+func (mr *MockIRepoMockRecorder) GetFollowedAccounts(followerUserUrl any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedLastUpdated", reflect.TypeOf((*MockIRepo)(nil).GetFeedLastUpdated), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowedAccounts", reflect.TypeOf((*MockIRepo)(nil).GetFollowedAccounts), followerUserUrl)
+}
+
+// GetFollower mocks base method.
+func (m *MockIRepo) GetFollower(user, followerUserUrl string) (*dal.FollowerInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFollower", user, followerUserUrl)
+	ret0, _ := ret[0].(*dal.FollowerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFollower indicates an expected call of GetFollower.
+func (mr *MockIRepoMockRecorder) GetFollower(user, followerUserUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollower", reflect.TypeOf((*MockIRepo)(nil).GetFollower), user, followerUserUrl)
 }
 
 // GetFollowerCount mocks base method.
-func (m *MockIRepo) GetFollowerCount(arg0 string, arg1 bool) (uint, error) {
+func (m *MockIRepo) GetFollowerCount(user string, onlyApproved bool) (uint, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFollowerCount", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetFollowerCount", user, onlyApproved)
 	ret0, _ := ret[0].(uint)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFollowerCount indicates an expected call of GetFollowerCount.
-func (mr *MockIRepoMockRecorder) GetFollowerCount(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetFollowerCount(user, onlyApproved any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowerCount", reflect.TypeOf((*MockIRepo)(nil).GetFollowerCount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowerCount", reflect.TypeOf((*MockIRepo)(nil).GetFollowerCount), user, onlyApproved)
+}
+
+// GetFollowerDomainCount mocks base method.
+func (m *MockIRepo) GetFollowerDomainCount() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFollowerDomainCount")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFollowerDomainCount indicates an expected call of GetFollowerDomainCount.
+func (mr *MockIRepoMockRecorder) GetFollowerDomainCount() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowerDomainCount", reflect.TypeOf((*MockIRepo)(nil).GetFollowerDomainCount))
 }
 
 // GetFollowersById mocks base method.
-func (m *MockIRepo) GetFollowersById(arg0 int, arg1 bool) ([]*dal.FollowerInfo, error) {
+func (m *MockIRepo) GetFollowersById(accountId int, onlyApproved bool) ([]*dal.FollowerInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFollowersById", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetFollowersById", accountId, onlyApproved)
 	ret0, _ := ret[0].([]*dal.FollowerInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFollowersById indicates an expected call of GetFollowersById.
-func (mr *MockIRepoMockRecorder) GetFollowersById(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetFollowersById(accountId, onlyApproved any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowersById", reflect.TypeOf((*MockIRepo)(nil).GetFollowersById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowersById", reflect.TypeOf((*MockIRepo)(nil).GetFollowersById), accountId, onlyApproved)
 }
 
 // GetFollowersByUser mocks base method.
-func (m *MockIRepo) GetFollowersByUser(arg0 string, arg1 bool) ([]*dal.FollowerInfo, error) {
+func (m *MockIRepo) GetFollowersByUser(user string, onlyApproved bool) ([]*dal.FollowerInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFollowersByUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetFollowersByUser", user, onlyApproved)
 	ret0, _ := ret[0].([]*dal.FollowerInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFollowersByUser indicates an expected call of GetFollowersByUser.
-func (mr *MockIRepoMockRecorder) GetFollowersByUser(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetFollowersByUser(user, onlyApproved any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowersByUser", reflect.TypeOf((*MockIRepo)(nil).GetFollowersByUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowersByUser", reflect.TypeOf((*MockIRepo)(nil).GetFollowersByUser), user, onlyApproved)
+}
+
+// GetInboundItems mocks base method.
+func (m *MockIRepo) GetInboundItems(due time.Time, skipIds []int, maxCount int) ([]*dal.InboundItem, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInboundItems", due, skipIds, maxCount)
+	ret0, _ := ret[0].([]*dal.InboundItem)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetInboundItems indicates an expected call of GetInboundItems.
+func (mr *MockIRepoMockRecorder) GetInboundItems(due, skipIds, maxCount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInboundItems", reflect.TypeOf((*MockIRepo)(nil).GetInboundItems), due, skipIds, maxCount)
 }
 
 // GetNextId mocks base method.
@@ -305,85 +704,175 @@ func (mr *MockIRepoMockRecorder) GetNextId() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextId", reflect.TypeOf((*MockIRepo)(nil).GetNextId))
 }
 
+// GetOpenReportCount mocks base method.
+func (m *MockIRepo) GetOpenReportCount() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenReportCount")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenReportCount indicates an expected call of GetOpenReportCount.
+func (mr *MockIRepoMockRecorder) GetOpenReportCount() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenReportCount", reflect.TypeOf((*MockIRepo)(nil).GetOpenReportCount))
+}
+
 // GetPostCount mocks base method.
-func (m *MockIRepo) GetPostCount(arg0 string) (uint, error) {
+func (m *MockIRepo) GetPostCount(user string) (uint, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostCount", arg0)
+	ret := m.ctrl.Call(m, "GetPostCount", user)
 	ret0, _ := ret[0].(uint)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPostCount indicates an expected call of GetPostCount.
-func (mr *MockIRepoMockRecorder) GetPostCount(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetPostCount(user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostCount", reflect.TypeOf((*MockIRepo)(nil).GetPostCount), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostCount", reflect.TypeOf((*MockIRepo)(nil).GetPostCount), user)
 }
 
 // GetPostsPage mocks base method.
-func (m *MockIRepo) GetPostsPage(arg0, arg1, arg2 int) ([]*dal.FeedPost, error) {
+func (m *MockIRepo) GetPostsPage(accountId, offset, limit int) ([]*dal.FeedPost, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsPage", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "GetPostsPage", accountId, offset, limit)
 	ret0, _ := ret[0].([]*dal.FeedPost)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPostsPage indicates an expected call of GetPostsPage.
-func (mr *MockIRepoMockRecorder) GetPostsPage(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetPostsPage(accountId, offset, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsPage", reflect.TypeOf((*MockIRepo)(nil).GetPostsPage), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsPage", reflect.TypeOf((*MockIRepo)(nil).GetPostsPage), accountId, offset, limit)
 }
 
 // GetPrivKey mocks base method.
-func (m *MockIRepo) GetPrivKey(arg0 string) (string, error) {
+func (m *MockIRepo) GetPrivKey(user string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPrivKey", arg0)
+	ret := m.ctrl.Call(m, "GetPrivKey", user)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPrivKey indicates an expected call of GetPrivKey.
-func (mr *MockIRepoMockRecorder) GetPrivKey(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetPrivKey(user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivKey", reflect.TypeOf((*MockIRepo)(nil).GetPrivKey), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivKey", reflect.TypeOf((*MockIRepo)(nil).GetPrivKey), user)
+}
+
+// GetRelays mocks base method.
+func (m *MockIRepo) GetRelays() ([]*dal.Relay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRelays")
+	ret0, _ := ret[0].([]*dal.Relay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelays indicates an expected call of GetRelays.
+func (mr *MockIRepoMockRecorder) GetRelays() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRelays", reflect.TypeOf((*MockIRepo)(nil).GetRelays))
+}
+
+// GetReport mocks base method.
+func (m *MockIRepo) GetReport(id int) (*dal.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReport", id)
+	ret0, _ := ret[0].(*dal.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReport indicates an expected call of GetReport.
+func (mr *MockIRepoMockRecorder) GetReport(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReport", reflect.TypeOf((*MockIRepo)(nil).GetReport), id)
+}
+
+// GetReports mocks base method.
+func (m *MockIRepo) GetReports(onlyOpen bool) ([]*dal.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReports", onlyOpen)
+	ret0, _ := ret[0].([]*dal.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReports indicates an expected call of GetReports.
+func (mr *MockIRepoMockRecorder) GetReports(onlyOpen any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReports", reflect.TypeOf((*MockIRepo)(nil).GetReports), onlyOpen)
+}
+
+// GetSysParam mocks base method.
+func (m *MockIRepo) GetSysParam(name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSysParam", name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSysParam indicates an expected call of GetSysParam.
+func (mr *MockIRepoMockRecorder) GetSysParam(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSysParam", reflect.TypeOf((*MockIRepo)(nil).GetSysParam), name)
 }
 
 // GetToot mocks base method.
-func (m *MockIRepo) GetToot(arg0 string) (*dal.Toot, error) {
+func (m *MockIRepo) GetToot(statusId string) (*dal.Toot, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetToot", arg0)
+	ret := m.ctrl.Call(m, "GetToot", statusId)
 	ret0, _ := ret[0].(*dal.Toot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetToot indicates an expected call of GetToot.
-func (mr *MockIRepoMockRecorder) GetToot(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetToot(statusId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetToot", reflect.TypeOf((*MockIRepo)(nil).GetToot), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetToot", reflect.TypeOf((*MockIRepo)(nil).GetToot), statusId)
 }
 
 // GetTootExtracts mocks base method.
-func (m *MockIRepo) GetTootExtracts(arg0 int) ([]*dal.Toot, error) {
+func (m *MockIRepo) GetTootExtracts(accountId int) ([]*dal.Toot, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTootExtracts", arg0)
+	ret := m.ctrl.Call(m, "GetTootExtracts", accountId)
 	ret0, _ := ret[0].([]*dal.Toot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTootExtracts indicates an expected call of GetTootExtracts.
-func (mr *MockIRepoMockRecorder) GetTootExtracts(arg0 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetTootExtracts(accountId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTootExtracts", reflect.TypeOf((*MockIRepo)(nil).GetTootExtracts), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTootExtracts", reflect.TypeOf((*MockIRepo)(nil).GetTootExtracts), accountId)
+}
+
+// GetTootQueueCount mocks base method.
+func (m *MockIRepo) GetTootQueueCount(sendingUser string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTootQueueCount", sendingUser)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTootQueueCount indicates an expected call of GetTootQueueCount.
+func (mr *MockIRepoMockRecorder) GetTootQueueCount(sendingUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTootQueueCount", reflect.TypeOf((*MockIRepo)(nil).GetTootQueueCount), sendingUser)
 }
 
 // GetTootQueueItems mocks base method.
-func (m *MockIRepo) GetTootQueueItems(arg0, arg1 int) ([]*dal.TootQueueItem, int, error) {
+func (m *MockIRepo) GetTootQueueItems(due time.Time, skipIds []int, skipHosts []string, maxPerHost, maxCount int) ([]*dal.TootQueueItem, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTootQueueItems", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetTootQueueItems", due, skipIds, skipHosts, maxPerHost, maxCount)
 	ret0, _ := ret[0].([]*dal.TootQueueItem)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
@@ -391,9 +880,24 @@ func (m *MockIRepo) GetTootQueueItems(arg0, arg1 int) ([]*dal.TootQueueItem, int
 }
 
 // GetTootQueueItems indicates an expected call of GetTootQueueItems.
-func (mr *MockIRepoMockRecorder) GetTootQueueItems(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) GetTootQueueItems(due, skipIds, skipHosts, maxPerHost, maxCount any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTootQueueItems", reflect.TypeOf((*MockIRepo)(nil).GetTootQueueItems), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTootQueueItems", reflect.TypeOf((*MockIRepo)(nil).GetTootQueueItems), due, skipIds, skipHosts, maxPerHost, maxCount)
+}
+
+// GetTootQueueSummary mocks base method.
+func (m *MockIRepo) GetTootQueueSummary(due time.Time) (*dal.TootQueueSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTootQueueSummary", due)
+	ret0, _ := ret[0].(*dal.TootQueueSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTootQueueSummary indicates an expected call of GetTootQueueSummary.
+func (mr *MockIRepoMockRecorder) GetTootQueueSummary(due any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTootQueueSummary", reflect.TypeOf((*MockIRepo)(nil).GetTootQueueSummary), due)
 }
 
 // GetTotalPostCount mocks base method.
@@ -423,75 +927,428 @@ func (mr *MockIRepoMockRecorder) InitUpdateDb() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitUpdateDb", reflect.TypeOf((*MockIRepo)(nil).InitUpdateDb))
 }
 
+// IsRemoteBlocked mocks base method.
+func (m *MockIRepo) IsRemoteBlocked(user, actorUrl string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRemoteBlocked", user, actorUrl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRemoteBlocked indicates an expected call of IsRemoteBlocked.
+func (mr *MockIRepoMockRecorder) IsRemoteBlocked(user, actorUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRemoteBlocked", reflect.TypeOf((*MockIRepo)(nil).IsRemoteBlocked), user, actorUrl)
+}
+
 // MarkActivityHandled mocks base method.
-func (m *MockIRepo) MarkActivityHandled(arg0 string, arg1 time.Time) (bool, error) {
+func (m *MockIRepo) MarkActivityHandled(id string, when time.Time) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkActivityHandled", arg0, arg1)
+	ret := m.ctrl.Call(m, "MarkActivityHandled", id, when)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // MarkActivityHandled indicates an expected call of MarkActivityHandled.
-func (mr *MockIRepoMockRecorder) MarkActivityHandled(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) MarkActivityHandled(id, when any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkActivityHandled", reflect.TypeOf((*MockIRepo)(nil).MarkActivityHandled), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkActivityHandled", reflect.TypeOf((*MockIRepo)(nil).MarkActivityHandled), id, when)
+}
+
+// MoveFollower mocks base method.
+func (m *MockIRepo) MoveFollower(user, oldUserUrl string, flwr *dal.FollowerInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveFollower", user, oldUserUrl, flwr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveFollower indicates an expected call of MoveFollower.
+func (mr *MockIRepoMockRecorder) MoveFollower(user, oldUserUrl, flwr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveFollower", reflect.TypeOf((*MockIRepo)(nil).MoveFollower), user, oldUserUrl, flwr)
 }
 
 // PurgePostsAndToots mocks base method.
-func (m *MockIRepo) PurgePostsAndToots(arg0 int, arg1 time.Time) error {
+func (m *MockIRepo) PurgePostsAndToots(accountId int, fromBefore time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PurgePostsAndToots", arg0, arg1)
+	ret := m.ctrl.Call(m, "PurgePostsAndToots", accountId, fromBefore)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PurgePostsAndToots indicates an expected call of PurgePostsAndToots.
-func (mr *MockIRepoMockRecorder) PurgePostsAndToots(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) PurgePostsAndToots(accountId, fromBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgePostsAndToots", reflect.TypeOf((*MockIRepo)(nil).PurgePostsAndToots), accountId, fromBefore)
+}
+
+// RecordFeedCheck mocks base method.
+func (m *MockIRepo) RecordFeedCheck(accountId int, when time.Time, checkErr string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFeedCheck", accountId, when, checkErr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFeedCheck indicates an expected call of RecordFeedCheck.
+func (mr *MockIRepoMockRecorder) RecordFeedCheck(accountId, when, checkErr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFeedCheck", reflect.TypeOf((*MockIRepo)(nil).RecordFeedCheck), accountId, when, checkErr)
+}
+
+// RecordInboxFailure mocks base method.
+func (m *MockIRepo) RecordInboxFailure(inbox, host string, when time.Time, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordInboxFailure", inbox, host, when, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordInboxFailure indicates an expected call of RecordInboxFailure.
+func (mr *MockIRepoMockRecorder) RecordInboxFailure(inbox, host, when, lastError any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgePostsAndToots", reflect.TypeOf((*MockIRepo)(nil).PurgePostsAndToots), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordInboxFailure", reflect.TypeOf((*MockIRepo)(nil).RecordInboxFailure), inbox, host, when, lastError)
+}
+
+// RecordInboxSuccess mocks base method.
+func (m *MockIRepo) RecordInboxSuccess(inbox, host string, when time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordInboxSuccess", inbox, host, when)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordInboxSuccess indicates an expected call of RecordInboxSuccess.
+func (mr *MockIRepoMockRecorder) RecordInboxSuccess(inbox, host, when any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordInboxSuccess", reflect.TypeOf((*MockIRepo)(nil).RecordInboxSuccess), inbox, host, when)
+}
+
+// RemoveBundleMember mocks base method.
+func (m *MockIRepo) RemoveBundleMember(bundleId, memberId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveBundleMember", bundleId, memberId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveBundleMember indicates an expected call of RemoveBundleMember.
+func (mr *MockIRepoMockRecorder) RemoveBundleMember(bundleId, memberId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveBundleMember", reflect.TypeOf((*MockIRepo)(nil).RemoveBundleMember), bundleId, memberId)
 }
 
 // RemoveFollower mocks base method.
-func (m *MockIRepo) RemoveFollower(arg0, arg1 string) error {
+func (m *MockIRepo) RemoveFollower(user, followerUserUrl string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveFollower", arg0, arg1)
+	ret := m.ctrl.Call(m, "RemoveFollower", user, followerUserUrl)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveFollower indicates an expected call of RemoveFollower.
-func (mr *MockIRepoMockRecorder) RemoveFollower(arg0, arg1 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) RemoveFollower(user, followerUserUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFollower", reflect.TypeOf((*MockIRepo)(nil).RemoveFollower), user, followerUserUrl)
+}
+
+// RemoveRemoteBlock mocks base method.
+func (m *MockIRepo) RemoveRemoteBlock(user, actorUrl string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRemoteBlock", user, actorUrl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRemoteBlock indicates an expected call of RemoveRemoteBlock.
+func (mr *MockIRepoMockRecorder) RemoveRemoteBlock(user, actorUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRemoteBlock", reflect.TypeOf((*MockIRepo)(nil).RemoveRemoteBlock), user, actorUrl)
+}
+
+// RescheduleInboundItem mocks base method.
+func (m *MockIRepo) RescheduleInboundItem(id, attempts int, nextAttemptAt time.Time, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleInboundItem", id, attempts, nextAttemptAt, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RescheduleInboundItem indicates an expected call of RescheduleInboundItem.
+func (mr *MockIRepoMockRecorder) RescheduleInboundItem(id, attempts, nextAttemptAt, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleInboundItem", reflect.TypeOf((*MockIRepo)(nil).RescheduleInboundItem), id, attempts, nextAttemptAt, lastError)
+}
+
+// RescheduleTootQueueItem mocks base method.
+func (m *MockIRepo) RescheduleTootQueueItem(id, attempts int, nextAttemptAt time.Time, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleTootQueueItem", id, attempts, nextAttemptAt, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RescheduleTootQueueItem indicates an expected call of RescheduleTootQueueItem.
+func (mr *MockIRepoMockRecorder) RescheduleTootQueueItem(id, attempts, nextAttemptAt, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleTootQueueItem", reflect.TypeOf((*MockIRepo)(nil).RescheduleTootQueueItem), id, attempts, nextAttemptAt, lastError)
+}
+
+// ResetInboxFailuresForHost mocks base method.
+func (m *MockIRepo) ResetInboxFailuresForHost(host string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetInboxFailuresForHost", host)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetInboxFailuresForHost indicates an expected call of ResetInboxFailuresForHost.
+func (mr *MockIRepoMockRecorder) ResetInboxFailuresForHost(host any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFollower", reflect.TypeOf((*MockIRepo)(nil).RemoveFollower), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetInboxFailuresForHost", reflect.TypeOf((*MockIRepo)(nil).ResetInboxFailuresForHost), host)
+}
+
+// ResolveReport mocks base method.
+func (m *MockIRepo) ResolveReport(id int, resolution string, resolvedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveReport", id, resolution, resolvedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveReport indicates an expected call of ResolveReport.
+func (mr *MockIRepoMockRecorder) ResolveReport(id, resolution, resolvedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveReport", reflect.TypeOf((*MockIRepo)(nil).ResolveReport), id, resolution, resolvedAt)
+}
+
+// SaveCachedActor mocks base method.
+func (m *MockIRepo) SaveCachedActor(actor *dal.CachedActor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCachedActor", actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCachedActor indicates an expected call of SaveCachedActor.
+func (mr *MockIRepoMockRecorder) SaveCachedActor(actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCachedActor", reflect.TypeOf((*MockIRepo)(nil).SaveCachedActor), actor)
+}
+
+// SaveDomainBlock mocks base method.
+func (m *MockIRepo) SaveDomainBlock(block *dal.DomainBlock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDomainBlock", block)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDomainBlock indicates an expected call of SaveDomainBlock.
+func (mr *MockIRepoMockRecorder) SaveDomainBlock(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDomainBlock", reflect.TypeOf((*MockIRepo)(nil).SaveDomainBlock), block)
+}
+
+// SaveFeedBlock mocks base method.
+func (m *MockIRepo) SaveFeedBlock(block *dal.FeedBlock) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFeedBlock", block)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveFeedBlock indicates an expected call of SaveFeedBlock.
+func (mr *MockIRepoMockRecorder) SaveFeedBlock(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFeedBlock", reflect.TypeOf((*MockIRepo)(nil).SaveFeedBlock), block)
+}
+
+// SaveRelay mocks base method.
+func (m *MockIRepo) SaveRelay(relay *dal.Relay) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRelay", relay)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRelay indicates an expected call of SaveRelay.
+func (mr *MockIRepoMockRecorder) SaveRelay(relay any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRelay", reflect.TypeOf((*MockIRepo)(nil).SaveRelay), relay)
+}
+
+// SetAccountManuallyApproves mocks base method.
+func (m *MockIRepo) SetAccountManuallyApproves(accountId int, manuallyApproves bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountManuallyApproves", accountId, manuallyApproves)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccountManuallyApproves indicates an expected call of SetAccountManuallyApproves.
+func (mr *MockIRepoMockRecorder) SetAccountManuallyApproves(accountId, manuallyApproves any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountManuallyApproves", reflect.TypeOf((*MockIRepo)(nil).SetAccountManuallyApproves), accountId, manuallyApproves)
+}
+
+// SetAccountRelayToots mocks base method.
+func (m *MockIRepo) SetAccountRelayToots(accountId int, relayToots bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountRelayToots", accountId, relayToots)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccountRelayToots indicates an expected call of SetAccountRelayToots.
+func (mr *MockIRepoMockRecorder) SetAccountRelayToots(accountId, relayToots any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountRelayToots", reflect.TypeOf((*MockIRepo)(nil).SetAccountRelayToots), accountId, relayToots)
+}
+
+// SetEdKeysIfMissing mocks base method.
+func (m *MockIRepo) SetEdKeysIfMissing(user, pubKey, privKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEdKeysIfMissing", user, pubKey, privKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEdKeysIfMissing indicates an expected call of SetEdKeysIfMissing.
+func (mr *MockIRepoMockRecorder) SetEdKeysIfMissing(user, pubKey, privKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEdKeysIfMissing", reflect.TypeOf((*MockIRepo)(nil).SetEdKeysIfMissing), user, pubKey, privKey)
 }
 
 // SetFollowerApproveStatus mocks base method.
-func (m *MockIRepo) SetFollowerApproveStatus(arg0, arg1 string, arg2 int) error {
+func (m *MockIRepo) SetFollowerApproveStatus(user, followerUserUrl string, status int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetFollowerApproveStatus", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "SetFollowerApproveStatus", user, followerUserUrl, status)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetFollowerApproveStatus indicates an expected call of SetFollowerApproveStatus.
-func (mr *MockIRepoMockRecorder) SetFollowerApproveStatus(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) SetFollowerApproveStatus(user, followerUserUrl, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFollowerApproveStatus", reflect.TypeOf((*MockIRepo)(nil).SetFollowerApproveStatus), user, followerUserUrl, status)
+}
+
+// SetSysParam mocks base method.
+func (m *MockIRepo) SetSysParam(name, val string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSysParam", name, val)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSysParam indicates an expected call of SetSysParam.
+func (mr *MockIRepoMockRecorder) SetSysParam(name, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSysParam", reflect.TypeOf((*MockIRepo)(nil).SetSysParam), name, val)
+}
+
+// TombstoneAccount mocks base method.
+func (m *MockIRepo) TombstoneAccount(accountId int, deletedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TombstoneAccount", accountId, deletedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TombstoneAccount indicates an expected call of TombstoneAccount.
+func (mr *MockIRepoMockRecorder) TombstoneAccount(accountId, deletedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TombstoneAccount", reflect.TypeOf((*MockIRepo)(nil).TombstoneAccount), accountId, deletedAt)
+}
+
+// UnmarkActivityHandled mocks base method.
+func (m *MockIRepo) UnmarkActivityHandled(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnmarkActivityHandled", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnmarkActivityHandled indicates an expected call of UnmarkActivityHandled.
+func (mr *MockIRepoMockRecorder) UnmarkActivityHandled(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnmarkActivityHandled", reflect.TypeOf((*MockIRepo)(nil).UnmarkActivityHandled), id)
+}
+
+// UpdateAccountFeedMeta mocks base method.
+func (m *MockIRepo) UpdateAccountFeedMeta(accountId int, feedMetaHash int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountFeedMeta", accountId, feedMetaHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountFeedMeta indicates an expected call of UpdateAccountFeedMeta.
+func (mr *MockIRepoMockRecorder) UpdateAccountFeedMeta(accountId, feedMetaHash any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFollowerApproveStatus", reflect.TypeOf((*MockIRepo)(nil).SetFollowerApproveStatus), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountFeedMeta", reflect.TypeOf((*MockIRepo)(nil).UpdateAccountFeedMeta), accountId, feedMetaHash)
 }
 
 // UpdateAccountFeedTimes mocks base method.
-func (m *MockIRepo) UpdateAccountFeedTimes(arg0 int, arg1, arg2 time.Time) error {
+func (m *MockIRepo) UpdateAccountFeedTimes(accountId int, lastUpdated, nextCheckDue time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccountFeedTimes", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "UpdateAccountFeedTimes", accountId, lastUpdated, nextCheckDue)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateAccountFeedTimes indicates an expected call of UpdateAccountFeedTimes.
-func (mr *MockIRepoMockRecorder) UpdateAccountFeedTimes(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockIRepoMockRecorder) UpdateAccountFeedTimes(accountId, lastUpdated, nextCheckDue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountFeedTimes", reflect.TypeOf((*MockIRepo)(nil).UpdateAccountFeedTimes), accountId, lastUpdated, nextCheckDue)
+}
+
+// UpdateAccountProfile mocks base method.
+func (m *MockIRepo) UpdateAccountProfile(accountId int, feedName, feedSummary, profileImageUrl string, feedMetaHash int64, updatedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountProfile", accountId, feedName, feedSummary, profileImageUrl, feedMetaHash, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountProfile indicates an expected call of UpdateAccountProfile.
+func (mr *MockIRepoMockRecorder) UpdateAccountProfile(accountId, feedName, feedSummary, profileImageUrl, feedMetaHash, updatedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountProfile", reflect.TypeOf((*MockIRepo)(nil).UpdateAccountProfile), accountId, feedName, feedSummary, profileImageUrl, feedMetaHash, updatedAt)
+}
+
+// UpdateAccountSettings mocks base method.
+func (m *MockIRepo) UpdateAccountSettings(accountId int, settings *dal.AccountSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountSettings", accountId, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountSettings indicates an expected call of UpdateAccountSettings.
+func (mr *MockIRepoMockRecorder) UpdateAccountSettings(accountId, settings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountSettings", reflect.TypeOf((*MockIRepo)(nil).UpdateAccountSettings), accountId, settings)
+}
+
+// UpdateFollowerInboxes mocks base method.
+func (m *MockIRepo) UpdateFollowerInboxes(userUrl, userInbox, sharedInbox string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFollowerInboxes", userUrl, userInbox, sharedInbox)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFollowerInboxes indicates an expected call of UpdateFollowerInboxes.
+func (mr *MockIRepoMockRecorder) UpdateFollowerInboxes(userUrl, userInbox, sharedInbox any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountFeedTimes", reflect.TypeOf((*MockIRepo)(nil).UpdateAccountFeedTimes), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFollowerInboxes", reflect.TypeOf((*MockIRepo)(nil).UpdateFollowerInboxes), userUrl, userInbox, sharedInbox)
 }
 
 // Vacuum mocks base method.
@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rss_parrot/logic (interfaces: IReports)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_reports.go -package mocks rss_parrot/logic IReports
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	dal "rss_parrot/dal"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIReports is a mock of IReports interface.
+type MockIReports struct {
+	ctrl     *gomock.Controller
+	recorder *MockIReportsMockRecorder
+	isgomock struct{}
+}
+
+// MockIReportsMockRecorder is the mock recorder for MockIReports.
+type MockIReportsMockRecorder struct {
+	mock *MockIReports
+}
+
+// NewMockIReports creates a new mock instance.
+func NewMockIReports(ctrl *gomock.Controller) *MockIReports {
+	mock := &MockIReports{ctrl: ctrl}
+	mock.recorder = &MockIReportsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIReports) EXPECT() *MockIReportsMockRecorder {
+	return m.recorder
+}
+
+// AddReport mocks base method.
+func (m *MockIReports) AddReport(report *dal.Report) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReport", report)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReport indicates an expected call of AddReport.
+func (mr *MockIReportsMockRecorder) AddReport(report any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReport", reflect.TypeOf((*MockIReports)(nil).AddReport), report)
+}
+
+// GetReports mocks base method.
+func (m *MockIReports) GetReports(onlyOpen bool) ([]*dal.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReports", onlyOpen)
+	ret0, _ := ret[0].([]*dal.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReports indicates an expected call of GetReports.
+func (mr *MockIReportsMockRecorder) GetReports(onlyOpen any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReports", reflect.TypeOf((*MockIReports)(nil).GetReports), onlyOpen)
+}
+
+// Resolve mocks base method.
+func (m *MockIReports) Resolve(id int, action string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", id, action)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockIReportsMockRecorder) Resolve(id, action any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockIReports)(nil).Resolve), id, action)
+}
@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rss_parrot/logic (interfaces: IUserRetriever)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination ../test/mocks/mock_user_retriever.go -package mocks rss_parrot/logic IUserRetriever
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	dto "rss_parrot/dto"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIUserRetriever is a mock of IUserRetriever interface.
+type MockIUserRetriever struct {
+	ctrl     *gomock.Controller
+	recorder *MockIUserRetrieverMockRecorder
+	isgomock struct{}
+}
+
+// MockIUserRetrieverMockRecorder is the mock recorder for MockIUserRetriever.
+type MockIUserRetrieverMockRecorder struct {
+	mock *MockIUserRetriever
+}
+
+// NewMockIUserRetriever creates a new mock instance.
+func NewMockIUserRetriever(ctrl *gomock.Controller) *MockIUserRetriever {
+	mock := &MockIUserRetriever{ctrl: ctrl}
+	mock.recorder = &MockIUserRetrieverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIUserRetriever) EXPECT() *MockIUserRetrieverMockRecorder {
+	return m.recorder
+}
+
+// Retrieve mocks base method.
+func (m *MockIUserRetriever) Retrieve(userUrl string) (*dto.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retrieve", userUrl)
+	ret0, _ := ret[0].(*dto.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Retrieve indicates an expected call of Retrieve.
+func (mr *MockIUserRetrieverMockRecorder) Retrieve(userUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retrieve", reflect.TypeOf((*MockIUserRetriever)(nil).Retrieve), userUrl)
+}
+
+// RetrieveCached mocks base method.
+func (m *MockIUserRetriever) RetrieveCached(userUrl string) (*dto.UserInfo, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveCached", userUrl)
+	ret0, _ := ret[0].(*dto.UserInfo)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RetrieveCached indicates an expected call of RetrieveCached.
+func (mr *MockIUserRetrieverMockRecorder) RetrieveCached(userUrl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveCached", reflect.TypeOf((*MockIUserRetriever)(nil).RetrieveCached), userUrl)
+}
+
+// RetrieveWebfinger mocks base method.
+func (m *MockIUserRetriever) RetrieveWebfinger(user, host string) (*dto.WebfingerResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveWebfinger", user, host)
+	ret0, _ := ret[0].(*dto.WebfingerResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrieveWebfinger indicates an expected call of RetrieveWebfinger.
+func (mr *MockIUserRetrieverMockRecorder) RetrieveWebfinger(user, host any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveWebfinger", reflect.TypeOf((*MockIUserRetriever)(nil).RetrieveWebfinger), user, host)
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"testing"
+)
+
+const (
+	relayInbox      = "https://relay.example/inbox"
+	relayLitePub    = "https://litepub.example/inbox"
+	relayLitePubAct = "https://litepub.example/relay"
+)
+
+type relaysHarness struct {
+	ctrl          *gomock.Controller
+	mockRepo      *mocks.MockIRepo
+	mockMessenger *mocks.MockIMessenger
+	rl            logic.IRelays
+}
+
+func setupRelaysTest(t *testing.T, cfgRelays []shared.Relay) *relaysHarness {
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks.NewMockILogger(ctrl)
+	setupDummyLogger(mockLogger)
+	h := relaysHarness{
+		ctrl:          ctrl,
+		mockRepo:      mocks.NewMockIRepo(ctrl),
+		mockMessenger: mocks.NewMockIMessenger(ctrl),
+	}
+	h.mockRepo.EXPECT().GetNextId().DoAndReturn(getNextId).AnyTimes()
+	cfg := &shared.Config{
+		Host:   "parrot.example",
+		Birb:   &shared.UserInfo{User: "birb"},
+		Relays: cfgRelays,
+	}
+	h.rl = logic.NewRelays(cfg, mockLogger, h.mockRepo, h.mockMessenger)
+	return &h
+}
+
+func Test_Relays_Response_Matches_Follow_Id(t *testing.T) {
+
+	h := setupRelaysTest(t, []shared.Relay{{Inbox: relayInbox}})
+	defer h.ctrl.Finish()
+
+	h.mockRepo.EXPECT().GetRelays().Return([]*dal.Relay{
+		{Inbox: relayInbox, FollowId: "https://parrot.example/activity/1", Status: dal.RelayPending},
+	}, nil).AnyTimes()
+	var saved *dal.Relay
+	h.mockRepo.EXPECT().SaveRelay(gomock.Any()).Do(func(r *dal.Relay) { saved = r }).Return(nil).Times(1)
+
+	sender := &dto.UserInfo{Id: "https://relay.example/actor"}
+	act := &dto.ActivityInBase{Type: "Accept", Object: map[string]any{
+		"id":   "https://parrot.example/activity/1",
+		"type": "Follow",
+	}}
+	isRelay, err := h.rl.HandleResponse(sender, act)
+	assert.Nil(t, err)
+	assert.True(t, isRelay)
+	assert.Equal(t, dal.RelayAccepted, saved.Status)
+
+	// Same host, but about some other Follow: not the relay's answer
+	act = &dto.ActivityInBase{Type: "Reject", Object: "https://parrot.example/activity/99"}
+	isRelay, err = h.rl.HandleResponse(sender, act)
+	assert.Nil(t, err)
+	assert.False(t, isRelay)
+
+	// Right ID, but from another host
+	act = &dto.ActivityInBase{Type: "Reject", Object: "https://parrot.example/activity/1"}
+	isRelay, err = h.rl.HandleResponse(&dto.UserInfo{Id: "https://evil.example/actor"}, act)
+	assert.Nil(t, err)
+	assert.False(t, isRelay)
+}
+
+func Test_Relays_Response_Without_Id_Needs_Actor(t *testing.T) {
+
+	h := setupRelaysTest(t, []shared.Relay{{Inbox: relayInbox}, {Inbox: relayLitePub, Actor: relayLitePubAct}})
+	defer h.ctrl.Finish()
+
+	h.mockRepo.EXPECT().GetRelays().Return([]*dal.Relay{
+		{Inbox: relayInbox, FollowId: "https://parrot.example/activity/1", Status: dal.RelayPending},
+		{Inbox: relayLitePub, FollowId: "https://parrot.example/activity/2", Status: dal.RelayPending},
+	}, nil).AnyTimes()
+	var saved *dal.Relay
+	h.mockRepo.EXPECT().SaveRelay(gomock.Any()).Do(func(r *dal.Relay) { saved = r }).Return(nil).Times(1)
+
+	// No configured actor to vouch for the sender
+	act := &dto.ActivityInBase{Type: "Accept", Object: map[string]any{"type": "Follow"}}
+	isRelay, err := h.rl.HandleResponse(&dto.UserInfo{Id: "https://relay.example/actor"}, act)
+	assert.Nil(t, err)
+	assert.False(t, isRelay)
+
+	// Some other actor on the LitePub relay's host
+	isRelay, err = h.rl.HandleResponse(&dto.UserInfo{Id: "https://litepub.example/users/bob"}, act)
+	assert.Nil(t, err)
+	assert.False(t, isRelay)
+
+	isRelay, err = h.rl.HandleResponse(&dto.UserInfo{Id: relayLitePubAct}, act)
+	assert.Nil(t, err)
+	assert.True(t, isRelay)
+	assert.Equal(t, relayLitePub, saved.Inbox)
+	assert.Equal(t, dal.RelayAccepted, saved.Status)
+}
+
+func Test_Relays_Sync_Subscriptions(t *testing.T) {
+
+	h := setupRelaysTest(t, []shared.Relay{{Inbox: relayLitePub, Actor: relayLitePubAct}})
+	defer h.ctrl.Finish()
+
+	h.mockRepo.EXPECT().GetRelays().Return([]*dal.Relay{
+		{Inbox: relayInbox, FollowId: "https://parrot.example/activity/1",
+			FollowObject: "https://relay.example/actor", Status: dal.RelayAccepted},
+	}, nil).Times(1)
+
+	var undo, follow *dto.ActivityOut
+	h.mockMessenger.EXPECT().EnqueueActivity("birb", relayInbox, gomock.Any(), logic.PriorityHigh).
+		Do(func(_, _ string, act *dto.ActivityOut, _ int) { undo = act }).Return(nil).Times(1)
+	h.mockRepo.EXPECT().DeleteRelay(relayInbox).Return(nil).Times(1)
+	h.mockMessenger.EXPECT().EnqueueActivity("birb", relayLitePub, gomock.Any(), logic.PriorityHigh).
+		Do(func(_, _ string, act *dto.ActivityOut, _ int) { follow = act }).Return(nil).Times(1)
+	var saved *dal.Relay
+	h.mockRepo.EXPECT().SaveRelay(gomock.Any()).Do(func(r *dal.Relay) { saved = r }).Return(nil).Times(1)
+
+	h.rl.SyncSubscriptions()
+
+	// The Undo carries the Follow we originally sent
+	assert.Equal(t, "Undo", undo.Type)
+	undoObj := undo.Object.(map[string]any)
+	assert.Equal(t, "https://parrot.example/activity/1", undoObj["id"])
+	assert.Equal(t, "https://relay.example/actor", undoObj["object"])
+
+	assert.Equal(t, "Follow", follow.Type)
+	assert.Equal(t, relayLitePubAct, follow.Object)
+	assert.Equal(t, follow.Id, saved.FollowId)
+	assert.Equal(t, relayLitePubAct, saved.FollowObject)
+	assert.Equal(t, dal.RelayPending, saved.Status)
+}
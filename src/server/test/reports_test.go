@@ -0,0 +1,91 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/dto"
+	"rss_parrot/logic"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"testing"
+)
+
+type reportsHarness struct {
+	cfg              *shared.Config
+	mockLogger       *mocks.MockILogger
+	mockRepo         *mocks.MockIRepo
+	mockMetrics      *mocks.MockIMetrics
+	mockMessenger    *mocks.MockIMessenger
+	mockFF           *mocks.MockIFeedFollower
+	mockBlockedFeeds *mocks.MockIBlockedFeeds
+}
+
+func setupReportsTest(t *testing.T) (*gomock.Controller, *reportsHarness, logic.IReports) {
+
+	ctrl := gomock.NewController(t)
+
+	h := &reportsHarness{
+		cfg:              &shared.Config{Host: "parrot.net", Birb: &shared.UserInfo{User: "birb"}},
+		mockLogger:       mocks.NewMockILogger(ctrl),
+		mockRepo:         mocks.NewMockIRepo(ctrl),
+		mockMetrics:      mocks.NewMockIMetrics(ctrl),
+		mockMessenger:    mocks.NewMockIMessenger(ctrl),
+		mockFF:           mocks.NewMockIFeedFollower(ctrl),
+		mockBlockedFeeds: mocks.NewMockIBlockedFeeds(ctrl),
+	}
+	setupDummyLogger(h.mockLogger)
+	setupDummyMetrics(h.mockMetrics)
+	h.mockRepo.EXPECT().GetOpenReportCount().Return(1, nil).AnyTimes()
+
+	rp := logic.NewReports(h.cfg, h.mockLogger, h.mockRepo, h.mockMetrics, h.mockMessenger, h.mockFF, h.mockBlockedFeeds)
+	return ctrl, h, rp
+}
+
+func Test_Reports_Delete_Status(t *testing.T) {
+
+	ctrl, h, rp := setupReportsTest(t)
+	defer ctrl.Finish()
+
+	const statusId = "https://parrot.net/u/some.blog.com/status/1234"
+	h.mockRepo.EXPECT().GetReport(gomock.Eq(3)).Return(&dal.Report{
+		Id:            3,
+		AccountHandle: "some.blog.com",
+		StatusIds:     []string{statusId},
+	}, nil)
+	h.mockRepo.EXPECT().GetFollowersByUser(gomock.Eq("some.blog.com"), gomock.Eq(true)).Return([]*dal.FollowerInfo{
+		{UserUrl: "https://one.social/users/a", UserInbox: "https://one.social/users/a/inbox", SharedInbox: "https://one.social/inbox"},
+		{UserUrl: "https://one.social/users/b", UserInbox: "https://one.social/users/b/inbox", SharedInbox: "https://one.social/inbox"},
+	}, nil)
+	h.mockRepo.EXPECT().DeleteToot(gomock.Eq(statusId)).Return(nil).Times(1)
+	h.mockMessenger.EXPECT().EnqueueActivityToMany(gomock.Eq("some.blog.com"), gomock.Eq([]string{"https://one.social/inbox"}),
+		gomock.Any(), gomock.Eq(logic.PriorityHigh)).
+		DoAndReturn(func(byUser string, toInboxes []string, act *dto.ActivityOut, priority int) error {
+			assert.Equal(t, "Delete", act.Type)
+			assert.Equal(t, "https://parrot.net/u/some.blog.com", act.Actor)
+			assert.Equal(t, statusId, act.Object.(dto.Tombstone).Id)
+			return nil
+		}).Times(1)
+	h.mockRepo.EXPECT().ResolveReport(gomock.Eq(3), gomock.Eq(logic.ReportDeleteStatus), gomock.Any()).Return(nil).Times(1)
+
+	found, err := rp.Resolve(3, logic.ReportDeleteStatus)
+	assert.Nil(t, err)
+	assert.True(t, found)
+}
+
+func Test_Reports_Block_Feed(t *testing.T) {
+
+	ctrl, h, rp := setupReportsTest(t)
+	defer ctrl.Finish()
+
+	acct := &dal.Account{Id: 5, Handle: "some.blog.com", FeedUrl: "https://some.blog.com/feed"}
+	h.mockRepo.EXPECT().GetReport(gomock.Eq(4)).Return(&dal.Report{Id: 4, AccountHandle: acct.Handle}, nil)
+	h.mockRepo.EXPECT().GetAccount(gomock.Eq(acct.Handle)).Return(acct, nil)
+	h.mockBlockedFeeds.EXPECT().AddBlock(dal.FeedBlockUrl, acct.FeedUrl, gomock.Any()).Return(&dal.FeedBlock{}, nil).Times(1)
+	h.mockFF.EXPECT().PurgeAccount(gomock.Eq(acct)).Return(nil).Times(1)
+	h.mockRepo.EXPECT().ResolveReport(gomock.Eq(4), gomock.Eq(logic.ReportBlockFeed), gomock.Any()).Return(nil).Times(1)
+
+	found, err := rp.Resolve(4, logic.ReportBlockFeed)
+	assert.Nil(t, err)
+	assert.True(t, found)
+}
@@ -61,6 +61,9 @@ func setupDummyMetrics(mockMetrics *mocks.MockIMetrics) {
 	mockMetrics.EXPECT().TotalPosts(gomock.Any()).AnyTimes()
 	mockMetrics.EXPECT().PostsDeleted(gomock.Any()).AnyTimes()
 	mockMetrics.EXPECT().CheckableFeedCount(gomock.Any()).AnyTimes()
+	mockMetrics.EXPECT().OpenReports(gomock.Any()).AnyTimes()
+	mockMetrics.EXPECT().InboundQueueLength(gomock.Any()).AnyTimes()
+	mockMetrics.EXPECT().InboundProcessed(gomock.Any(), gomock.Any()).AnyTimes()
 }
 
 func checkStrSlice(items []string) func(x any) bool {
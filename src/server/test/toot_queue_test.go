@@ -0,0 +1,72 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"rss_parrot/dal"
+	"rss_parrot/shared"
+	"rss_parrot/test/mocks"
+	"testing"
+	"time"
+)
+
+func getQueueIds(items []*dal.TootQueueItem) []int {
+	res := make([]int, 0, len(items))
+	for _, item := range items {
+		res = append(res, item.Id)
+	}
+	return res
+}
+
+// Picking queue items is all SQL, so this runs against a real database
+func Test_Toot_Queue_Selection(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockLogger := mocks.NewMockILogger(ctrl)
+	setupDummyLogger(mockLogger)
+	cfg := &shared.Config{Host: birbHost, Birb: &shared.UserInfo{User: "birb"}, DbFile: t.TempDir() + "/parrot.db"}
+	repo := dal.NewRepo(cfg, mockLogger)
+	repo.InitUpdateDb()
+
+	// IDs 1 to 3 go to a.social, 4 and 5 to b.social, and 6 is a reply to c.social
+	for _, item := range []struct {
+		host     string
+		priority int
+	}{{"a.social", 0}, {"a.social", 0}, {"a.social", 0}, {"b.social", 0}, {"b.social", 0}, {"c.social", 10}} {
+		assert.Nil(t, repo.AddTootQueueItem(&dal.TootQueueItem{
+			SendingUser: "some.blog.com",
+			ToInbox:     "https://" + item.host + "/inbox",
+			ToHost:      item.host,
+			Priority:    item.priority,
+			TootedAt:    time.Now().UTC(),
+			Activity:    "{}",
+		}))
+	}
+	due := time.Now().UTC().Add(time.Minute)
+
+	// High priority first; then hosts take turns
+	items, count, err := repo.GetTootQueueItems(due, nil, nil, 2, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, count)
+	assert.Equal(t, []int{6, 1, 4, 2, 5}, getQueueIds(items))
+
+	items, _, _ = repo.GetTootQueueItems(due, nil, nil, 1, 10)
+	assert.Equal(t, []int{6, 1, 4}, getQueueIds(items))
+
+	items, _, _ = repo.GetTootQueueItems(due, nil, nil, 2, 2)
+	assert.Equal(t, []int{6, 1}, getQueueIds(items))
+
+	// Items in flight don't count towards their host's share
+	items, _, _ = repo.GetTootQueueItems(due, []int{2}, []string{"b.social"}, 2, 10)
+	assert.Equal(t, []int{6, 1, 3}, getQueueIds(items))
+
+	items, _, _ = repo.GetTootQueueItems(due, []int{1, 6}, []string{"a.social", "c.social"}, 2, 10)
+	assert.Equal(t, []int{4, 5}, getQueueIds(items))
+
+	// Item waiting for its retry is not picked until it's due
+	assert.Nil(t, repo.RescheduleTootQueueItem(1, 1, due.Add(time.Hour), "timeout"))
+	items, count, _ = repo.GetTootQueueItems(due, nil, nil, 1, 10)
+	assert.Equal(t, 6, count)
+	assert.Equal(t, []int{6, 2, 4}, getQueueIds(items))
+}